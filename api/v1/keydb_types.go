@@ -18,6 +18,7 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -32,33 +33,403 @@ type KeydbSpec struct {
 	// Image defines the container image to use.
 	// +kubebuilder:validation:MinLength=1
 	Image string `json:"image,omitempty"`
+	// ImageFlavor is the layout of Image: bitnami for the Bitnami images,
+	// upstream for eqalpha/keydb, or custom for an image described by
+	// ImageProfile. It decides where keydb.conf and the data are mounted, how
+	// keydb-server is started and how it receives the password.
+	// +kubebuilder:validation:Enum=bitnami;upstream;custom
+	// +kubebuilder:default=bitnami
+	// +optional
+	ImageFlavor string `json:"imageFlavor,omitempty"`
+	// ImageProfile describes the layout of a custom image, e.g. an internal
+	// hardened build. Only used with the custom flavor.
+	// +optional
+	ImageProfile *ImageProfileSpec `json:"imageProfile,omitempty"`
 	// +kubebuilder:validation:Minimum=1
 	Replicas    *int32          `json:"replicas,omitempty"`
 	Replication ReplicationSpec `json:"replication,omitempty"`
 	Persistence PersistenceSpec `json:"persistence,omitempty"`
 	// PasswordSecret is a reference to the secret containing the password for KeyDB.
-	// If not specified, a random password will be generated and stored in a new Secret.
+	// If not specified, a random password will be generated and stored in a new Secret,
+	// which can be rotated without downtime with the keydb.keydb/rotate-password annotation.
 	// +optional
 	PasswordSecret *corev1.SecretKeySelector `json:"passwordSecret,omitempty"`
 	// Resources defines the resource requests and limits for KeyDB pods
 	// +optional
 	Resources corev1.ResourceRequirements `json:"resources,omitempty"`
+	// NodeSelector constrains the KeyDB pods to nodes with these labels, e.g.
+	// a dedicated memory-optimized node pool.
+	// +optional
+	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+	// Tolerations let the KeyDB pods, and the backup and restore Jobs that
+	// run on their nodes, use tainted nodes.
+	// +optional
+	Tolerations []corev1.Toleration `json:"tolerations,omitempty"`
+	// Affinity adds node affinity, pod affinity and pod anti-affinity terms.
+	// Its pod anti-affinity terms are added to the one PodAntiAffinity selects.
+	// +optional
+	Affinity *corev1.Affinity `json:"affinity,omitempty"`
+	// PodAntiAffinity keeps the pods on different nodes: preferred tries to,
+	// required does not schedule a pod on a node that runs another one, and
+	// none leaves placement to Affinity.
+	// +kubebuilder:validation:Enum=preferred;required;none
+	// +kubebuilder:default=preferred
+	// +optional
+	PodAntiAffinity string `json:"podAntiAffinity,omitempty"`
+	// TopologySpreadConstraints spread the pods, e.g. across zones with
+	// topology.kubernetes.io/zone. A constraint without a labelSelector
+	// selects the pods of this Keydb.
+	// +optional
+	TopologySpreadConstraints []corev1.TopologySpreadConstraint `json:"topologySpreadConstraints,omitempty"`
+	// PriorityClassName of the KeyDB pods.
+	// +optional
+	PriorityClassName string `json:"priorityClassName,omitempty"`
+	// SecurityContext sets the user, group and seccomp profile of the KeyDB
+	// pods and of the backup and restore Jobs that read their data. The pods
+	// meet the restricted Pod Security Standard whatever is set here.
+	// +optional
+	SecurityContext *SecurityContextSpec `json:"securityContext,omitempty"`
+	// PodTemplate customizes the pods beyond what the other fields cover, e.g.
+	// to add a log shipper or the labels a platform requires. It is merged
+	// into the generated pod template like kubectl apply merges a patch.
+	// +optional
+	PodTemplate *PodTemplateOverride `json:"podTemplate,omitempty"`
+	// Config sets keydb.conf directives, e.g. maxmemory, maxmemory-policy or
+	// save, overriding the operator's defaults. Most directives are applied to
+	// running pods; those KeyDB only reads at startup, such as server-threads,
+	// roll the pods one at a time, as does adding or removing a directive.
+	// Directives the operator manages, such as replicaof, dir, bind, port,
+	// appendonly and the dbfilename, appendfilename and appenddirname that
+	// backups and restores rely on, are rejected.
+	// +optional
+	Config map[string]string `json:"config,omitempty"`
 	// Metrics enables exposing Prometheus metrics via an exporter sidecar
 	// +optional
 	Metrics MetricsSpec `json:"metrics,omitempty"`
+	// Service controls how clients outside the cluster, and master-master
+	// peers in other clusters, reach KeyDB.
+	// +optional
+	Service ServiceSpec `json:"service,omitempty"`
+	// NetworkPolicy has the operator generate a NetworkPolicy that only lets
+	// the listed clients, the operator, backups and replication peers reach
+	// the pods. Removing it deletes the NetworkPolicy.
+	// +optional
+	NetworkPolicy *NetworkPolicySpec `json:"networkPolicy,omitempty"`
+	// TLS serves client and replication traffic over TLS on port 6379
+	// instead of plaintext.
+	// +optional
+	TLS *TLSSpec `json:"tls,omitempty"`
+	// Restore seeds the data directory of the first pod from a backup when the
+	// Keydb is created. Other pods then do a full sync from it. Requires persistence.
+	// +optional
+	Restore *RestoreSpec `json:"restore,omitempty"`
+}
+
+// ImageProfileSpec describes a custom KeyDB image. The image must provide
+// bash, keydb-server and keydb-cli; the password is read from the file named
+// by KEYDB_PASSWORD_FILE.
+type ImageProfileSpec struct {
+	// Server is the keydb-server binary. Defaults to keydb-server on the PATH.
+	// +optional
+	Server string `json:"server,omitempty"`
+	// ConfigDir is where keydb.conf is mounted. Defaults to /etc/keydb.
+	// +optional
+	ConfigDir string `json:"configDir,omitempty"`
+	// DataDir is the data directory. Defaults to /data.
+	// +optional
+	DataDir string `json:"dataDir,omitempty"`
+	// EnvScript is sourced before keydb-server starts and by the probes, e.g.
+	// to set PATH or LD_LIBRARY_PATH.
+	// +optional
+	EnvScript string `json:"envScript,omitempty"`
+}
+
+// SecurityContextSpec overrides the identity the KeyDB containers run as,
+// e.g. for images such as eqalpha/keydb that do not use the Bitnami UID 1001.
+type SecurityContextSpec struct {
+	// RunAsUser is the UID of all containers. Defaults to 1001.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RunAsUser *int64 `json:"runAsUser,omitempty"`
+	// RunAsGroup is the primary GID of all containers. Defaults to 1001.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RunAsGroup *int64 `json:"runAsGroup,omitempty"`
+	// FSGroup owns the data volume. Defaults to 1001.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	FSGroup *int64 `json:"fsGroup,omitempty"`
+	// SeccompProfile of the pods. Defaults to RuntimeDefault; the restricted
+	// standard also allows Localhost.
+	// +optional
+	SeccompProfile *corev1.SeccompProfile `json:"seccompProfile,omitempty"`
+	// ReadOnlyRootFilesystem mounts the root filesystem of the KeyDB pod's
+	// containers read-only. Defaults to true; turn it off for images that
+	// write outside /tmp and the data directory.
+	// +optional
+	ReadOnlyRootFilesystem *bool `json:"readOnlyRootFilesystem,omitempty"`
+}
+
+// TLSSpec selects the certificate KeyDB serves. Exactly one of SecretName or
+// CertManager must be set. The certificate is also used as the client
+// certificate for replication, so peers of a master-master cluster in other
+// namespaces must trust the same CA.
+type TLSSpec struct {
+	// SecretName is an existing kubernetes.io/tls Secret with tls.crt, tls.key
+	// and ca.crt. Its certificate must be valid for the Service and pod DNS names.
+	// +optional
+	SecretName string `json:"secretName,omitempty"`
+	// CertManager has the operator request the certificate from cert-manager.
+	// +optional
+	CertManager *CertManagerSpec `json:"certManager,omitempty"`
+	// AuthClients controls whether clients must present a certificate signed
+	// by the CA: no, optional or yes.
+	// +kubebuilder:validation:Enum=no;optional;yes
+	// +kubebuilder:default=no
+	// +optional
+	AuthClients string `json:"authClients,omitempty"`
+}
+
+// CertManagerSpec describes the cert-manager Certificate the operator creates.
+// The Certificate and its Secret are named <name>-tls.
+type CertManagerSpec struct {
+	// IssuerRef is the Issuer or ClusterIssuer that signs the certificate.
+	IssuerRef IssuerReference `json:"issuerRef"`
+	// Duration is the requested lifetime of the certificate, e.g. 2160h.
+	// +optional
+	Duration *metav1.Duration `json:"duration,omitempty"`
+	// RenewBefore is how long before expiry the certificate is renewed.
+	// +optional
+	RenewBefore *metav1.Duration `json:"renewBefore,omitempty"`
+}
+
+// IssuerReference points at a cert-manager issuer.
+type IssuerReference struct {
+	// Name of the issuer.
+	// +kubebuilder:validation:MinLength=1
+	Name string `json:"name"`
+	// Kind is Issuer or ClusterIssuer.
+	// +kubebuilder:default=Issuer
+	// +optional
+	Kind string `json:"kind,omitempty"`
+	// Group of the issuer. Defaults to cert-manager.io.
+	// +optional
+	Group string `json:"group,omitempty"`
+}
+
+// RestoreSpec selects where initial data comes from. Exactly one source must be set.
+type RestoreSpec struct {
+	// BackupName restores from a completed KeydbBackup in the same namespace.
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+	// S3 restores an RDB file from an S3-compatible bucket.
+	// +optional
+	S3 *S3RestoreSource `json:"s3,omitempty"`
+	// PersistentVolumeClaim copies dump.rdb and/or AOF files from an existing claim.
+	// +optional
+	PersistentVolumeClaim *PVCRestoreSource `json:"persistentVolumeClaim,omitempty"`
+	// Image is used to download from S3. It must provide sh and the aws CLI.
+	// Defaults to DefaultBackupImage.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// S3RestoreSource is an RDB object in an S3-compatible bucket.
+type S3RestoreSource struct {
+	// Bucket is the name of the bucket.
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+	// Key is the object key of the RDB file.
+	// +kubebuilder:validation:MinLength=1
+	Key string `json:"key"`
+	// Endpoint is the URL of an S3-compatible service. Leave empty for AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Region of the bucket.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// CredentialsSecret holds AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+	CredentialsSecret corev1.LocalObjectReference `json:"credentialsSecret"`
+}
+
+// PVCRestoreSource is an existing claim holding KeyDB data files.
+type PVCRestoreSource struct {
+	// ClaimName is the PersistentVolumeClaim in the same namespace.
+	// +kubebuilder:validation:MinLength=1
+	ClaimName string `json:"claimName"`
+	// SubPath is the directory inside the claim holding the files.
+	// +optional
+	SubPath string `json:"subPath,omitempty"`
+}
+
+// ServiceSpec configures the client-facing Services: <name>-svc and, in
+// master-replica mode, <name>-primary and <name>-replicas. The headless
+// Service used for pod DNS is not affected.
+type ServiceSpec struct {
+	// Type of the client-facing Services. With ClusterIP, <name>-svc stays
+	// headless.
+	// +kubebuilder:validation:Enum=ClusterIP;NodePort;LoadBalancer
+	// +kubebuilder:default=ClusterIP
+	// +optional
+	Type corev1.ServiceType `json:"type,omitempty"`
+	// Annotations are added to the client-facing and per-pod Services, e.g.
+	// to configure the cloud load balancer.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// LoadBalancerSourceRanges restricts the client CIDRs a LoadBalancer
+	// accepts.
+	// +optional
+	LoadBalancerSourceRanges []string `json:"loadBalancerSourceRanges,omitempty"`
+	// ExternalTrafficPolicy of NodePort and LoadBalancer Services. Local
+	// keeps the client source IP.
+	// +kubebuilder:validation:Enum=Cluster;Local
+	// +optional
+	ExternalTrafficPolicy corev1.ServiceExternalTrafficPolicy `json:"externalTrafficPolicy,omitempty"`
+	// PerPod creates a Service of the same type named after every pod, so
+	// each replica can be addressed on its own, e.g. as a spec.replication.domain
+	// of a master-master cluster elsewhere.
+	// +optional
+	PerPod bool `json:"perPod,omitempty"`
+}
+
+// NetworkPolicySpec configures the generated NetworkPolicy, named after the
+// Keydb. Pods of the Keydb, the operator and backup Jobs are always allowed
+// in, and DNS is always allowed out. Replication peers in
+// spec.replication.domain that are Services or IPs are allowed both ways.
+type NetworkPolicySpec struct {
+	// Clients may connect on port 6379. With a NodePort or LoadBalancer
+	// Service, list the client ipBlocks here.
+	// +optional
+	Clients []networkingv1.NetworkPolicyPeer `json:"clients,omitempty"`
+	// MetricsNamespaceSelector selects the namespaces allowed to scrape the
+	// exporter on port 9121. Metrics cannot be scraped when unset.
+	// +optional
+	MetricsNamespaceSelector *metav1.LabelSelector `json:"metricsNamespaceSelector,omitempty"`
+	// ReplicationPeers are where spec.replication.domain hosts outside the
+	// cluster live, e.g. the ipBlocks of their load balancers, since a
+	// NetworkPolicy cannot match host names.
+	// +optional
+	ReplicationPeers []networkingv1.NetworkPolicyPeer `json:"replicationPeers,omitempty"`
+	// Egress adds rules for other destinations the pods need. The S3 endpoint
+	// of spec.restore is allowed until the restore completes: by IP or
+	// Service namespace when the endpoint has one, otherwise by port only.
+	// +optional
+	Egress []networkingv1.NetworkPolicyEgressRule `json:"egress,omitempty"`
+}
+
+// PodTemplateOverride is merged into the generated pod template. Lists are
+// merged by name: a container named keydb, config-reloader or metrics changes
+// the operator's container, any other name adds one. Containers and volumes
+// are left out of the CRD schema, which would otherwise be too large to
+// kubectl apply; the API server validates them on the StatefulSet.
+type PodTemplateOverride struct {
+	// Labels are added to the pods. Labels the operator sets, such as apps,
+	// cannot be overridden.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations are added to the pods.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// Containers are added to, or merged into, the pod's containers.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Containers []corev1.Container `json:"containers,omitempty"`
+	// InitContainers are added to, or merged into, the pod's init containers.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	InitContainers []corev1.Container `json:"initContainers,omitempty"`
+	// Volumes are added to the pod, e.g. for the extra containers.
+	// +optional
+	// +kubebuilder:validation:Schemaless
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Volumes []corev1.Volume `json:"volumes,omitempty"`
+	// VolumeMounts are added to the keydb container.
+	// +optional
+	VolumeMounts []corev1.VolumeMount `json:"volumeMounts,omitempty"`
+	// Env is added to the keydb container.
+	// +optional
+	Env []corev1.EnvVar `json:"env,omitempty"`
+	// ImagePullSecrets are used to pull the images of the pod.
+	// +optional
+	ImagePullSecrets []corev1.LocalObjectReference `json:"imagePullSecrets,omitempty"`
+	// ServiceAccountName replaces the ServiceAccount the operator creates for
+	// the pods.
+	// +optional
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
 }
 
 type MetricsSpec struct {
 	Enabled bool   `json:"enabled"`
 	Image   string `json:"image,omitempty"`
+	// ServiceMonitor has the operator create a Prometheus Operator
+	// ServiceMonitor scraping the exporter of every pod. Requires the
+	// Prometheus Operator CRDs; removing it deletes the ServiceMonitor.
+	// +optional
+	ServiceMonitor *ServiceMonitorSpec `json:"serviceMonitor,omitempty"`
+	// PrometheusRule has the operator create a PrometheusRule alerting when a
+	// pod is down, replication is broken, memory is near maxmemory or
+	// connections are rejected. Requires the Prometheus Operator CRDs.
+	// +optional
+	PrometheusRule *PrometheusRuleSpec `json:"prometheusRule,omitempty"`
+}
+
+// ServiceMonitorSpec configures the generated ServiceMonitor.
+type ServiceMonitorSpec struct {
+	// Interval between scrapes, e.g. 30s. Defaults to Prometheus' global interval.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h))+$`
+	// +optional
+	Interval string `json:"interval,omitempty"`
+	// ScrapeTimeout of each scrape. Must not exceed Interval.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h))+$`
+	// +optional
+	ScrapeTimeout string `json:"scrapeTimeout,omitempty"`
+	// Labels are added to the ServiceMonitor, e.g. the release label the
+	// Prometheus serviceMonitorSelector matches.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// PrometheusRuleSpec configures the generated alerts.
+type PrometheusRuleSpec struct {
+	// Labels are added to the PrometheusRule, e.g. the label the Prometheus
+	// ruleSelector matches.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// AlertLabels are added to every alert, e.g. severity or team routing labels.
+	// +optional
+	AlertLabels map[string]string `json:"alertLabels,omitempty"`
+	// For is how long a condition must hold before an alert fires.
+	// +kubebuilder:validation:Pattern=`^([0-9]+(ms|s|m|h))+$`
+	// +kubebuilder:default="5m"
+	// +optional
+	For string `json:"for,omitempty"`
+	// MemoryUsagePercent of maxmemory above which KeydbMemoryNearLimit fires.
+	// Only applies to pods with maxmemory set.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	// +kubebuilder:default=90
+	// +optional
+	MemoryUsagePercent int32 `json:"memoryUsagePercent,omitempty"`
+	// RejectedConnections is how many connections a pod may reject in five
+	// minutes, e.g. because of maxclients, before KeydbRejectedConnections fires.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	RejectedConnections int32 `json:"rejectedConnections,omitempty"`
 }
 
 type ReplicationSpec struct {
-	Enabled bool             `json:"enabled"`
-	Mode    string           `json:"mode,omitempty"`
-	Domain  []string         `json:"domain,omitempty"`
-	Keydb   KeydbAddressSpec `json:"keydb,omitempty"`
-	Port    int32            `json:"port,omitempty"`
+	Enabled bool `json:"enabled"`
+	// Mode selects the replication topology. In master-replica mode a single
+	// pod is the writable primary and every other pod replicates from it.
+	// +kubebuilder:validation:Enum=master-replica;master-master
+	// +optional
+	Mode string `json:"mode,omitempty"`
+	// Domain lists remote KeyDB hosts to replicate from in master-master mode.
+	Domain []string         `json:"domain,omitempty"`
+	Keydb  KeydbAddressSpec `json:"keydb,omitempty"`
+	Port   int32            `json:"port,omitempty"`
 }
 type PersistenceSpec struct {
 	Enabled          bool   `json:"enabled"`
@@ -89,6 +460,136 @@ type KeydbStatus struct {
 	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 	// LastUpdateTime is the last time the status was updated
 	LastUpdateTime *metav1.Time `json:"lastUpdateTime,omitempty"`
+	// Primary is the pod currently acting as the writable primary in master-replica mode
+	// +optional
+	Primary string `json:"primary,omitempty"`
+	// Nodes reports the replication state KeyDB itself sees on each running pod
+	// +optional
+	Nodes []NodeStatus `json:"nodes,omitempty"`
+	// Restore reports the progress of spec.restore
+	// +optional
+	Restore *RestoreStatus `json:"restore,omitempty"`
+	// ConfigReload reports how the config reloader of each running pod applied
+	// the latest keydb.conf change
+	// +optional
+	ConfigReload []ConfigReloadStatus `json:"configReload,omitempty"`
+	// PasswordRotation reports the progress of the latest password rotation
+	// +optional
+	PasswordRotation *PasswordRotationStatus `json:"passwordRotation,omitempty"`
+}
+
+// PasswordRotationStatus tracks a rotation of the generated password Secret
+type PasswordRotationStatus struct {
+	// Step is one of AddPassword, UpdateMasterauth, RollClients,
+	// RemoveOldPassword or Completed
+	Step string `json:"step"`
+	// Trigger is the keydb.keydb/rotate-password annotation value the rotation was started for
+	Trigger string `json:"trigger"`
+	// Message explains the current step
+	// +optional
+	Message string `json:"message,omitempty"`
+	// StartTime is when the rotation started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// LastTransitionTime is when Step last changed
+	// +optional
+	LastTransitionTime *metav1.Time `json:"lastTransitionTime,omitempty"`
+	// CompletionTime is when the old password was removed
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// RestoreStatus reports where the initial data came from and how far the restore got
+type RestoreStatus struct {
+	// Phase is one of Pending, Restoring, Completed, Skipped or Failed
+	Phase string `json:"phase"`
+	// Source describes what is being restored
+	// +optional
+	Source string `json:"source,omitempty"`
+	// S3 is the object being restored, resolved from spec.restore. Keeping it
+	// here keeps the pod template stable if the backup is deleted later.
+	// +optional
+	S3 *S3RestoreSource `json:"s3,omitempty"`
+	// Pod is the pod the data was seeded into
+	// +optional
+	Pod string `json:"pod,omitempty"`
+	// Message explains the current phase
+	// +optional
+	Message string `json:"message,omitempty"`
+	// CompletionTime is when the data was seeded
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+	// AppendOnlyRestored is set once Pod runs with AOF again after loading
+	// the restored dump.rdb without it
+	// +optional
+	AppendOnlyRestored bool `json:"appendOnlyRestored,omitempty"`
+}
+
+// NodeStatus is the per-pod view built from INFO replication, server and memory
+type NodeStatus struct {
+	// Pod is the name of the pod
+	Pod string `json:"pod"`
+	// Role is the role reported by KeyDB (master, slave or active-replica)
+	// +optional
+	Role string `json:"role,omitempty"`
+	// MasterHost is the primary this pod replicates from, if any
+	// +optional
+	MasterHost string `json:"masterHost,omitempty"`
+	// LinkStatus is the state of the link to MasterHost (up or down)
+	// +optional
+	LinkStatus string `json:"linkStatus,omitempty"`
+	// ConnectedReplicas is the number of replicas attached to this pod
+	// +optional
+	ConnectedReplicas int32 `json:"connectedReplicas,omitempty"`
+	// ReplicationOffset is the master_repl_offset of this pod
+	// +optional
+	ReplicationOffset int64 `json:"replicationOffset,omitempty"`
+	// LagBytes is how far this replica is behind its in-cluster primary
+	// +optional
+	LagBytes *int64 `json:"lagBytes,omitempty"`
+	// LagSeconds is the time since this replica last heard from its primary
+	// +optional
+	LagSeconds *int64 `json:"lagSeconds,omitempty"`
+	// Version is the KeyDB server version
+	// +optional
+	Version string `json:"version,omitempty"`
+	// UsedMemory is the human readable memory used by the dataset
+	// +optional
+	UsedMemory string `json:"usedMemory,omitempty"`
+	// PersistenceError is set when the last RDB save or AOF write failed
+	// +optional
+	PersistenceError string `json:"persistenceError,omitempty"`
+	// Error is set when the pod could not be queried
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// ConfigReloadStatus is what the config reloader sidecar of a pod reports
+type ConfigReloadStatus struct {
+	// Pod is the name of the pod
+	Pod string `json:"pod"`
+	// UpToDate is true once the pod has processed the current keydb.conf
+	UpToDate bool `json:"upToDate"`
+	// LastReloadTime is when a change was last applied to the pod
+	// +optional
+	LastReloadTime *metav1.Time `json:"lastReloadTime,omitempty"`
+	// Failed lists the directives KeyDB rejected
+	// +optional
+	Failed []DirectiveError `json:"failed,omitempty"`
+	// PendingRestart lists changed directives KeyDB only reads at startup
+	// +optional
+	PendingRestart []string `json:"pendingRestart,omitempty"`
+	// Error is set when the reloader could not be queried
+	// +optional
+	Error string `json:"error,omitempty"`
+}
+
+// DirectiveError is a keydb.conf directive KeyDB refused to apply
+type DirectiveError struct {
+	// Directive is the rejected directive
+	Directive string `json:"directive"`
+	// Error is the reply of CONFIG SET
+	Error string `json:"error"`
 }
 
 // ReplicaStatus represents the status of individual replicas
@@ -98,12 +599,93 @@ type ReplicaStatus struct {
 	Failed   []string `json:"failed,omitempty"`
 }
 
+// Replication modes
+const (
+	ReplicationModeMasterReplica = "master-replica"
+	ReplicationModeMasterMaster  = "master-master"
+)
+
+// Pod anti-affinity modes
+const (
+	PodAntiAffinityPreferred = "preferred"
+	PodAntiAffinityRequired  = "required"
+	PodAntiAffinityNone      = "none"
+)
+
+// Role label maintained by the operator on pods in master-replica mode
+const (
+	LabelRole   = "keydb.keydb/role"
+	RolePrimary = "primary"
+	RoleReplica = "replica"
+)
+
+// Traffic label maintained by the operator. Pods start with TrafficEnabled
+// from the StatefulSet template and the client-facing Services only select
+// those, so switching it to TrafficDisabled drains a pod without touching
+// replication over the headless Service.
+const (
+	LabelTraffic    = "keydb.keydb/traffic"
+	TrafficEnabled  = "enabled"
+	TrafficDisabled = "disabled"
+)
+
+// LabelPodService marks the per-pod Services of spec.service.perPod so the
+// operator can remove those of pods that no longer exist.
+const LabelPodService = "keydb.keydb/pod-service"
+
+// LabelMetrics marks the Service the generated ServiceMonitor scrapes, so
+// every pod is scraped once.
+const LabelMetrics = "keydb.keydb/metrics"
+
+// AnnotationRotatePassword on a Keydb rotates its generated password Secret
+// whenever the value changes. Any value works; a timestamp is customary.
+const AnnotationRotatePassword = "keydb.keydb/rotate-password"
+
+// Password rotation steps. Both passwords are accepted from AddPassword until
+// RemoveOldPassword, so clients and replicas can move over one at a time.
+const (
+	RotationStepAddPassword       = "AddPassword"
+	RotationStepUpdateMasterauth  = "UpdateMasterauth"
+	RotationStepRollClients       = "RollClients"
+	RotationStepRemoveOldPassword = "RemoveOldPassword"
+	RotationStepCompleted         = "Completed"
+)
+
+// Restore phases
+const (
+	RestorePhasePending   = "Pending"
+	RestorePhaseRestoring = "Restoring"
+	RestorePhaseCompleted = "Completed"
+	RestorePhaseSkipped   = "Skipped"
+	RestorePhaseFailed    = "Failed"
+)
+
+// Image flavors
+const (
+	ImageFlavorBitnami  = "bitnami"
+	ImageFlavorUpstream = "upstream"
+	ImageFlavorCustom   = "custom"
+)
+
+// Defaults applied by the defaulting webhook
+const (
+	DefaultImage        = "docker.io/bitnamilegacy/keydb:6.3.4-debian-12-r24"
+	DefaultMetricsImage = "oliver006/redis_exporter:latest"
+	// DefaultUpstreamImage is the default image of the upstream flavor
+	DefaultUpstreamImage = "eqalpha/keydb:x86_64_v6.3.4"
+	DefaultReplicas      = int32(1)
+	DefaultPort          = int32(6379)
+	// DefaultUID is the user, group and fsGroup of the Bitnami image
+	DefaultUID = int64(1001)
+)
+
 // Condition types
 const (
 	ConditionTypeReady       = "Ready"
 	ConditionTypeDegraded    = "Degraded"
 	ConditionTypeProgressing = "Progressing"
 	ConditionTypeReconciled  = "Reconciled"
+	ConditionTypeFailover    = "Failover"
 )
 
 // Condition reasons
@@ -114,6 +696,11 @@ const (
 	ReasonScalingDown          = "ScalingDown"
 	ReasonAllReplicasReady     = "AllReplicasReady"
 	ReasonSomeReplicasNotReady = "SomeReplicasNotReady"
+	ReasonPrimaryFailover      = "PrimaryFailover"
+	ReasonPrimaryHealthy       = "PrimaryHealthy"
+	ReasonHealthy              = "Healthy"
+	ReasonReplicationUnhealthy = "ReplicationUnhealthy"
+	ReasonPersistenceUnhealthy = "PersistenceUnhealthy"
 )
 
 // +kubebuilder:object:root=true
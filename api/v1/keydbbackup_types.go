@@ -0,0 +1,165 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeydbBackupSpec defines the desired state of KeydbBackup.
+type KeydbBackupSpec struct {
+	// KeydbName is the Keydb in the same namespace to back up.
+	// +kubebuilder:validation:MinLength=1
+	KeydbName string `json:"keydbName"`
+	// Source selects the pod the snapshot is taken on. Taking it on a replica
+	// keeps the fork off the primary.
+	// +kubebuilder:validation:Enum=primary;replica
+	// +kubebuilder:default=primary
+	// +optional
+	Source string `json:"source,omitempty"`
+	// Pod takes the snapshot on a specific pod of the Keydb, overriding Source.
+	// +optional
+	Pod string `json:"pod,omitempty"`
+	// Storage is where the snapshot is uploaded.
+	Storage BackupStorageSpec `json:"storage"`
+	// DeletionPolicy controls what happens to the uploaded snapshot when the
+	// KeydbBackup is deleted: Retain keeps it, Delete removes it from the
+	// bucket. Defaults to Retain; backups created by a schedule default to Delete.
+	// +kubebuilder:validation:Enum=Retain;Delete
+	// +optional
+	DeletionPolicy string `json:"deletionPolicy,omitempty"`
+	// Image is the image of the upload container. It must provide sh,
+	// sha256sum and the aws CLI. Defaults to DefaultBackupImage.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// BackupStorageSpec selects the backup destination.
+type BackupStorageSpec struct {
+	// S3 uploads to an S3-compatible bucket such as AWS S3 or MinIO.
+	S3 S3StorageSpec `json:"s3"`
+}
+
+// S3StorageSpec describes an S3-compatible bucket.
+type S3StorageSpec struct {
+	// Bucket is the name of the bucket.
+	// +kubebuilder:validation:MinLength=1
+	Bucket string `json:"bucket"`
+	// Prefix is prepended to the object key.
+	// +optional
+	Prefix string `json:"prefix,omitempty"`
+	// Endpoint is the URL of an S3-compatible service, e.g. http://minio.minio:9000.
+	// Leave empty for AWS S3.
+	// +optional
+	Endpoint string `json:"endpoint,omitempty"`
+	// Region of the bucket.
+	// +optional
+	Region string `json:"region,omitempty"`
+	// CredentialsSecret holds AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.
+	CredentialsSecret corev1.LocalObjectReference `json:"credentialsSecret"`
+}
+
+// KeydbBackupStatus defines the observed state of KeydbBackup.
+type KeydbBackupStatus struct {
+	// Phase is one of Pending, Running, Completed or Failed
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Message explains the current phase
+	// +optional
+	Message string `json:"message,omitempty"`
+	// SourcePod is the pod the snapshot was taken on
+	// +optional
+	SourcePod string `json:"sourcePod,omitempty"`
+	// ReplicationOffset is the replication offset of SourcePod right after the snapshot completed
+	// +optional
+	ReplicationOffset int64 `json:"replicationOffset,omitempty"`
+	// Location is the URL of the uploaded snapshot
+	// +optional
+	Location string `json:"location,omitempty"`
+	// Size is the size of the snapshot in bytes
+	// +optional
+	Size int64 `json:"size,omitempty"`
+	// Checksum is the sha256 of the snapshot, prefixed with "sha256:"
+	// +optional
+	Checksum string `json:"checksum,omitempty"`
+	// StartTime is when BGSAVE was requested
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// PreviousSaveTime is the LASTSAVE of SourcePod before BGSAVE was
+	// requested, in unix seconds of the pod's clock
+	// +optional
+	PreviousSaveTime int64 `json:"previousSaveTime,omitempty"`
+	// SnapshotTime is when KeyDB finished writing the snapshot
+	// +optional
+	SnapshotTime *metav1.Time `json:"snapshotTime,omitempty"`
+	// CompletionTime is when the upload finished
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// Backup sources
+const (
+	BackupSourcePrimary = "primary"
+	BackupSourceReplica = "replica"
+)
+
+// Backup phases
+const (
+	BackupPhasePending   = "Pending"
+	BackupPhaseRunning   = "Running"
+	BackupPhaseCompleted = "Completed"
+	BackupPhaseFailed    = "Failed"
+)
+
+// Backup deletion policies
+const (
+	BackupDeletionPolicyRetain = "Retain"
+	BackupDeletionPolicyDelete = "Delete"
+)
+
+// DefaultBackupImage is the upload image used when KeydbBackupSpec.Image is empty
+const DefaultBackupImage = "amazon/aws-cli:2.17.0"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Keydb",type=string,JSONPath=`.spec.keydbName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Size",type=integer,JSONPath=`.status.size`
+// +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completionTime`
+
+// KeydbBackup is the Schema for the keydbbackups API.
+type KeydbBackup struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeydbBackupSpec   `json:"spec,omitempty"`
+	Status KeydbBackupStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KeydbBackupList contains a list of KeydbBackup.
+type KeydbBackupList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeydbBackup `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeydbBackup{}, &KeydbBackupList{})
+}
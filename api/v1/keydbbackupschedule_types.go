@@ -0,0 +1,140 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeydbBackupScheduleSpec defines the desired state of KeydbBackupSchedule.
+type KeydbBackupScheduleSpec struct {
+	// Schedule is a cron expression in the standard five-field format, or a
+	// descriptor such as @daily. Times are UTC.
+	// +kubebuilder:validation:MinLength=1
+	Schedule string `json:"schedule"`
+	// Suspend stops new backups from being created. Retention still applies.
+	// +optional
+	Suspend bool `json:"suspend,omitempty"`
+	// ConcurrencyPolicy decides what to do when a backup is still running at
+	// the next scheduled time: Allow runs both, Forbid skips the new one and
+	// Replace deletes the running one.
+	// +kubebuilder:validation:Enum=Allow;Forbid;Replace
+	// +kubebuilder:default=Forbid
+	// +optional
+	ConcurrencyPolicy string `json:"concurrencyPolicy,omitempty"`
+	// BackupTemplate is the spec of the KeydbBackups created by this schedule.
+	BackupTemplate KeydbBackupSpec `json:"backupTemplate"`
+	// Retention decides which completed backups are kept. Backups that fall
+	// out of every rule are deleted, which removes their snapshot from the
+	// bucket unless their DeletionPolicy is Retain. With no rules set every
+	// backup is kept.
+	// +optional
+	Retention BackupRetentionSpec `json:"retention,omitempty"`
+	// FailedBackupsHistoryLimit is how many failed backups are kept for inspection.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:default=3
+	// +optional
+	FailedBackupsHistoryLimit *int32 `json:"failedBackupsHistoryLimit,omitempty"`
+}
+
+// BackupRetentionSpec lists retention rules. A completed backup is kept when
+// any rule keeps it.
+type BackupRetentionSpec struct {
+	// KeepLast keeps the N most recent completed backups.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepLast *int32 `json:"keepLast,omitempty"`
+	// KeepDaily keeps the most recent backup of each day for the last N days.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepDaily *int32 `json:"keepDaily,omitempty"`
+	// KeepWeekly keeps the most recent backup of each ISO week for the last N weeks.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	KeepWeekly *int32 `json:"keepWeekly,omitempty"`
+}
+
+// KeydbBackupScheduleStatus defines the observed state of KeydbBackupSchedule.
+type KeydbBackupScheduleStatus struct {
+	// Conditions report whether the schedule is valid and whether the last backup succeeded
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// Active lists the backups that have not finished yet
+	// +optional
+	Active []string `json:"active,omitempty"`
+	// LastScheduleTime is the last time a backup was due
+	// +optional
+	LastScheduleTime *metav1.Time `json:"lastScheduleTime,omitempty"`
+	// LastSuccessfulTime is when the most recent successful backup completed
+	// +optional
+	LastSuccessfulTime *metav1.Time `json:"lastSuccessfulTime,omitempty"`
+	// LastBackup is the most recent backup that finished, successfully or not
+	// +optional
+	LastBackup string `json:"lastBackup,omitempty"`
+	// NextScheduleTime is when the next backup is due
+	// +optional
+	NextScheduleTime *metav1.Time `json:"nextScheduleTime,omitempty"`
+}
+
+// Concurrency policies
+const (
+	ConcurrencyPolicyAllow   = "Allow"
+	ConcurrencyPolicyForbid  = "Forbid"
+	ConcurrencyPolicyReplace = "Replace"
+)
+
+// LabelBackupSchedule is set on the KeydbBackups a schedule creates
+const LabelBackupSchedule = "keydb.keydb/schedule"
+
+// Schedule condition types and reasons
+const (
+	ConditionTypeBackupSucceeded = "BackupSucceeded"
+
+	ReasonScheduleValid   = "ScheduleValid"
+	ReasonInvalidSchedule = "InvalidSchedule"
+	ReasonBackupCompleted = "BackupCompleted"
+	ReasonBackupFailed    = "BackupFailed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Schedule",type=string,JSONPath=`.spec.schedule`
+// +kubebuilder:printcolumn:name="Suspend",type=boolean,JSONPath=`.spec.suspend`
+// +kubebuilder:printcolumn:name="Last Success",type=date,JSONPath=`.status.lastSuccessfulTime`
+// +kubebuilder:printcolumn:name="Next",type=date,JSONPath=`.status.nextScheduleTime`
+
+// KeydbBackupSchedule is the Schema for the keydbbackupschedules API.
+type KeydbBackupSchedule struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeydbBackupScheduleSpec   `json:"spec,omitempty"`
+	Status KeydbBackupScheduleStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KeydbBackupScheduleList contains a list of KeydbBackupSchedule.
+type KeydbBackupScheduleList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeydbBackupSchedule `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeydbBackupSchedule{}, &KeydbBackupScheduleList{})
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeydbRestoreSpec defines the desired state of KeydbRestore.
+type KeydbRestoreSpec struct {
+	// KeydbName is the running Keydb in the same namespace to restore into.
+	// Its current data is replaced.
+	// +kubebuilder:validation:MinLength=1
+	KeydbName string `json:"keydbName"`
+	// BackupName restores from a completed KeydbBackup in the same namespace.
+	// +optional
+	BackupName string `json:"backupName,omitempty"`
+	// S3 restores an RDB file from an S3-compatible bucket.
+	// +optional
+	S3 *S3RestoreSource `json:"s3,omitempty"`
+	// Image is the image of the restore Job. It must provide sh and the aws
+	// CLI. Defaults to DefaultBackupImage.
+	// +optional
+	Image string `json:"image,omitempty"`
+}
+
+// KeydbRestoreStatus defines the observed state of KeydbRestore.
+type KeydbRestoreStatus struct {
+	// Phase is one of Pending, Draining, Restoring, Resyncing, Completed or Failed
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Message explains the current phase
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Source describes what is being restored
+	// +optional
+	Source string `json:"source,omitempty"`
+	// S3 is the object being restored, resolved from the spec
+	// +optional
+	S3 *S3RestoreSource `json:"s3,omitempty"`
+	// Pod is the primary the data is restored into
+	// +optional
+	Pod string `json:"pod,omitempty"`
+	// PodUID is the UID of Pod before it was restarted to load the data
+	// +optional
+	PodUID string `json:"podUID,omitempty"`
+	// ResyncedReplicas lists the replicas that were told to resync from the restored primary
+	// +optional
+	ResyncedReplicas []string `json:"resyncedReplicas,omitempty"`
+	// StartTime is when traffic was taken off the Keydb
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+	// CompletionTime is when the Keydb was returned to service
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// In-place restore phases. Pending, Completed and Failed are shared with
+// the restore phases of a Keydb.
+const (
+	RestorePhaseDraining  = "Draining"
+	RestorePhaseResyncing = "Resyncing"
+)
+
+// AnnotationRestore is set on a Keydb while a KeydbRestore holds it. The Keydb
+// then reports the Restoring phase, takes its pods out of the Services and
+// rejects spec changes.
+const AnnotationRestore = "keydb.keydb/restore"
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Keydb",type=string,JSONPath=`.spec.keydbName`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Source",type=string,JSONPath=`.status.source`
+// +kubebuilder:printcolumn:name="Completed",type=date,JSONPath=`.status.completionTime`
+
+// KeydbRestore is the Schema for the keydbrestores API.
+type KeydbRestore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeydbRestoreSpec   `json:"spec,omitempty"`
+	Status KeydbRestoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KeydbRestoreList contains a list of KeydbRestore.
+type KeydbRestoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeydbRestore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeydbRestore{}, &KeydbRestoreList{})
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KeydbUserSpec defines the desired state of KeydbUser.
+type KeydbUserSpec struct {
+	// KeydbName is the Keydb in the same namespace the user is created on.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="keydbName is immutable"
+	KeydbName string `json:"keydbName"`
+	// Username is the ACL user name. The default user is managed by the
+	// operator and cannot be used.
+	// +kubebuilder:validation:MinLength=1
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9._-]+$`
+	// +kubebuilder:validation:XValidation:rule="self != 'default'",message="the default user is managed by the operator"
+	// +kubebuilder:validation:XValidation:rule="self == oldSelf",message="username is immutable"
+	Username string `json:"username"`
+	// PasswordSecret is the Secret key holding the user's password. Changes to
+	// the Secret are applied to the running pods.
+	PasswordSecret corev1.SecretKeySelector `json:"passwordSecret"`
+	// Enabled turns the user off without deleting it when false.
+	// +kubebuilder:default=true
+	// +optional
+	Enabled *bool `json:"enabled,omitempty"`
+	// Categories allows command categories such as "read" or "write". Prefix a
+	// category with "-" to deny it, e.g. "-dangerous". Applied in order.
+	// +kubebuilder:validation:items:Pattern=`^[+-]?[a-z]+$`
+	// +optional
+	Categories []string `json:"categories,omitempty"`
+	// Commands allows individual commands after Categories are applied. Prefix
+	// a command with "-" to deny it, e.g. "-flushall".
+	// +kubebuilder:validation:items:Pattern=`^[+-]?[A-Za-z0-9|-]+$`
+	// +optional
+	Commands []string `json:"commands,omitempty"`
+	// Keys are the key patterns the user may access, e.g. "app:*".
+	// +kubebuilder:validation:items:Pattern=`^\S+$`
+	// +optional
+	Keys []string `json:"keys,omitempty"`
+	// Channels are the Pub/Sub channel patterns the user may access.
+	// +kubebuilder:validation:items:Pattern=`^\S+$`
+	// +optional
+	Channels []string `json:"channels,omitempty"`
+}
+
+// KeydbUserStatus defines the observed state of KeydbUser.
+type KeydbUserStatus struct {
+	// Phase is one of Pending, Ready or Failed
+	// +optional
+	Phase string `json:"phase,omitempty"`
+	// Message explains the current phase
+	// +optional
+	Message string `json:"message,omitempty"`
+	// Pods lists the pods the user was last applied to
+	// +optional
+	Pods []string `json:"pods,omitempty"`
+	// ObservedGeneration is the generation last applied to the pods
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// LastSyncTime is when the user was last applied to every pod
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// KeydbUser phases
+const (
+	UserPhasePending = "Pending"
+	UserPhaseReady   = "Ready"
+	UserPhaseFailed  = "Failed"
+)
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Keydb",type=string,JSONPath=`.spec.keydbName`
+// +kubebuilder:printcolumn:name="Username",type=string,JSONPath=`.spec.username`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// KeydbUser is the Schema for the keydbusers API.
+type KeydbUser struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   KeydbUserSpec   `json:"spec,omitempty"`
+	Status KeydbUserStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// KeydbUserList contains a list of KeydbUser.
+type KeydbUserList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []KeydbUser `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&KeydbUser{}, &KeydbUserList{})
+}
@@ -22,10 +22,157 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRetentionSpec) DeepCopyInto(out *BackupRetentionSpec) {
+	*out = *in
+	if in.KeepLast != nil {
+		in, out := &in.KeepLast, &out.KeepLast
+		*out = new(int32)
+		**out = **in
+	}
+	if in.KeepDaily != nil {
+		in, out := &in.KeepDaily, &out.KeepDaily
+		*out = new(int32)
+		**out = **in
+	}
+	if in.KeepWeekly != nil {
+		in, out := &in.KeepWeekly, &out.KeepWeekly
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRetentionSpec.
+func (in *BackupRetentionSpec) DeepCopy() *BackupRetentionSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRetentionSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupStorageSpec) DeepCopyInto(out *BackupStorageSpec) {
+	*out = *in
+	out.S3 = in.S3
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupStorageSpec.
+func (in *BackupStorageSpec) DeepCopy() *BackupStorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupStorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertManagerSpec) DeepCopyInto(out *CertManagerSpec) {
+	*out = *in
+	out.IssuerRef = in.IssuerRef
+	if in.Duration != nil {
+		in, out := &in.Duration, &out.Duration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.RenewBefore != nil {
+		in, out := &in.RenewBefore, &out.RenewBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertManagerSpec.
+func (in *CertManagerSpec) DeepCopy() *CertManagerSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertManagerSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ConfigReloadStatus) DeepCopyInto(out *ConfigReloadStatus) {
+	*out = *in
+	if in.LastReloadTime != nil {
+		in, out := &in.LastReloadTime, &out.LastReloadTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Failed != nil {
+		in, out := &in.Failed, &out.Failed
+		*out = make([]DirectiveError, len(*in))
+		copy(*out, *in)
+	}
+	if in.PendingRestart != nil {
+		in, out := &in.PendingRestart, &out.PendingRestart
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ConfigReloadStatus.
+func (in *ConfigReloadStatus) DeepCopy() *ConfigReloadStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ConfigReloadStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *DirectiveError) DeepCopyInto(out *DirectiveError) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new DirectiveError.
+func (in *DirectiveError) DeepCopy() *DirectiveError {
+	if in == nil {
+		return nil
+	}
+	out := new(DirectiveError)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageProfileSpec) DeepCopyInto(out *ImageProfileSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageProfileSpec.
+func (in *ImageProfileSpec) DeepCopy() *ImageProfileSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageProfileSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *IssuerReference) DeepCopyInto(out *IssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new IssuerReference.
+func (in *IssuerReference) DeepCopy() *IssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(IssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Keydb) DeepCopyInto(out *Keydb) {
 	*out = *in
@@ -69,31 +216,58 @@ func (in *KeydbAddressSpec) DeepCopy() *KeydbAddressSpec {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeydbList) DeepCopyInto(out *KeydbList) {
+func (in *KeydbBackup) DeepCopyInto(out *KeydbBackup) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	out.Spec = in.Spec
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbBackup.
+func (in *KeydbBackup) DeepCopy() *KeydbBackup {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbBackup)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeydbBackup) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbBackupList) DeepCopyInto(out *KeydbBackupList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]Keydb, len(*in))
+		*out = make([]KeydbBackup, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbList.
-func (in *KeydbList) DeepCopy() *KeydbList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbBackupList.
+func (in *KeydbBackupList) DeepCopy() *KeydbBackupList {
 	if in == nil {
 		return nil
 	}
-	out := new(KeydbList)
+	out := new(KeydbBackupList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *KeydbList) DeepCopyObject() runtime.Object {
+func (in *KeydbBackupList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -101,138 +275,1063 @@ func (in *KeydbList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeydbSpec) DeepCopyInto(out *KeydbSpec) {
+func (in *KeydbBackupSchedule) DeepCopyInto(out *KeydbBackupSchedule) {
 	*out = *in
-	if in.Replicas != nil {
-		in, out := &in.Replicas, &out.Replicas
-		*out = new(int32)
-		**out = **in
-	}
-	in.Replication.DeepCopyInto(&out.Replication)
-	out.Persistence = in.Persistence
-	if in.PasswordSecret != nil {
-		in, out := &in.PasswordSecret, &out.PasswordSecret
-		*out = new(corev1.SecretKeySelector)
-		(*in).DeepCopyInto(*out)
-	}
-	in.Resources.DeepCopyInto(&out.Resources)
-	out.Metrics = in.Metrics
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbSpec.
-func (in *KeydbSpec) DeepCopy() *KeydbSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbBackupSchedule.
+func (in *KeydbBackupSchedule) DeepCopy() *KeydbBackupSchedule {
 	if in == nil {
 		return nil
 	}
-	out := new(KeydbSpec)
+	out := new(KeydbBackupSchedule)
 	in.DeepCopyInto(out)
 	return out
 }
 
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeydbBackupSchedule) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *KeydbStatus) DeepCopyInto(out *KeydbStatus) {
+func (in *KeydbBackupScheduleList) DeepCopyInto(out *KeydbBackupScheduleList) {
 	*out = *in
-	if in.Conditions != nil {
-		in, out := &in.Conditions, &out.Conditions
-		*out = make([]metav1.Condition, len(*in))
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeydbBackupSchedule, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
-	in.Replicas.DeepCopyInto(&out.Replicas)
-	if in.LastUpdateTime != nil {
-		in, out := &in.LastUpdateTime, &out.LastUpdateTime
-		*out = (*in).DeepCopy()
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbBackupScheduleList.
+func (in *KeydbBackupScheduleList) DeepCopy() *KeydbBackupScheduleList {
+	if in == nil {
+		return nil
 	}
+	out := new(KeydbBackupScheduleList)
+	in.DeepCopyInto(out)
+	return out
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbStatus.
-func (in *KeydbStatus) DeepCopy() *KeydbStatus {
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeydbBackupScheduleList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbBackupScheduleSpec) DeepCopyInto(out *KeydbBackupScheduleSpec) {
+	*out = *in
+	out.BackupTemplate = in.BackupTemplate
+	in.Retention.DeepCopyInto(&out.Retention)
+	if in.FailedBackupsHistoryLimit != nil {
+		in, out := &in.FailedBackupsHistoryLimit, &out.FailedBackupsHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbBackupScheduleSpec.
+func (in *KeydbBackupScheduleSpec) DeepCopy() *KeydbBackupScheduleSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(KeydbStatus)
+	out := new(KeydbBackupScheduleSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *MetricsSpec) DeepCopyInto(out *MetricsSpec) {
+func (in *KeydbBackupScheduleStatus) DeepCopyInto(out *KeydbBackupScheduleStatus) {
 	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Active != nil {
+		in, out := &in.Active, &out.Active
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastScheduleTime != nil {
+		in, out := &in.LastScheduleTime, &out.LastScheduleTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastSuccessfulTime != nil {
+		in, out := &in.LastSuccessfulTime, &out.LastSuccessfulTime
+		*out = (*in).DeepCopy()
+	}
+	if in.NextScheduleTime != nil {
+		in, out := &in.NextScheduleTime, &out.NextScheduleTime
+		*out = (*in).DeepCopy()
+	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsSpec.
-func (in *MetricsSpec) DeepCopy() *MetricsSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbBackupScheduleStatus.
+func (in *KeydbBackupScheduleStatus) DeepCopy() *KeydbBackupScheduleStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(MetricsSpec)
+	out := new(KeydbBackupScheduleStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PersistenceSpec) DeepCopyInto(out *PersistenceSpec) {
+func (in *KeydbBackupSpec) DeepCopyInto(out *KeydbBackupSpec) {
 	*out = *in
+	out.Storage = in.Storage
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistenceSpec.
-func (in *PersistenceSpec) DeepCopy() *PersistenceSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbBackupSpec.
+func (in *KeydbBackupSpec) DeepCopy() *KeydbBackupSpec {
 	if in == nil {
 		return nil
 	}
-	out := new(PersistenceSpec)
+	out := new(KeydbBackupSpec)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ReplicaStatus) DeepCopyInto(out *ReplicaStatus) {
+func (in *KeydbBackupStatus) DeepCopyInto(out *KeydbBackupStatus) {
 	*out = *in
-	if in.Ready != nil {
-		in, out := &in.Ready, &out.Ready
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
 	}
-	if in.NotReady != nil {
-		in, out := &in.NotReady, &out.NotReady
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.SnapshotTime != nil {
+		in, out := &in.SnapshotTime, &out.SnapshotTime
+		*out = (*in).DeepCopy()
 	}
-	if in.Failed != nil {
-		in, out := &in.Failed, &out.Failed
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaStatus.
-func (in *ReplicaStatus) DeepCopy() *ReplicaStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbBackupStatus.
+func (in *KeydbBackupStatus) DeepCopy() *KeydbBackupStatus {
 	if in == nil {
 		return nil
 	}
-	out := new(ReplicaStatus)
+	out := new(KeydbBackupStatus)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *ReplicationSpec) DeepCopyInto(out *ReplicationSpec) {
+func (in *KeydbList) DeepCopyInto(out *KeydbList) {
 	*out = *in
-	if in.Domain != nil {
-		in, out := &in.Domain, &out.Domain
-		*out = make([]string, len(*in))
-		copy(*out, *in)
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]Keydb, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
-	out.Keydb = in.Keydb
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSpec.
-func (in *ReplicationSpec) DeepCopy() *ReplicationSpec {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbList.
+func (in *KeydbList) DeepCopy() *KeydbList {
 	if in == nil {
 		return nil
 	}
-	out := new(ReplicationSpec)
+	out := new(KeydbList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeydbList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbRestore) DeepCopyInto(out *KeydbRestore) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbRestore.
+func (in *KeydbRestore) DeepCopy() *KeydbRestore {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbRestore)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeydbRestore) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbRestoreList) DeepCopyInto(out *KeydbRestoreList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeydbRestore, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbRestoreList.
+func (in *KeydbRestoreList) DeepCopy() *KeydbRestoreList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbRestoreList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeydbRestoreList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbRestoreSpec) DeepCopyInto(out *KeydbRestoreSpec) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3RestoreSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbRestoreSpec.
+func (in *KeydbRestoreSpec) DeepCopy() *KeydbRestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbRestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbRestoreStatus) DeepCopyInto(out *KeydbRestoreStatus) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3RestoreSource)
+		**out = **in
+	}
+	if in.ResyncedReplicas != nil {
+		in, out := &in.ResyncedReplicas, &out.ResyncedReplicas
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbRestoreStatus.
+func (in *KeydbRestoreStatus) DeepCopy() *KeydbRestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbRestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbSpec) DeepCopyInto(out *KeydbSpec) {
+	*out = *in
+	if in.ImageProfile != nil {
+		in, out := &in.ImageProfile, &out.ImageProfile
+		*out = new(ImageProfileSpec)
+		**out = **in
+	}
+	if in.Replicas != nil {
+		in, out := &in.Replicas, &out.Replicas
+		*out = new(int32)
+		**out = **in
+	}
+	in.Replication.DeepCopyInto(&out.Replication)
+	out.Persistence = in.Persistence
+	if in.PasswordSecret != nil {
+		in, out := &in.PasswordSecret, &out.PasswordSecret
+		*out = new(corev1.SecretKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Resources.DeepCopyInto(&out.Resources)
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Tolerations != nil {
+		in, out := &in.Tolerations, &out.Tolerations
+		*out = make([]corev1.Toleration, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Affinity != nil {
+		in, out := &in.Affinity, &out.Affinity
+		*out = new(corev1.Affinity)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TopologySpreadConstraints != nil {
+		in, out := &in.TopologySpreadConstraints, &out.TopologySpreadConstraints
+		*out = make([]corev1.TopologySpreadConstraint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.SecurityContext != nil {
+		in, out := &in.SecurityContext, &out.SecurityContext
+		*out = new(SecurityContextSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PodTemplate != nil {
+		in, out := &in.PodTemplate, &out.PodTemplate
+		*out = new(PodTemplateOverride)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Config != nil {
+		in, out := &in.Config, &out.Config
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Metrics.DeepCopyInto(&out.Metrics)
+	in.Service.DeepCopyInto(&out.Service)
+	if in.NetworkPolicy != nil {
+		in, out := &in.NetworkPolicy, &out.NetworkPolicy
+		*out = new(NetworkPolicySpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TLS != nil {
+		in, out := &in.TLS, &out.TLS
+		*out = new(TLSSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(RestoreSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbSpec.
+func (in *KeydbSpec) DeepCopy() *KeydbSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbStatus) DeepCopyInto(out *KeydbStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	in.Replicas.DeepCopyInto(&out.Replicas)
+	if in.LastUpdateTime != nil {
+		in, out := &in.LastUpdateTime, &out.LastUpdateTime
+		*out = (*in).DeepCopy()
+	}
+	if in.Nodes != nil {
+		in, out := &in.Nodes, &out.Nodes
+		*out = make([]NodeStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Restore != nil {
+		in, out := &in.Restore, &out.Restore
+		*out = new(RestoreStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConfigReload != nil {
+		in, out := &in.ConfigReload, &out.ConfigReload
+		*out = make([]ConfigReloadStatus, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PasswordRotation != nil {
+		in, out := &in.PasswordRotation, &out.PasswordRotation
+		*out = new(PasswordRotationStatus)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbStatus.
+func (in *KeydbStatus) DeepCopy() *KeydbStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbUser) DeepCopyInto(out *KeydbUser) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbUser.
+func (in *KeydbUser) DeepCopy() *KeydbUser {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbUser)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeydbUser) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbUserList) DeepCopyInto(out *KeydbUserList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]KeydbUser, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbUserList.
+func (in *KeydbUserList) DeepCopy() *KeydbUserList {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbUserList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *KeydbUserList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbUserSpec) DeepCopyInto(out *KeydbUserSpec) {
+	*out = *in
+	in.PasswordSecret.DeepCopyInto(&out.PasswordSecret)
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Categories != nil {
+		in, out := &in.Categories, &out.Categories
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Commands != nil {
+		in, out := &in.Commands, &out.Commands
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Keys != nil {
+		in, out := &in.Keys, &out.Keys
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Channels != nil {
+		in, out := &in.Channels, &out.Channels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbUserSpec.
+func (in *KeydbUserSpec) DeepCopy() *KeydbUserSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbUserSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *KeydbUserStatus) DeepCopyInto(out *KeydbUserStatus) {
+	*out = *in
+	if in.Pods != nil {
+		in, out := &in.Pods, &out.Pods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.LastSyncTime != nil {
+		in, out := &in.LastSyncTime, &out.LastSyncTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new KeydbUserStatus.
+func (in *KeydbUserStatus) DeepCopy() *KeydbUserStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(KeydbUserStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MetricsSpec) DeepCopyInto(out *MetricsSpec) {
+	*out = *in
+	if in.ServiceMonitor != nil {
+		in, out := &in.ServiceMonitor, &out.ServiceMonitor
+		*out = new(ServiceMonitorSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PrometheusRule != nil {
+		in, out := &in.PrometheusRule, &out.PrometheusRule
+		*out = new(PrometheusRuleSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MetricsSpec.
+func (in *MetricsSpec) DeepCopy() *MetricsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(MetricsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NetworkPolicySpec) DeepCopyInto(out *NetworkPolicySpec) {
+	*out = *in
+	if in.Clients != nil {
+		in, out := &in.Clients, &out.Clients
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.MetricsNamespaceSelector != nil {
+		in, out := &in.MetricsNamespaceSelector, &out.MetricsNamespaceSelector
+		*out = new(metav1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReplicationPeers != nil {
+		in, out := &in.ReplicationPeers, &out.ReplicationPeers
+		*out = make([]networkingv1.NetworkPolicyPeer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = make([]networkingv1.NetworkPolicyEgressRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NetworkPolicySpec.
+func (in *NetworkPolicySpec) DeepCopy() *NetworkPolicySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(NetworkPolicySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NodeStatus) DeepCopyInto(out *NodeStatus) {
+	*out = *in
+	if in.LagBytes != nil {
+		in, out := &in.LagBytes, &out.LagBytes
+		*out = new(int64)
+		**out = **in
+	}
+	if in.LagSeconds != nil {
+		in, out := &in.LagSeconds, &out.LagSeconds
+		*out = new(int64)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NodeStatus.
+func (in *NodeStatus) DeepCopy() *NodeStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(NodeStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PVCRestoreSource) DeepCopyInto(out *PVCRestoreSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PVCRestoreSource.
+func (in *PVCRestoreSource) DeepCopy() *PVCRestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PVCRestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PasswordRotationStatus) DeepCopyInto(out *PasswordRotationStatus) {
+	*out = *in
+	if in.StartTime != nil {
+		in, out := &in.StartTime, &out.StartTime
+		*out = (*in).DeepCopy()
+	}
+	if in.LastTransitionTime != nil {
+		in, out := &in.LastTransitionTime, &out.LastTransitionTime
+		*out = (*in).DeepCopy()
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PasswordRotationStatus.
+func (in *PasswordRotationStatus) DeepCopy() *PasswordRotationStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PasswordRotationStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PersistenceSpec) DeepCopyInto(out *PersistenceSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PersistenceSpec.
+func (in *PersistenceSpec) DeepCopy() *PersistenceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PersistenceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PodTemplateOverride) DeepCopyInto(out *PodTemplateOverride) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]corev1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.InitContainers != nil {
+		in, out := &in.InitContainers, &out.InitContainers
+		*out = make([]corev1.Container, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Volumes != nil {
+		in, out := &in.Volumes, &out.Volumes
+		*out = make([]corev1.Volume, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.VolumeMounts != nil {
+		in, out := &in.VolumeMounts, &out.VolumeMounts
+		*out = make([]corev1.VolumeMount, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Env != nil {
+		in, out := &in.Env, &out.Env
+		*out = make([]corev1.EnvVar, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]corev1.LocalObjectReference, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PodTemplateOverride.
+func (in *PodTemplateOverride) DeepCopy() *PodTemplateOverride {
+	if in == nil {
+		return nil
+	}
+	out := new(PodTemplateOverride)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PrometheusRuleSpec) DeepCopyInto(out *PrometheusRuleSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.AlertLabels != nil {
+		in, out := &in.AlertLabels, &out.AlertLabels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PrometheusRuleSpec.
+func (in *PrometheusRuleSpec) DeepCopy() *PrometheusRuleSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PrometheusRuleSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicaStatus) DeepCopyInto(out *ReplicaStatus) {
+	*out = *in
+	if in.Ready != nil {
+		in, out := &in.Ready, &out.Ready
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.NotReady != nil {
+		in, out := &in.NotReady, &out.NotReady
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Failed != nil {
+		in, out := &in.Failed, &out.Failed
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicaStatus.
+func (in *ReplicaStatus) DeepCopy() *ReplicaStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicaStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReplicationSpec) DeepCopyInto(out *ReplicationSpec) {
+	*out = *in
+	if in.Domain != nil {
+		in, out := &in.Domain, &out.Domain
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	out.Keydb = in.Keydb
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReplicationSpec.
+func (in *ReplicationSpec) DeepCopy() *ReplicationSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ReplicationSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreSpec) DeepCopyInto(out *RestoreSpec) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3RestoreSource)
+		**out = **in
+	}
+	if in.PersistentVolumeClaim != nil {
+		in, out := &in.PersistentVolumeClaim, &out.PersistentVolumeClaim
+		*out = new(PVCRestoreSource)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreSpec.
+func (in *RestoreSpec) DeepCopy() *RestoreSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RestoreStatus) DeepCopyInto(out *RestoreStatus) {
+	*out = *in
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(S3RestoreSource)
+		**out = **in
+	}
+	if in.CompletionTime != nil {
+		in, out := &in.CompletionTime, &out.CompletionTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RestoreStatus.
+func (in *RestoreStatus) DeepCopy() *RestoreStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RestoreStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3RestoreSource) DeepCopyInto(out *S3RestoreSource) {
+	*out = *in
+	out.CredentialsSecret = in.CredentialsSecret
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3RestoreSource.
+func (in *S3RestoreSource) DeepCopy() *S3RestoreSource {
+	if in == nil {
+		return nil
+	}
+	out := new(S3RestoreSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *S3StorageSpec) DeepCopyInto(out *S3StorageSpec) {
+	*out = *in
+	out.CredentialsSecret = in.CredentialsSecret
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new S3StorageSpec.
+func (in *S3StorageSpec) DeepCopy() *S3StorageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(S3StorageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SecurityContextSpec) DeepCopyInto(out *SecurityContextSpec) {
+	*out = *in
+	if in.RunAsUser != nil {
+		in, out := &in.RunAsUser, &out.RunAsUser
+		*out = new(int64)
+		**out = **in
+	}
+	if in.RunAsGroup != nil {
+		in, out := &in.RunAsGroup, &out.RunAsGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.FSGroup != nil {
+		in, out := &in.FSGroup, &out.FSGroup
+		*out = new(int64)
+		**out = **in
+	}
+	if in.SeccompProfile != nil {
+		in, out := &in.SeccompProfile, &out.SeccompProfile
+		*out = new(corev1.SeccompProfile)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReadOnlyRootFilesystem != nil {
+		in, out := &in.ReadOnlyRootFilesystem, &out.ReadOnlyRootFilesystem
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SecurityContextSpec.
+func (in *SecurityContextSpec) DeepCopy() *SecurityContextSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SecurityContextSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceMonitorSpec) DeepCopyInto(out *ServiceMonitorSpec) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceMonitorSpec.
+func (in *ServiceMonitorSpec) DeepCopy() *ServiceMonitorSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceMonitorSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServiceSpec) DeepCopyInto(out *ServiceSpec) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServiceSpec.
+func (in *ServiceSpec) DeepCopy() *ServiceSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ServiceSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TLSSpec) DeepCopyInto(out *TLSSpec) {
+	*out = *in
+	if in.CertManager != nil {
+		in, out := &in.CertManager, &out.CertManager
+		*out = new(CertManagerSpec)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TLSSpec.
+func (in *TLSSpec) DeepCopy() *TLSSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TLSSpec)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -0,0 +1,98 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// config-reloader runs as a sidecar in KeyDB pods and applies changes to the
+// mounted keydb.conf without restarting KeyDB.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	"github.com/rsingh0101/keydb-operator/internal/configreloader"
+)
+
+func main() {
+	var opts configreloader.Options
+	var statusAddr string
+
+	flag.StringVar(&opts.ConfigFile, "config-file", "/opt/bitnami/keydb/etc/keydb.conf", "The keydb.conf to watch.")
+	flag.StringVar(&opts.Addr, "keydb-address", "localhost:6379", "The KeyDB server to apply changes to.")
+	flag.StringVar(&opts.PasswordFile, "password-file", os.Getenv("KEYDB_PASSWORD_FILE"),
+		"The file holding the KeyDB password. Defaults to $KEYDB_PASSWORD_FILE.")
+	flag.StringVar(&opts.TLSDir, "tls-dir", os.Getenv("KEYDB_TLS_DIR"),
+		"The directory holding tls.crt, tls.key and ca.crt when KeyDB serves TLS. Defaults to $KEYDB_TLS_DIR.")
+	flag.StringVar(&opts.ExporterPasswordFile, "exporter-password-file", "",
+		"The file to keep the password of the metrics exporter in. Disabled when empty.")
+	flag.StringVar(&opts.ExporterAddr, "exporter-address", "localhost:9121",
+		"The metrics exporter to reload the password file of.")
+	flag.DurationVar(&opts.Interval, "resync-interval", time.Minute, "How often the files are re-read besides watch events.")
+	flag.StringVar(&statusAddr, "status-bind-address", fmt.Sprintf(":%d", configreloader.Port),
+		"The address the status endpoint binds to.")
+	zapOpts := zap.Options{}
+	zapOpts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&zapOpts)))
+	log := ctrl.Log.WithName("config-reloader")
+	opts.Log = log
+
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.Error(err, "unable to get the hostname")
+		os.Exit(1)
+	}
+	opts.Hostname = hostname
+
+	reloader := configreloader.New(opts)
+	if err := reloader.Init(); err != nil {
+		log.Error(err, "unable to read the initial configuration")
+		os.Exit(1)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(configreloader.StatusPath, reloader)
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) { w.WriteHeader(http.StatusOK) })
+	server := &http.Server{Addr: statusAddr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+
+	ctx := ctrl.SetupSignalHandler()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(err, "status endpoint failed")
+			os.Exit(1)
+		}
+	}()
+
+	log.Info("Watching configuration", "file", opts.ConfigFile, "tlsDir", opts.TLSDir)
+	if err := reloader.Run(ctx); err != nil {
+		log.Error(err, "config reloader failed")
+		os.Exit(1)
+	}
+}
@@ -39,6 +39,9 @@ import (
 
 	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
 	"github.com/rsingh0101/keydb-operator/internal/controller"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	"github.com/rsingh0101/keydb-operator/internal/metrics"
+	webhookv1 "github.com/rsingh0101/keydb-operator/internal/webhook/v1"
 
 	// +kubebuilder:scaffold:imports
 
@@ -67,6 +70,7 @@ func main() {
 	var probeAddr string
 	var secureMetrics bool
 	var enableHTTP2 bool
+	var configReloaderImage string
 	var tlsOpts []func(*tls.Config)
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metrics endpoint binds to. "+
@@ -86,6 +90,9 @@ func main() {
 	flag.StringVar(&metricsCertKey, "metrics-cert-key", "tls.key", "The name of the metrics server key file.")
 	flag.BoolVar(&enableHTTP2, "enable-http2", false,
 		"If set, HTTP/2 will be enabled for the metrics and webhook servers")
+	flag.StringVar(&configReloaderImage, "config-reloader-image", os.Getenv("CONFIG_RELOADER_IMAGE"),
+		"The image running the config reloader sidecar in KeyDB pods, normally the operator image. "+
+			"Defaults to $CONFIG_RELOADER_IMAGE. Required.")
 	opts := zap.Options{
 		Development: true,
 		TimeEncoder: zapcore.ISO8601TimeEncoder, // Prettier timestamps
@@ -105,6 +112,12 @@ func main() {
 	flag.Parse()
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+
+	// Without it every KeyDB pod would get a sidecar it cannot pull
+	if configReloaderImage == "" {
+		setupLog.Error(nil, "--config-reloader-image or $CONFIG_RELOADER_IMAGE must be set")
+		os.Exit(1)
+	}
 	// log := ctrl.Log.WithName("example")
 	// log.Info("🌈 Colorful controller-runtime logs in production mode")
 	// if the enable-http2 flag is false (the default), http/2 should be disabled
@@ -220,13 +233,60 @@ func main() {
 		os.Exit(1)
 	}
 
+	keydbClients := keydbclient.NewPool()
+
 	if err := (&controller.KeydbReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:              mgr.GetClient(),
+		Scheme:              mgr.GetScheme(),
+		Recorder:            controller.DeduplicateEvents(mgr.GetEventRecorderFor("keydb-controller")),
+		KeydbClients:        keydbClients,
+		ConfigReloaderImage: configReloaderImage,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Keydb")
 		os.Exit(1)
 	}
+	if err := (&controller.KeydbBackupReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		Recorder:     controller.DeduplicateEvents(mgr.GetEventRecorderFor("keydbbackup-controller")),
+		KeydbClients: keydbClients,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeydbBackup")
+		os.Exit(1)
+	}
+	if err := (&controller.KeydbBackupScheduleReconciler{
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: controller.DeduplicateEvents(mgr.GetEventRecorderFor("keydbbackupschedule-controller")),
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeydbBackupSchedule")
+		os.Exit(1)
+	}
+	if err := (&controller.KeydbRestoreReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		Recorder:     controller.DeduplicateEvents(mgr.GetEventRecorderFor("keydbrestore-controller")),
+		KeydbClients: keydbClients,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeydbRestore")
+		os.Exit(1)
+	}
+	if err := (&controller.KeydbUserReconciler{
+		Client:       mgr.GetClient(),
+		Scheme:       mgr.GetScheme(),
+		Recorder:     controller.DeduplicateEvents(mgr.GetEventRecorderFor("keydbuser-controller")),
+		KeydbClients: keydbClients,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "KeydbUser")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err := webhookv1.SetupKeydbWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "Keydb")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if metricsCertWatcher != nil {
@@ -245,6 +305,11 @@ func main() {
 		}
 	}
 
+	if err := metrics.Register(mgr.GetClient()); err != nil {
+		setupLog.Error(err, "unable to register metrics")
+		os.Exit(1)
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
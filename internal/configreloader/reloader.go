@@ -0,0 +1,539 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package configreloader runs next to KeyDB and applies changes to the mounted
+// keydb.conf to the running server. It only sends the directives that changed
+// and reports the outcome of each on a status endpoint the operator polls.
+package configreloader
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-logr/logr"
+
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	"github.com/rsingh0101/keydb-operator/internal/keydbconf"
+)
+
+const (
+	// Port serves the status endpoint in KeyDB pods.
+	Port = 9122
+	// StatusPath is the status endpoint.
+	StatusPath = "/status"
+
+	defaultInterval = time.Minute
+)
+
+// Options configures a Reloader.
+type Options struct {
+	// ConfigFile is the mounted keydb.conf.
+	ConfigFile string
+	// Addr is the KeyDB server, normally localhost:6379.
+	Addr string
+	// PasswordFile holds the password of the default user. It is read on
+	// every connection so a rotated password is picked up.
+	PasswordFile string
+	// TLSDir holds tls.crt, tls.key and ca.crt when KeyDB serves TLS.
+	// KeyDB is told to reload the certificate when it changes.
+	TLSDir string
+	// Hostname is the pod name. replicaof lines pointing at the pod itself
+	// are skipped.
+	Hostname string
+	// ExporterPasswordFile, when set, receives the password in the JSON form
+	// redis_exporter reads with --redis.password-file, so the metrics
+	// exporter follows a rotated password without a restart.
+	ExporterPasswordFile string
+	// ExporterAddr is the HTTP address of the exporter, normally
+	// localhost:9121. It is told to reload ExporterPasswordFile on changes.
+	ExporterAddr string
+	// Interval re-reads the files in case a watch event was missed.
+	// Defaults to a minute.
+	Interval time.Duration
+	Log      logr.Logger
+}
+
+// Outcome of applying a directive.
+const (
+	ResultApplied         = "Applied"
+	ResultFailed          = "Failed"
+	ResultRestartRequired = "RestartRequired"
+)
+
+// DirectiveStatus is the outcome of the latest change to a directive.
+type DirectiveStatus struct {
+	Directive string `json:"directive"`
+	Value     string `json:"value"`
+	Result    string `json:"result"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Status is served as JSON on StatusPath.
+type Status struct {
+	// ConfigHash is the Hash of the keydb.conf the reloader last processed.
+	ConfigHash string `json:"configHash"`
+	// LastReloadTime is when a change was last applied.
+	LastReloadTime *time.Time `json:"lastReloadTime,omitempty"`
+	// Directives holds the outcome of every directive changed since the
+	// reloader started, sorted by directive.
+	Directives []DirectiveStatus `json:"directives,omitempty"`
+}
+
+// Reloader applies changes to keydb.conf to a KeyDB server.
+type Reloader struct {
+	opts Options
+
+	mu         sync.Mutex
+	config     map[string][]string
+	configHash string
+	certHash   string
+	results    map[string]DirectiveStatus
+	lastReload *time.Time
+	// exporterPassword is the password the exporter last reloaded
+	exporterPassword *string
+}
+
+// New returns a Reloader. Init must be called before Reload.
+func New(opts Options) *Reloader {
+	if opts.Interval == 0 {
+		opts.Interval = defaultInterval
+	}
+	return &Reloader{opts: opts, results: map[string]DirectiveStatus{}}
+}
+
+// Hash identifies the content of a keydb.conf.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:])
+}
+
+// Parse returns the values of each directive in a keydb.conf, in file order.
+// Directives are lowercased and a quoted empty value ("") becomes empty.
+func Parse(content []byte) map[string][]string {
+	config := map[string][]string{}
+	scanner := bufio.NewScanner(strings.NewReader(string(content)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		directive, value, _ := strings.Cut(line, " ")
+		value = strings.TrimSpace(value)
+		if value == `""` {
+			value = ""
+		}
+		directive = strings.ToLower(directive)
+		config[directive] = append(config[directive], value)
+	}
+	return config
+}
+
+// Changed returns the directives whose values differ between two parsed
+// configs, including the ones removed from updated, sorted.
+func Changed(old, updated map[string][]string) []string {
+	var changed []string
+	for directive, values := range updated {
+		if !equal(old[directive], values) {
+			changed = append(changed, directive)
+		}
+	}
+	for directive := range old {
+		if _, ok := updated[directive]; !ok {
+			changed = append(changed, directive)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Init records the files KeyDB was started with, so that only later changes
+// are applied.
+func (r *Reloader) Init() error {
+	content, err := os.ReadFile(r.opts.ConfigFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	certHash, err := r.readCertHash()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config = Parse(content)
+	if content != nil {
+		r.configHash = Hash(content)
+	}
+	r.certHash = certHash
+	return nil
+}
+
+// Reload applies the directives that changed since the last call and reloads
+// the certificate when it changed. Directives KeyDB rejects are reported in
+// the status and not retried until they change again. An error is returned
+// when KeyDB could not be reached, in which case the next call tries again.
+func (r *Reloader) Reload(ctx context.Context) error {
+	content, err := os.ReadFile(r.opts.ConfigFile)
+	if errors.Is(err, os.ErrNotExist) {
+		// ConfigMap updates swap the files; the next event sees the new one
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	certHash, err := r.readCertHash()
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	hash := Hash(content)
+	if hash == r.configHash && certHash == r.certHash {
+		return nil
+	}
+
+	c, err := r.dial(ctx)
+	if err != nil {
+		return fmt.Errorf("connect to %s: %w", r.opts.Addr, err)
+	}
+	defer c.Close() //nolint:errcheck
+
+	if hash != r.configHash {
+		config := Parse(content)
+		for _, directive := range Changed(r.config, config) {
+			result, ok := r.apply(ctx, c, directive, r.config[directive], config[directive])
+			if !ok {
+				continue
+			}
+			r.results[directive] = result
+			r.opts.Log.Info("Config directive changed", "directive", directive, "result", result.Result, "error", result.Error)
+		}
+		r.config = config
+		r.configHash = hash
+		now := time.Now()
+		r.lastReload = &now
+	}
+
+	if certHash != r.certHash {
+		// Setting any tls-* directive makes KeyDB reload the certificate files,
+		// which cert-manager replaces in the mounted Secret on renewal
+		if err := c.ConfigSet(ctx, "tls-cert-file", filepath.Join(r.opts.TLSDir, "tls.crt")); err != nil {
+			return fmt.Errorf("reload certificate: %w", err)
+		}
+		r.certHash = certHash
+		r.opts.Log.Info("Reloaded the TLS certificate")
+	}
+	return nil
+}
+
+// SyncExporterPassword writes the current password to ExporterPasswordFile and
+// has the exporter reload it. Nothing is done while the password is the one
+// the exporter last reloaded; a failed reload is retried on the next call.
+func (r *Reloader) SyncExporterPassword(ctx context.Context) error {
+	if r.opts.ExporterPasswordFile == "" {
+		return nil
+	}
+	var password string
+	if r.opts.PasswordFile != "" {
+		pw, err := os.ReadFile(r.opts.PasswordFile)
+		if err != nil {
+			return err
+		}
+		password = strings.TrimRight(string(pw), "\r\n")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.exporterPassword != nil && *r.exporterPassword == password {
+		return nil
+	}
+
+	// The exporter looks the password up by the address it scrapes
+	scheme := "redis://"
+	if r.opts.TLSDir != "" {
+		scheme = "rediss://"
+	}
+	content, err := json.Marshal(map[string]string{scheme + r.opts.Addr: password})
+	if err != nil {
+		return err
+	}
+	// Rename so the exporter never reads a partly written file
+	tmp := r.opts.ExporterPasswordFile + ".tmp"
+	if err := os.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, r.opts.ExporterPasswordFile); err != nil {
+		return err
+	}
+
+	if r.opts.ExporterAddr != "" {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+r.opts.ExporterAddr+"/-/reload", nil)
+		if err != nil {
+			return err
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			return fmt.Errorf("reload the exporter password: %w", err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("reload the exporter password: %s", resp.Status)
+		}
+	}
+	r.exporterPassword = &password
+	r.opts.Log.Info("Updated the password of the metrics exporter")
+	return nil
+}
+
+// apply sends a changed directive to KeyDB. ok is false for directives the
+// reloader leaves alone.
+func (r *Reloader) apply(ctx context.Context, c *keydbclient.Client, directive string, old, values []string) (DirectiveStatus, bool) {
+	status := DirectiveStatus{Directive: directive, Value: strings.Join(values, " ")}
+
+	if len(values) == 0 {
+		// KeyDB has no way to go back to the default of a removed directive,
+		// so it keeps the old value until the pod restarts
+		if directive == "replicaof" || directive == "slaveof" || keydbconf.Classify(directive) == keydbconf.Owned {
+			return status, false
+		}
+		status.Result = ResultRestartRequired
+		return status, true
+	}
+
+	switch directive {
+	case "replicaof", "slaveof":
+		// Every line adds a primary in master-master mode, so only the new
+		// ones are sent
+		status.Result = ResultApplied
+		var errs []string
+		for _, value := range values {
+			if contains(old, value) || strings.HasPrefix(value, r.opts.Hostname+".") {
+				continue
+			}
+			if err := replicaOf(ctx, c, value); err != nil {
+				errs = append(errs, err.Error())
+			}
+		}
+		if len(errs) > 0 {
+			status.Result, status.Error = ResultFailed, strings.Join(errs, "; ")
+		}
+		return status, true
+	}
+
+	switch keydbconf.Classify(directive) {
+	case keydbconf.Owned:
+		return status, false
+	case keydbconf.RestartRequired:
+		status.Result = ResultRestartRequired
+		return status, true
+	}
+
+	// Directives such as save take all their values in a single CONFIG SET
+	if err := c.ConfigSet(ctx, directive, status.Value); err != nil {
+		status.Result, status.Error = ResultFailed, err.Error()
+	} else {
+		status.Result = ResultApplied
+	}
+	return status, true
+}
+
+func replicaOf(ctx context.Context, c *keydbclient.Client, value string) error {
+	fields := strings.Fields(value)
+	if len(fields) != 2 {
+		return fmt.Errorf("invalid replicaof %q", value)
+	}
+	port, err := strconv.ParseInt(fields[1], 10, 32)
+	if err != nil {
+		return fmt.Errorf("invalid replicaof port %q", fields[1])
+	}
+	return c.ReplicaOf(ctx, fields[0], int32(port))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Status returns what the reloader has done so far.
+func (r *Reloader) Status() Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	status := Status{ConfigHash: r.configHash, LastReloadTime: r.lastReload}
+	for _, result := range r.results {
+		status.Directives = append(status.Directives, result)
+	}
+	sort.Slice(status.Directives, func(i, j int) bool {
+		return status.Directives[i].Directive < status.Directives[j].Directive
+	})
+	return status
+}
+
+// ServeHTTP serves the status as JSON.
+func (r *Reloader) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(r.Status())
+}
+
+// Run watches the config and certificate directories and reloads on every
+// change until ctx is done. The password directory is watched as well when
+// the exporter password is kept in sync. Kubelet updates a mounted ConfigMap or Secret by
+// swapping a symlink, so the directories are watched instead of the files.
+func (r *Reloader) Run(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer watcher.Close() //nolint:errcheck
+
+	dirs := []string{filepath.Dir(r.opts.ConfigFile), r.opts.TLSDir}
+	if r.opts.ExporterPasswordFile != "" && r.opts.PasswordFile != "" {
+		dirs = append(dirs, filepath.Dir(r.opts.PasswordFile))
+	}
+	for _, dir := range dirs {
+		if dir == "" {
+			continue
+		}
+		if err := watcher.Add(dir); err != nil {
+			return fmt.Errorf("watch %s: %w", dir, err)
+		}
+	}
+
+	ticker := time.NewTicker(r.opts.Interval)
+	defer ticker.Stop()
+
+	reload := func() {
+		if err := r.Reload(ctx); err != nil {
+			r.opts.Log.Error(err, "Failed to reload the configuration")
+		}
+		if err := r.SyncExporterPassword(ctx); err != nil {
+			r.opts.Log.Error(err, "Failed to update the password of the metrics exporter")
+		}
+	}
+	reload()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case _, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			reload()
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			r.opts.Log.Error(err, "File watch failed")
+		case <-ticker.C:
+			reload()
+		}
+	}
+}
+
+func (r *Reloader) dial(ctx context.Context) (*keydbclient.Client, error) {
+	opts := keydbclient.Options{Addr: r.opts.Addr}
+	if r.opts.PasswordFile != "" {
+		pw, err := os.ReadFile(r.opts.PasswordFile)
+		if err != nil {
+			return nil, err
+		}
+		opts.Password = strings.TrimRight(string(pw), "\r\n")
+	}
+	if r.opts.TLSDir != "" {
+		config, err := r.tlsConfig()
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = config
+	}
+	return keydbclient.Dial(ctx, opts)
+}
+
+// tlsConfig presents the pod's certificate, so it also works when KeyDB
+// requires client certificates. The server certificate is checked against
+// ca.crt but not for localhost, which spec.tls.secretName certificates do not
+// have to cover.
+func (r *Reloader) tlsConfig() (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(filepath.Join(r.opts.TLSDir, "tls.crt"), filepath.Join(r.opts.TLSDir, "tls.key"))
+	if err != nil {
+		return nil, err
+	}
+	ca, err := os.ReadFile(filepath.Join(r.opts.TLSDir, "ca.crt"))
+	if err != nil {
+		return nil, err
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(ca) {
+		return nil, fmt.Errorf("no certificates in %s", filepath.Join(r.opts.TLSDir, "ca.crt"))
+	}
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		// Verification happens in VerifyConnection, without the host name
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyConnection:   keydbclient.VerifyChain(roots),
+	}, nil
+}
+
+func (r *Reloader) readCertHash() (string, error) {
+	if r.opts.TLSDir == "" {
+		return "", nil
+	}
+	var content []byte
+	for _, name := range []string{"tls.crt", "ca.crt"} {
+		b, err := os.ReadFile(filepath.Join(r.opts.TLSDir, name))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return "", err
+		}
+		content = append(content, b...)
+	}
+	return Hash(content), nil
+}
@@ -0,0 +1,246 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package configreloader_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rsingh0101/keydb-operator/internal/configreloader"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient/fake"
+)
+
+const baseConfig = `bind 0.0.0.0 ::
+port 6379
+maxmemory 256mb
+save 900 1
+replicaof keydb-0.keydb-headless.default.svc.cluster.local 6379
+`
+
+var _ = Describe("Parse", func() {
+	It("should collect repeated directives and unquote empty values", func() {
+		config := configreloader.Parse([]byte("# comment\nSave 900 1\nsave 300 10\n\nappendfsync \"\"\n"))
+		Expect(config).To(Equal(map[string][]string{
+			"save":        {"900 1", "300 10"},
+			"appendfsync": {""},
+		}))
+	})
+
+	It("should report only changed directives", func() {
+		old := configreloader.Parse([]byte(baseConfig))
+		updated := configreloader.Parse([]byte(strings.Replace(baseConfig, "256mb", "1gb", 1) + "save 300 10\n"))
+		Expect(configreloader.Changed(old, updated)).To(Equal([]string{"maxmemory", "save"}))
+	})
+
+	It("should report removed directives", func() {
+		old := configreloader.Parse([]byte(baseConfig))
+		updated := configreloader.Parse([]byte(strings.Replace(baseConfig, "maxmemory 256mb\n", "", 1)))
+		Expect(configreloader.Changed(old, updated)).To(Equal([]string{"maxmemory"}))
+	})
+})
+
+var _ = Describe("Reloader", func() {
+	var (
+		ctx        context.Context
+		server     *fake.Server
+		configFile string
+		reloader   *configreloader.Reloader
+	)
+
+	writeConfig := func(content string) {
+		Expect(os.WriteFile(configFile, []byte(content), 0o644)).To(Succeed())
+	}
+
+	setCommands := func() [][]string {
+		var sets [][]string
+		for _, cmd := range server.Commands() {
+			if cmd[0] != "AUTH" {
+				sets = append(sets, cmd)
+			}
+		}
+		return sets
+	}
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		server, err = fake.NewServer()
+		Expect(err).NotTo(HaveOccurred())
+		server.SetPassword("s3cr3t")
+		DeferCleanup(server.Close)
+
+		dir := GinkgoT().TempDir()
+		configFile = filepath.Join(dir, "keydb.conf")
+		passwordFile := filepath.Join(dir, "password")
+		Expect(os.WriteFile(passwordFile, []byte("s3cr3t\n"), 0o600)).To(Succeed())
+		writeConfig(baseConfig)
+
+		reloader = configreloader.New(configreloader.Options{
+			ConfigFile:   configFile,
+			Addr:         server.Addr(),
+			PasswordFile: passwordFile,
+			Hostname:     "keydb-1",
+		})
+		Expect(reloader.Init()).To(Succeed())
+	})
+
+	It("should not touch KeyDB until the config changes", func() {
+		Expect(reloader.Reload(ctx)).To(Succeed())
+		Expect(server.Connections()).To(BeZero())
+		Expect(reloader.Status().ConfigHash).To(Equal(configreloader.Hash([]byte(baseConfig))))
+	})
+
+	It("should apply only the changed directives", func() {
+		writeConfig(strings.Replace(baseConfig, "256mb", "1gb", 1) +
+			"save 300 10\nserver-threads 4\ndir /elsewhere\n" +
+			"replicaof keydb-1.keydb-headless.default.svc.cluster.local 6379\n" +
+			"replicaof keydb-2.keydb-headless.default.svc.cluster.local 6379\n")
+		Expect(reloader.Reload(ctx)).To(Succeed())
+
+		Expect(setCommands()).To(ConsistOf(
+			[]string{"CONFIG", "SET", "maxmemory", "1gb"},
+			[]string{"CONFIG", "SET", "save", "900 1 300 10"},
+			[]string{"REPLICAOF", "keydb-2.keydb-headless.default.svc.cluster.local", "6379"},
+		))
+
+		status := reloader.Status()
+		Expect(status.LastReloadTime).NotTo(BeNil())
+		Expect(status.Directives).To(Equal([]configreloader.DirectiveStatus{
+			{Directive: "maxmemory", Value: "1gb", Result: configreloader.ResultApplied},
+			{
+				Directive: "replicaof",
+				Value: "keydb-0.keydb-headless.default.svc.cluster.local 6379 " +
+					"keydb-1.keydb-headless.default.svc.cluster.local 6379 " +
+					"keydb-2.keydb-headless.default.svc.cluster.local 6379",
+				Result: configreloader.ResultApplied,
+			},
+			{Directive: "save", Value: "900 1 300 10", Result: configreloader.ResultApplied},
+			{Directive: "server-threads", Value: "4", Result: configreloader.ResultRestartRequired},
+		}))
+	})
+
+	It("should require a restart for removed directives", func() {
+		writeConfig(strings.Replace(strings.Replace(baseConfig, "maxmemory 256mb\n", "", 1),
+			"replicaof keydb-0.keydb-headless.default.svc.cluster.local 6379\n", "", 1))
+		Expect(reloader.Reload(ctx)).To(Succeed())
+
+		Expect(setCommands()).To(BeEmpty())
+		Expect(reloader.Status().Directives).To(Equal([]configreloader.DirectiveStatus{
+			{Directive: "maxmemory", Value: "", Result: configreloader.ResultRestartRequired},
+		}))
+	})
+
+	It("should report directives KeyDB rejects", func() {
+		server.Handle("CONFIG", func(args []string) interface{} {
+			if args[2] == "maxmemory-policy" {
+				return keydbclient.Error("ERR Invalid argument 'lru' for CONFIG SET 'maxmemory-policy'")
+			}
+			return "+OK"
+		})
+		writeConfig(baseConfig + "maxmemory-policy lru\nmaxmemory-samples 10\n")
+		Expect(reloader.Reload(ctx)).To(Succeed())
+
+		Expect(reloader.Status().Directives).To(ConsistOf(
+			configreloader.DirectiveStatus{
+				Directive: "maxmemory-policy", Value: "lru", Result: configreloader.ResultFailed,
+				Error: "ERR Invalid argument 'lru' for CONFIG SET 'maxmemory-policy'",
+			},
+			configreloader.DirectiveStatus{Directive: "maxmemory-samples", Value: "10", Result: configreloader.ResultApplied},
+		))
+
+		By("serving the status as JSON")
+		rec := httptest.NewRecorder()
+		reloader.ServeHTTP(rec, httptest.NewRequest("GET", configreloader.StatusPath, nil))
+		var status configreloader.Status
+		Expect(json.Unmarshal(rec.Body.Bytes(), &status)).To(Succeed())
+		Expect(status.ConfigHash).To(Equal(reloader.Status().ConfigHash))
+		Expect(status.Directives).To(Equal(reloader.Status().Directives))
+	})
+
+	It("should accept a replicaof the operator already applied", func() {
+		// After a failover the operator repoints the replicas before the
+		// ConfigMap catches up
+		server.SetReplication("keydb-2.keydb-headless.default.svc.cluster.local", "6379", 0)
+		writeConfig(strings.Replace(baseConfig, "keydb-0.", "keydb-2.", 1))
+		Expect(reloader.Reload(ctx)).To(Succeed())
+
+		Expect(reloader.Status().Directives).To(ConsistOf(configreloader.DirectiveStatus{
+			Directive: "replicaof",
+			Value:     "keydb-2.keydb-headless.default.svc.cluster.local 6379",
+			Result:    configreloader.ResultApplied,
+		}))
+	})
+
+	It("should retry when KeyDB cannot be reached", func() {
+		server.SetPassword("rotated")
+		writeConfig(strings.Replace(baseConfig, "256mb", "1gb", 1))
+		Expect(reloader.Reload(ctx)).To(MatchError(ContainSubstring("WRONGPASS")))
+		Expect(reloader.Status().Directives).To(BeEmpty())
+
+		server.SetPassword("s3cr3t")
+		Expect(reloader.Reload(ctx)).To(Succeed())
+		Expect(server.Config("maxmemory")).To(Equal("1gb"))
+	})
+
+	It("should keep the password of the metrics exporter in sync", func() {
+		var reloads int
+		exporter := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Path).To(Equal("/-/reload"))
+			reloads++
+		}))
+		DeferCleanup(exporter.Close)
+
+		dir := GinkgoT().TempDir()
+		passwordFile := filepath.Join(dir, "password")
+		exporterFile := filepath.Join(dir, "password.json")
+		Expect(os.WriteFile(passwordFile, []byte("s3cr3t\n"), 0o600)).To(Succeed())
+		reloader = configreloader.New(configreloader.Options{
+			ConfigFile:           configFile,
+			Addr:                 "localhost:6379",
+			PasswordFile:         passwordFile,
+			ExporterPasswordFile: exporterFile,
+			ExporterAddr:         strings.TrimPrefix(exporter.URL, "http://"),
+		})
+		exporterPassword := func() map[string]string {
+			content, err := os.ReadFile(exporterFile)
+			Expect(err).NotTo(HaveOccurred())
+			var passwords map[string]string
+			Expect(json.Unmarshal(content, &passwords)).To(Succeed())
+			return passwords
+		}
+
+		Expect(reloader.SyncExporterPassword(ctx)).To(Succeed())
+		Expect(exporterPassword()).To(Equal(map[string]string{"redis://localhost:6379": "s3cr3t"}))
+		Expect(reloader.SyncExporterPassword(ctx)).To(Succeed())
+		Expect(reloads).To(Equal(1))
+
+		By("reloading the exporter after a rotation")
+		Expect(os.WriteFile(passwordFile, []byte("rotated\n"), 0o600)).To(Succeed())
+		Expect(reloader.SyncExporterPassword(ctx)).To(Succeed())
+		Expect(exporterPassword()).To(Equal(map[string]string{"redis://localhost:6379": "rotated"}))
+		Expect(reloads).To(Equal(2))
+	})
+})
@@ -0,0 +1,102 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+var _ = Describe("Keydb conditions", func() {
+	var (
+		keydb *keydbv1.Keydb
+		r     *KeydbReconciler
+	)
+
+	BeforeEach(func() {
+		keydb = &keydbv1.Keydb{ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "one", Generation: 2}}
+		r = &KeydbReconciler{}
+	})
+
+	It("should only move LastTransitionTime on a transition", func() {
+		r.updateConditions(keydb, 1, 3, 3)
+		ready := meta.FindStatusCondition(keydb.Status.Conditions, keydbv1.ConditionTypeReady)
+		Expect(ready.Status).To(Equal(metav1.ConditionFalse))
+		past := metav1.NewTime(time.Now().Add(-time.Hour))
+		ready.LastTransitionTime = past
+
+		r.updateConditions(keydb, 2, 3, 3)
+		ready = meta.FindStatusCondition(keydb.Status.Conditions, keydbv1.ConditionTypeReady)
+		Expect(ready.Message).To(Equal("2/3 replicas are ready"))
+		Expect(ready.LastTransitionTime).To(Equal(past))
+
+		r.updateConditions(keydb, 3, 3, 3)
+		ready = meta.FindStatusCondition(keydb.Status.Conditions, keydbv1.ConditionTypeReady)
+		Expect(ready.Status).To(Equal(metav1.ConditionTrue))
+		Expect(ready.LastTransitionTime.After(past.Time)).To(BeTrue())
+	})
+
+	It("should report a degraded Keydb that still serves clients", func() {
+		keydb.Status.Nodes = []keydbv1.NodeStatus{
+			{Pod: "keydb-0", Role: "master"},
+			{Pod: "keydb-1", MasterHost: "keydb-0", LinkStatus: "up"},
+		}
+		r.updateConditions(keydb, 2, 2, 2)
+		Expect(meta.IsStatusConditionFalse(keydb.Status.Conditions, keydbv1.ConditionTypeDegraded)).To(BeTrue())
+
+		keydb.Status.Nodes[0].PersistenceError = "last RDB save failed"
+		r.updateConditions(keydb, 2, 2, 2)
+		degraded := meta.FindStatusCondition(keydb.Status.Conditions, keydbv1.ConditionTypeDegraded)
+		Expect(degraded.Status).To(Equal(metav1.ConditionTrue))
+		Expect(degraded.Reason).To(Equal(keydbv1.ReasonPersistenceUnhealthy))
+		Expect(degraded.Message).To(Equal("keydb-0: last RDB save failed"))
+
+		keydb.Status.Nodes[1].LinkStatus = "down"
+		r.updateConditions(keydb, 2, 2, 2)
+		degraded = meta.FindStatusCondition(keydb.Status.Conditions, keydbv1.ConditionTypeDegraded)
+		Expect(degraded.Reason).To(Equal(keydbv1.ReasonReplicationUnhealthy))
+		Expect(degraded.Message).To(Equal("keydb-1: link to keydb-0 is down; keydb-0: last RDB save failed"))
+
+		r.updateConditions(keydb, 0, 2, 2)
+		Expect(meta.IsStatusConditionFalse(keydb.Status.Conditions, keydbv1.ConditionTypeDegraded)).To(BeTrue())
+	})
+
+	It("should name the failing step in Reconciled", func() {
+		setReconciledCondition(keydb, "statefulset", errors.New("admission webhook denied the request"))
+		reconciled := meta.FindStatusCondition(keydb.Status.Conditions, keydbv1.ConditionTypeReconciled)
+		Expect(reconciled.Status).To(Equal(metav1.ConditionFalse))
+		Expect(reconciled.Reason).To(Equal(keydbv1.ReasonReconcileError))
+		Expect(reconciled.Message).To(Equal("Failed to reconcile statefulset: admission webhook denied the request"))
+		Expect(reconciled.ObservedGeneration).To(Equal(int64(2)))
+
+		setReconciledCondition(keydb, "", nil)
+		Expect(meta.IsStatusConditionTrue(keydb.Status.Conditions, keydbv1.ConditionTypeReconciled)).To(BeTrue())
+	})
+
+	It("should read persistence failures from INFO", func() {
+		Expect(persistenceError(map[string]string{"rdb_last_bgsave_status": "ok", "aof_last_write_status": "ok"})).To(BeEmpty())
+		Expect(persistenceError(map[string]string{"rdb_last_bgsave_status": "err", "aof_last_bgrewrite_status": "err"})).
+			To(Equal("last RDB save failed, last AOF rewrite failed"))
+	})
+})
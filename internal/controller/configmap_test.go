@@ -0,0 +1,108 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+)
+
+var _ = Describe("Master-replica config", func() {
+	var keydb *keydbv1.Keydb
+
+	BeforeEach(func() {
+		keydb = &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "default"},
+			Spec: keydbv1.KeydbSpec{
+				Replication: keydbv1.ReplicationSpec{Mode: keydbv1.ReplicationModeMasterReplica},
+			},
+			Status: keydbv1.KeydbStatus{Primary: "keydb-2"},
+		}
+	})
+
+	configMaps := func() (config, health *corev1.ConfigMap) {
+		cms, err := k8sresources.GenerateKeydbConfigMap(keydb, scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+		for _, cm := range cms {
+			switch cm.Name {
+			case "keydb-config":
+				config = cm
+			case "keydb-healthz":
+				health = cm
+			}
+		}
+		return config, health
+	}
+
+	replicaOf := func(conf string) []string {
+		var lines []string
+		for _, line := range strings.Split(conf, "\n") {
+			if strings.HasPrefix(line, "replicaof ") {
+				lines = append(lines, line)
+			}
+		}
+		return lines
+	}
+
+	It("should point every pod at the elected primary", func() {
+		config, _ := configMaps()
+		Expect(replicaOf(config.Data["keydb.conf"])).To(Equal([]string{
+			"replicaof keydb-2.keydb-headless.default.svc.cluster.local 6379",
+		}))
+	})
+
+	It("should drop the replicaof line on the primary only", func() {
+		bash, err := exec.LookPath("bash")
+		if err != nil {
+			Skip("bash is not installed")
+		}
+		config, health := configMaps()
+		dir := GinkgoT().TempDir()
+		source := filepath.Join(dir, "keydb.conf")
+		Expect(os.WriteFile(source, []byte(config.Data["keydb.conf"]), 0o644)).To(Succeed())
+
+		// Run the startup script against files in dir instead of the mounts
+		script := health.Data["fix_replication_config.sh"]
+		script = regexp.MustCompile(`CONFIG_SOURCE="[^"]*"`).ReplaceAllString(script, `CONFIG_SOURCE="`+source+`"`)
+		script = strings.Replace(script, `CONFIG_FILE="/tmp/keydb.conf"`, `CONFIG_FILE="`+dir+`/fixed.conf"`, 1)
+		fixed := func(hostname string) []string {
+			cmd := exec.Command(bash, "-c", script)
+			cmd.Env = []string{"HOSTNAME=" + hostname}
+			Expect(cmd.Run()).To(Succeed())
+			content, err := os.ReadFile(filepath.Join(dir, "fixed.conf"))
+			Expect(err).NotTo(HaveOccurred())
+			return replicaOf(string(content))
+		}
+
+		Expect(fixed("keydb-2")).To(BeEmpty())
+		Expect(fixed("keydb-0")).To(Equal([]string{"replicaof keydb-2.keydb-headless.default.svc.cluster.local 6379"}))
+		// keydb-2 must not match keydb-20 and the like
+		Expect(fixed("keydb-20")).To(HaveLen(1))
+	})
+})
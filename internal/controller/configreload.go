@@ -0,0 +1,127 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	"github.com/rsingh0101/keydb-operator/internal/configreloader"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// configReloadTimeout bounds the status query to the reloader of a pod
+const configReloadTimeout = 2 * time.Second
+
+// collectConfigReloadStatus asks the config reloader of every running pod how
+// it applied keydb.conf. A pod is up to date once it has processed the
+// content of the ConfigMap as it is now.
+func (r *KeydbReconciler) collectConfigReloadStatus(ctx context.Context, keydb *keydbv1.Keydb, pods []corev1.Pod) []keydbv1.ConfigReloadStatus {
+	var cm corev1.ConfigMap
+	if err := r.Get(ctx, types.NamespacedName{Name: keydb.Name + "-config", Namespace: keydb.Namespace}, &cm); err != nil {
+		log.FromContext(ctx).V(1).Info("unable to collect config reload status", "error", err.Error())
+		return nil
+	}
+	want := configreloader.Hash([]byte(cm.Data["keydb.conf"]))
+
+	var statuses []keydbv1.ConfigReloadStatus
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			continue
+		}
+
+		status := keydbv1.ConfigReloadStatus{Pod: pod.Name}
+		reported, err := reloaderStatus(ctx, pod.Status.PodIP)
+		if err != nil {
+			status.Error = err.Error()
+			statuses = append(statuses, status)
+			continue
+		}
+
+		status.UpToDate = reported.ConfigHash == want
+		if reported.LastReloadTime != nil {
+			t := metav1.NewTime(*reported.LastReloadTime)
+			status.LastReloadTime = &t
+		}
+		for _, d := range reported.Directives {
+			switch d.Result {
+			case configreloader.ResultFailed:
+				status.Failed = append(status.Failed, keydbv1.DirectiveError{Directive: d.Directive, Error: d.Error})
+			case configreloader.ResultRestartRequired:
+				status.PendingRestart = append(status.PendingRestart, d.Directive)
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses
+}
+
+// reloaderStatus queries the status endpoint of the config reloader in a pod.
+func reloaderStatus(ctx context.Context, podIP string) (*configreloader.Status, error) {
+	ctx, cancel := context.WithTimeout(ctx, configReloadTimeout)
+	defer cancel()
+
+	url := "http://" + net.JoinHostPort(podIP, strconv.Itoa(configreloader.Port)) + configreloader.StatusPath
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("config reloader returned %s", resp.Status)
+	}
+	var status configreloader.Status
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// reportConfigReloadFailures emits an event for every directive a pod newly
+// rejected since the previous status.
+func (r *KeydbReconciler) reportConfigReloadFailures(keydb *keydbv1.Keydb, previous []keydbv1.ConfigReloadStatus) {
+	seen := map[string]bool{}
+	for _, status := range previous {
+		for _, f := range status.Failed {
+			seen[status.Pod+"/"+f.Directive+"/"+f.Error] = true
+		}
+	}
+	for _, status := range keydb.Status.ConfigReload {
+		for _, f := range status.Failed {
+			if seen[status.Pod+"/"+f.Directive+"/"+f.Error] {
+				continue
+			}
+			r.event(keydb, corev1.EventTypeWarning, "ConfigReloadFailed",
+				fmt.Sprintf("Pod %s rejected %s: %s", status.Pod, f.Directive, f.Error))
+		}
+	}
+}
@@ -0,0 +1,165 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// eventDedupWindow is how long an event is suppressed after the same event
+// was recorded for the same object.
+const eventDedupWindow = 10 * time.Minute
+
+type eventKey struct {
+	uid                        types.UID
+	namespace, name            string
+	eventType, reason, message string
+}
+
+// dedupRecorder drops events that repeat within eventDedupWindow, so a
+// reconcile that keeps failing reports the error once instead of on every
+// retry.
+type dedupRecorder struct {
+	record.EventRecorder
+	window time.Duration
+	now    func() time.Time
+
+	mu   sync.Mutex
+	seen map[eventKey]time.Time
+}
+
+// DeduplicateEvents wraps recorder so that identical events for the same
+// object are recorded at most once every ten minutes.
+func DeduplicateEvents(recorder record.EventRecorder) record.EventRecorder {
+	return &dedupRecorder{
+		EventRecorder: recorder,
+		window:        eventDedupWindow,
+		now:           time.Now,
+		seen:          map[eventKey]time.Time{},
+	}
+}
+
+// Event implements record.EventRecorder.
+func (d *dedupRecorder) Event(object runtime.Object, eventType, reason, message string) {
+	if d.record(object, eventType, reason, message) {
+		d.EventRecorder.Event(object, eventType, reason, message)
+	}
+}
+
+// Eventf implements record.EventRecorder.
+func (d *dedupRecorder) Eventf(object runtime.Object, eventType, reason, messageFmt string, args ...interface{}) {
+	d.Event(object, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// AnnotatedEventf implements record.EventRecorder.
+func (d *dedupRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventType, reason, messageFmt string, args ...interface{}) {
+	message := fmt.Sprintf(messageFmt, args...)
+	if d.record(object, eventType, reason, message) {
+		d.EventRecorder.AnnotatedEventf(object, annotations, eventType, reason, "%s", message)
+	}
+}
+
+// record reports whether the event is new, remembering it if so.
+func (d *dedupRecorder) record(object runtime.Object, eventType, reason, message string) bool {
+	obj, ok := object.(client.Object)
+	if !ok {
+		return true
+	}
+	key := eventKey{
+		uid:       obj.GetUID(),
+		namespace: obj.GetNamespace(),
+		name:      obj.GetName(),
+		eventType: eventType,
+		reason:    reason,
+		message:   message,
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	for k, at := range d.seen {
+		if now.Sub(at) >= d.window {
+			delete(d.seen, k)
+		}
+	}
+	if _, ok := d.seen[key]; ok {
+		return false
+	}
+	d.seen[key] = now
+	return true
+}
+
+// reportStatefulSetChanges records the scaling and rolling restarts applying
+// desired over the existing StatefulSet will cause.
+func (r *KeydbReconciler) reportStatefulSetChanges(keydb *keydbv1.Keydb, existing, desired *appsv1.StatefulSet) {
+	from, to := int32(1), int32(1)
+	if existing.Spec.Replicas != nil {
+		from = *existing.Spec.Replicas
+	}
+	if desired.Spec.Replicas != nil {
+		to = *desired.Spec.Replicas
+	}
+	switch {
+	case to > from:
+		r.event(keydb, corev1.EventTypeNormal, keydbv1.ReasonScalingUp, fmt.Sprintf("Scaling up from %d to %d replicas", from, to))
+	case to < from:
+		r.event(keydb, corev1.EventTypeNormal, keydbv1.ReasonScalingDown, fmt.Sprintf("Scaling down from %d to %d replicas", from, to))
+	}
+
+	var changed []string
+	if existing.Spec.Template.Annotations["checksum/config"] != desired.Spec.Template.Annotations["checksum/config"] {
+		changed = append(changed, "spec.config")
+	}
+	if existing.Spec.Template.Spec.Containers[0].Image != desired.Spec.Template.Spec.Containers[0].Image {
+		changed = append(changed, "image "+desired.Spec.Template.Spec.Containers[0].Image)
+	}
+	if len(changed) > 0 {
+		r.event(keydb, corev1.EventTypeNormal, "RollingRestart",
+			"Restarting the pods one at a time to apply "+strings.Join(changed, " and "))
+	}
+}
+
+// reportReplicationLinks records the replicas that lost or got back the link
+// to their primary since the previous status.
+func (r *KeydbReconciler) reportReplicationLinks(keydb *keydbv1.Keydb, previous []keydbv1.NodeStatus) {
+	wasDown := map[string]bool{}
+	for _, node := range previous {
+		wasDown[node.Pod] = node.LinkStatus == "down"
+	}
+	for _, node := range keydb.Status.Nodes {
+		down, known := wasDown[node.Pod]
+		switch {
+		case node.LinkStatus == "down" && !down:
+			r.event(keydb, corev1.EventTypeWarning, "ReplicationLinkDown",
+				fmt.Sprintf("Pod %s lost the replication link to %s", node.Pod, node.MasterHost))
+		case node.LinkStatus == "up" && known && down:
+			r.event(keydb, corev1.EventTypeNormal, "ReplicationLinkRestored",
+				fmt.Sprintf("Pod %s is replicating from %s again", node.Pod, node.MasterHost))
+		}
+	}
+}
@@ -0,0 +1,107 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+var _ = Describe("Events", func() {
+	var (
+		keydb    *keydbv1.Keydb
+		recorder *record.FakeRecorder
+	)
+
+	BeforeEach(func() {
+		keydb = &keydbv1.Keydb{ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "one", UID: "uid"}}
+		recorder = record.NewFakeRecorder(10)
+	})
+
+	drain := func() []string {
+		var events []string
+		for len(recorder.Events) > 0 {
+			events = append(events, <-recorder.Events)
+		}
+		return events
+	}
+
+	It("should drop an event repeated within the window", func() {
+		now := time.Now()
+		dedup := DeduplicateEvents(recorder).(*dedupRecorder)
+		dedup.now = func() time.Time { return now }
+
+		dedup.Event(keydb, corev1.EventTypeWarning, keydbv1.ReasonReconcileError, "boom")
+		dedup.Eventf(keydb, corev1.EventTypeWarning, keydbv1.ReasonReconcileError, "%s", "boom")
+		dedup.Event(keydb, corev1.EventTypeWarning, keydbv1.ReasonReconcileError, "other")
+		other := keydb.DeepCopy()
+		other.Name, other.UID = "two", "uid-two"
+		dedup.Event(other, corev1.EventTypeWarning, keydbv1.ReasonReconcileError, "boom")
+		Expect(drain()).To(HaveLen(3))
+
+		now = now.Add(eventDedupWindow)
+		dedup.Event(keydb, corev1.EventTypeWarning, keydbv1.ReasonReconcileError, "boom")
+		Expect(drain()).To(Equal([]string{"Warning ReconcileError boom"}))
+	})
+
+	It("should report scaling and rolling restarts", func() {
+		r := &KeydbReconciler{Recorder: recorder}
+		sts := func(replicas int32, config, image string) *appsv1.StatefulSet {
+			s := &appsv1.StatefulSet{}
+			s.Spec.Replicas = ptr.To(replicas)
+			s.Spec.Template.Annotations = map[string]string{"checksum/config": config}
+			s.Spec.Template.Spec.Containers = []corev1.Container{{Name: "keydb", Image: image}}
+			return s
+		}
+
+		r.reportStatefulSetChanges(keydb, sts(3, "a", "keydb:1"), sts(3, "a", "keydb:1"))
+		Expect(drain()).To(BeEmpty())
+
+		r.reportStatefulSetChanges(keydb, sts(1, "a", "keydb:1"), sts(3, "b", "keydb:2"))
+		Expect(drain()).To(Equal([]string{
+			"Normal ScalingUp Scaling up from 1 to 3 replicas",
+			"Normal RollingRestart Restarting the pods one at a time to apply spec.config and image keydb:2",
+		}))
+	})
+
+	It("should report replication links going down and coming back", func() {
+		r := &KeydbReconciler{Recorder: recorder}
+		keydb.Status.Nodes = []keydbv1.NodeStatus{
+			{Pod: "keydb-0", Role: "master"},
+			{Pod: "keydb-1", MasterHost: "keydb-0", LinkStatus: "down"},
+		}
+		r.reportReplicationLinks(keydb, nil)
+		Expect(drain()).To(Equal([]string{"Warning ReplicationLinkDown Pod keydb-1 lost the replication link to keydb-0"}))
+
+		previous := keydb.Status.Nodes
+		r.reportReplicationLinks(keydb, previous)
+		Expect(drain()).To(BeEmpty())
+
+		keydb.Status.Nodes = []keydbv1.NodeStatus{{Pod: "keydb-1", MasterHost: "keydb-0", LinkStatus: "up"}}
+		r.reportReplicationLinks(keydb, previous)
+		Expect(drain()).To(Equal([]string{"Normal ReplicationLinkRestored Pod keydb-1 is replicating from keydb-0 again"}))
+	})
+})
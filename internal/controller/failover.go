@@ -0,0 +1,221 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	"github.com/rsingh0101/keydb-operator/internal/metrics"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// failoverGracePeriod is how long every replica must have lost its link to
+// the primary before a replica is promoted, so a quick restart of the primary
+// pod does not trigger a failover.
+const failoverGracePeriod = 15 * time.Second
+
+// failoverCandidate is a replica that answered INFO replication.
+type failoverCandidate struct {
+	pod    *corev1.Pod
+	offset int64
+}
+
+// reconcileFailover promotes a replica when the primary of a master-replica
+// cluster is lost. It returns a non-zero duration when the primary is down but
+// the grace period has not elapsed yet, or while a failover is completing.
+//
+// The chosen replica is recorded in status before it is promoted, so an
+// interrupted failover is finished on the same pod instead of electing
+// another one and ending up with two writable primaries. The Failover
+// condition stays True until the new primary serves and every replica
+// follows it.
+func (r *KeydbReconciler) reconcileFailover(ctx context.Context, keydb *keydbv1.Keydb) (time.Duration, error) {
+	if keydb.Spec.Replication.Mode != keydbv1.ReplicationModeMasterReplica || r.KeydbClients == nil {
+		return 0, nil
+	}
+	// A restore restarts the primary on purpose; promoting a replica would
+	// bring back the data being replaced
+	if _, restoring := keydb.Annotations[keydbv1.AnnotationRestore]; restoring {
+		return 0, nil
+	}
+	logger := log.FromContext(ctx)
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList,
+		client.InNamespace(keydb.Namespace),
+		client.MatchingLabels{"apps": keydb.Name},
+	); err != nil {
+		return 0, err
+	}
+
+	opts, err := keydbOptions(ctx, r.Client, keydb)
+	if err != nil {
+		return 0, err
+	}
+
+	oldPrimary := k8sresources.PrimaryPodName(keydb)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Name == oldPrimary && pod.DeletionTimestamp == nil && isPodReady(pod) {
+			// Primary is serving; finish a failover to it if one is under way
+			if meta.IsStatusConditionTrue(keydb.Status.Conditions, keydbv1.ConditionTypeFailover) {
+				return r.completeFailover(ctx, keydb, opts, pod, podList.Items)
+			}
+			return 0, nil
+		}
+	}
+
+	var promoted *corev1.Pod
+	var candidates []failoverCandidate
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Name == oldPrimary || pod.DeletionTimestamp != nil || !isPodReady(pod) {
+			continue
+		}
+
+		info, err := podInfo(ctx, r.KeydbClients, keydb, opts, pod, "replication")
+		if err != nil {
+			logger.V(1).Info("unable to query replica", "pod", pod.Name, "error", err.Error())
+			continue
+		}
+
+		// A replica that already left the primary was promoted by a failover
+		// whose status update was lost; it stays the winner
+		if info["role"] == "master" {
+			promoted = pod
+			break
+		}
+		// A replica still connected to the primary means the primary is only
+		// failing its probes, not lost.
+		if info["master_link_status"] == "up" {
+			return 0, nil
+		}
+		if downSince, err := strconv.Atoi(info["master_link_down_since_seconds"]); err == nil &&
+			downSince >= 0 && time.Duration(downSince)*time.Second < failoverGracePeriod {
+			return failoverGracePeriod - time.Duration(downSince)*time.Second, nil
+		}
+
+		offset, _ := strconv.ParseInt(info["slave_repl_offset"], 10, 64)
+		candidates = append(candidates, failoverCandidate{pod: pod, offset: offset})
+	}
+
+	newPrimary := promoted
+	if newPrimary == nil {
+		if len(candidates) == 0 {
+			logger.Info("primary is down but no replica is available for promotion", "primary", oldPrimary)
+			return failoverGracePeriod, nil
+		}
+		// Highest replication offset wins; ties go to the lowest pod name for determinism
+		sort.SliceStable(candidates, func(i, j int) bool {
+			if candidates[i].offset != candidates[j].offset {
+				return candidates[i].offset > candidates[j].offset
+			}
+			return candidates[i].pod.Name < candidates[j].pod.Name
+		})
+		newPrimary = candidates[0].pod
+		logger.Info("promoting replica", "oldPrimary", oldPrimary, "newPrimary", newPrimary.Name, "offset", candidates[0].offset)
+	}
+
+	message := fmt.Sprintf("Primary failed over from %s to %s", oldPrimary, newPrimary.Name)
+	keydb.Status.Primary = newPrimary.Name
+	// Remove first so LastTransitionTime records this failover and not the first one
+	meta.RemoveStatusCondition(&keydb.Status.Conditions, keydbv1.ConditionTypeFailover)
+	meta.SetStatusCondition(&keydb.Status.Conditions, metav1.Condition{
+		Type:               keydbv1.ConditionTypeFailover,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: keydb.Generation,
+		Reason:             keydbv1.ReasonPrimaryFailover,
+		Message:            message,
+	})
+	if err := r.Status().Update(ctx, keydb); err != nil {
+		return 0, err
+	}
+	metrics.Failovers.WithLabelValues(keydb.Namespace, keydb.Name).Inc()
+	if r.Recorder != nil {
+		r.Recorder.Event(keydb, corev1.EventTypeWarning, keydbv1.ReasonPrimaryFailover, message)
+	}
+
+	return r.completeFailover(ctx, keydb, opts, newPrimary, podList.Items)
+}
+
+// completeFailover promotes the primary recorded in status if it still
+// replicates, points the other replicas at it and sets the Failover condition
+// to False once they all follow it.
+func (r *KeydbReconciler) completeFailover(ctx context.Context, keydb *keydbv1.Keydb, opts keydbclient.Options, primary *corev1.Pod, pods []corev1.Pod) (time.Duration, error) {
+	logger := log.FromContext(ctx)
+
+	info, err := podInfo(ctx, r.KeydbClients, keydb, opts, primary, "replication")
+	if err != nil {
+		logger.V(1).Info("unable to query new primary", "pod", primary.Name, "error", err.Error())
+		return failoverGracePeriod, nil
+	}
+	if info["role"] != "master" {
+		if err := withPod(ctx, r.KeydbClients, keydb, opts, primary, func(c *keydbclient.Client) error {
+			return c.ReplicaOfNoOne(ctx)
+		}); err != nil {
+			return 0, fmt.Errorf("failed to promote %s: %w", primary.Name, err)
+		}
+	}
+
+	primaryHost := k8sresources.PodFQDN(keydb, primary.Name)
+	following := true
+	for i := range pods {
+		pod := &pods[i]
+		if pod.Name == primary.Name || pod.DeletionTimestamp != nil || !isPodReady(pod) {
+			continue
+		}
+		info, err := podInfo(ctx, r.KeydbClients, keydb, opts, pod, "replication")
+		if err != nil {
+			following = false
+			continue
+		}
+		if info["master_host"] == primaryHost {
+			following = following && info["master_link_status"] == "up"
+			continue
+		}
+		following = false
+		if err := withPod(ctx, r.KeydbClients, keydb, opts, pod, func(c *keydbclient.Client) error {
+			return c.ReplicaOf(ctx, primaryHost, keydbPort)
+		}); err != nil {
+			// The replica picks up the new primary from the ConfigMap on restart
+			logger.Error(err, "failed to repoint replica", "pod", pod.Name)
+		}
+	}
+	if !following {
+		return failoverGracePeriod, nil
+	}
+
+	meta.SetStatusCondition(&keydb.Status.Conditions, metav1.Condition{
+		Type:               keydbv1.ConditionTypeFailover,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: keydb.Generation,
+		Reason:             keydbv1.ReasonPrimaryHealthy,
+		Message:            fmt.Sprintf("%s is the primary and every replica follows it", primary.Name),
+	})
+	return 0, r.Status().Update(ctx, keydb)
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/ptr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	keydbfake "github.com/rsingh0101/keydb-operator/internal/keydbclient/fake"
+)
+
+// fakeCluster is a master-replica Keydb on a fake API server whose pods are
+// served by fake KeyDB servers. keydb-0 is the primary and the other pods
+// replicate from it.
+type fakeCluster struct {
+	keydb   *keydbv1.Keydb
+	pods    []corev1.Pod
+	servers map[string]*keydbfake.Server
+	r       *KeydbReconciler
+}
+
+func newFakeCluster(replicas int) *fakeCluster {
+	keydb := &keydbv1.Keydb{
+		ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "default"},
+		Spec: keydbv1.KeydbSpec{
+			Replicas:    ptr.To(int32(replicas)),
+			Replication: keydbv1.ReplicationSpec{Mode: keydbv1.ReplicationModeMasterReplica},
+		},
+	}
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "keydb-secret", Namespace: "default"},
+		Data:       map[string][]byte{k8sresources.SecretKeyPassword: []byte("s3cr3t")},
+	}
+	fc := &fakeCluster{keydb: keydb, servers: map[string]*keydbfake.Server{}}
+	objects := []client.Object{keydb, secret}
+	for i := 0; i < replicas; i++ {
+		name := fmt.Sprintf("keydb-%d", i)
+		server, err := keydbfake.NewServer()
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(server.Close)
+		server.SetPassword("s3cr3t")
+		if i > 0 {
+			server.SetReplication(k8sresources.PodFQDN(keydb, "keydb-0"), "6379", 0)
+		}
+		fc.servers[name] = server
+
+		fc.pods = append(fc.pods, corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{"apps": "keydb"}},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				PodIP:      fmt.Sprintf("10.0.0.%d", i+1),
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		})
+	}
+	for i := range fc.pods {
+		objects = append(objects, &fc.pods[i])
+	}
+
+	previous := podAddr
+	podAddr = func(pod *corev1.Pod) string { return fc.servers[pod.Name].Addr() }
+	DeferCleanup(func() { podAddr = previous })
+
+	pool := keydbclient.NewPool()
+	DeferCleanup(pool.Forget, "default/keydb")
+	fc.r = &KeydbReconciler{
+		Client: fake.NewClientBuilder().
+			WithScheme(scheme.Scheme).
+			WithStatusSubresource(&keydbv1.Keydb{}).
+			WithObjects(objects...).
+			Build(),
+		Scheme:       scheme.Scheme,
+		KeydbClients: pool,
+		Recorder:     record.NewFakeRecorder(10),
+	}
+	return fc
+}
+
+// stop takes a pod out of service, as a crashed KeyDB would be.
+func (fc *fakeCluster) stop(ctx context.Context, name string) {
+	for i := range fc.pods {
+		if fc.pods[i].Name == name {
+			fc.pods[i].Status.Conditions = nil
+			Expect(fc.r.Status().Update(ctx, &fc.pods[i])).To(Succeed())
+		}
+	}
+}
+
+// replicaOfCommands returns the REPLICAOF commands a pod received.
+func (fc *fakeCluster) replicaOfCommands(name string) [][]string {
+	var commands [][]string
+	for _, cmd := range fc.servers[name].Commands() {
+		if cmd[0] == "REPLICAOF" {
+			commands = append(commands, cmd)
+		}
+	}
+	return commands
+}
+
+var _ = Describe("Failover", func() {
+	var (
+		ctx context.Context
+		fc  *fakeCluster
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fc = newFakeCluster(3)
+		fc.stop(ctx, "keydb-0")
+	})
+
+	failover := func() time.Duration {
+		keydb := &keydbv1.Keydb{}
+		Expect(fc.r.Get(ctx, client.ObjectKeyFromObject(fc.keydb), keydb)).To(Succeed())
+		wait, err := fc.r.reconcileFailover(ctx, keydb)
+		Expect(err).NotTo(HaveOccurred())
+		fc.keydb = keydb
+		return wait
+	}
+	failoverCondition := func() *metav1.Condition {
+		return meta.FindStatusCondition(fc.keydb.Status.Conditions, keydbv1.ConditionTypeFailover)
+	}
+
+	It("should wait out the grace period before promoting", func() {
+		fc.servers["keydb-1"].SetLinkDown(5)
+		fc.servers["keydb-2"].SetLinkDown(5)
+
+		Expect(failover()).To(Equal(failoverGracePeriod - 5*time.Second))
+		Expect(fc.keydb.Status.Primary).To(BeEmpty())
+		Expect(fc.replicaOfCommands("keydb-1")).To(BeEmpty())
+		Expect(fc.replicaOfCommands("keydb-2")).To(BeEmpty())
+	})
+
+	It("should not fail over while a replica still reaches the primary", func() {
+		fc.servers["keydb-2"].SetLinkDown(60)
+
+		Expect(failover()).To(BeZero())
+		Expect(fc.keydb.Status.Primary).To(BeEmpty())
+	})
+
+	It("should promote the replica with the largest offset", func() {
+		fc.servers["keydb-1"].SetReplication(k8sresources.PodFQDN(fc.keydb, "keydb-0"), "6379", 100)
+		fc.servers["keydb-2"].SetReplication(k8sresources.PodFQDN(fc.keydb, "keydb-0"), "6379", 200)
+		fc.servers["keydb-1"].SetLinkDown(60)
+		fc.servers["keydb-2"].SetLinkDown(60)
+
+		Expect(failover()).To(Equal(failoverGracePeriod))
+		Expect(fc.keydb.Status.Primary).To(Equal("keydb-2"))
+		Expect(failoverCondition().Status).To(Equal(metav1.ConditionTrue))
+		Expect(fc.replicaOfCommands("keydb-2")).To(Equal([][]string{{"REPLICAOF", "NO", "ONE"}}))
+		Expect(fc.replicaOfCommands("keydb-1")).To(Equal([][]string{
+			{"REPLICAOF", k8sresources.PodFQDN(fc.keydb, "keydb-2"), "6379"},
+		}))
+	})
+
+	It("should not fail over while a restore holds the Keydb", func() {
+		fc.servers["keydb-1"].SetLinkDown(60)
+		fc.servers["keydb-2"].SetLinkDown(60)
+		fc.keydb.Annotations = map[string]string{keydbv1.AnnotationRestore: "restore"}
+		Expect(fc.r.Update(ctx, fc.keydb)).To(Succeed())
+
+		Expect(failover()).To(BeZero())
+		Expect(fc.keydb.Status.Primary).To(BeEmpty())
+		Expect(fc.replicaOfCommands("keydb-1")).To(BeEmpty())
+		Expect(fc.replicaOfCommands("keydb-2")).To(BeEmpty())
+	})
+
+	It("should finish an interrupted failover on the recorded primary", func() {
+		// The status update went through but the operator stopped before
+		// keydb-2 was promoted
+		fc.keydb.Status.Primary = "keydb-2"
+		meta.SetStatusCondition(&fc.keydb.Status.Conditions, metav1.Condition{
+			Type: keydbv1.ConditionTypeFailover, Status: metav1.ConditionTrue, Reason: keydbv1.ReasonPrimaryFailover,
+		})
+		Expect(fc.r.Status().Update(ctx, fc.keydb)).To(Succeed())
+		fc.servers["keydb-1"].SetLinkDown(60)
+		fc.servers["keydb-2"].SetLinkDown(60)
+
+		failover()
+		Expect(fc.keydb.Status.Primary).To(Equal("keydb-2"))
+		Expect(fc.replicaOfCommands("keydb-2")).To(Equal([][]string{{"REPLICAOF", "NO", "ONE"}}))
+		Expect(fc.replicaOfCommands("keydb-1")).To(Equal([][]string{
+			{"REPLICAOF", k8sresources.PodFQDN(fc.keydb, "keydb-2"), "6379"},
+		}))
+	})
+
+	It("should report the primary healthy only once every replica follows it", func() {
+		fc.servers["keydb-1"].SetLinkDown(60)
+		fc.servers["keydb-2"].SetLinkDown(60)
+		Expect(failover()).To(Equal(failoverGracePeriod))
+		promoted := fc.keydb.Status.Primary
+		Expect(promoted).NotTo(BeEmpty())
+		replica := "keydb-1"
+		if promoted == replica {
+			replica = "keydb-2"
+		}
+
+		By("waiting while the repointed replica has not synced yet")
+		fc.servers[replica].SetLinkDown(1)
+		Expect(failover()).To(Equal(failoverGracePeriod))
+		Expect(failoverCondition().Status).To(Equal(metav1.ConditionTrue))
+
+		By("clearing Failover once the link is up")
+		fc.servers[replica].SetReplication(k8sresources.PodFQDN(fc.keydb, promoted), "6379", 0)
+		Expect(failover()).To(BeZero())
+		Expect(failoverCondition().Status).To(Equal(metav1.ConditionFalse))
+		Expect(failoverCondition().Reason).To(Equal(keydbv1.ReasonPrimaryHealthy))
+	})
+})
@@ -0,0 +1,249 @@
+package k8sresources
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// LabelBackup is set on the Jobs and pods of a KeydbBackup. It must not be the
+// "apps" label, which the Keydb Services select on.
+const LabelBackup = "keydb.keydb/backup"
+
+// BackupUploadContainer is the Job container that reports the snapshot size
+// and checksum in its termination message.
+const BackupUploadContainer = "upload"
+
+// uploadScript copies /backup/dump.rdb to $S3_URL and writes
+// {"size":N,"sha256":"..."} to the termination log for the controller.
+const uploadScript = `set -eu
+SIZE=$(stat -c %s /backup/dump.rdb)
+SUM=$(sha256sum /backup/dump.rdb | cut -d' ' -f1)
+if [ -n "${S3_ENDPOINT:-}" ]; then
+  aws --endpoint-url "$S3_ENDPOINT" s3 cp /backup/dump.rdb "$S3_URL"
+else
+  aws s3 cp /backup/dump.rdb "$S3_URL"
+fi
+printf '{"size":%s,"sha256":"%s"}' "$SIZE" "$SUM" > /dev/termination-log
+`
+
+// cleanupScript removes $S3_URL from the bucket.
+const cleanupScript = `set -eu
+if [ -n "${S3_ENDPOINT:-}" ]; then
+  aws --endpoint-url "$S3_ENDPOINT" s3 rm "$S3_URL"
+else
+  aws s3 rm "$S3_URL"
+fi
+`
+
+// BackupJobName returns the name of the Job uploading a backup.
+func BackupJobName(b *keydbv1.KeydbBackup) string {
+	return b.Name + "-backup"
+}
+
+// BackupLocation returns the s3:// URL a backup is uploaded to.
+func BackupLocation(b *keydbv1.KeydbBackup) string {
+	key := path.Join(b.Spec.Storage.S3.Prefix, b.Namespace, b.Spec.KeydbName, b.Name+".rdb")
+	return fmt.Sprintf("s3://%s/%s", b.Spec.Storage.S3.Bucket, key)
+}
+
+// S3Env returns the environment the aws CLI needs to reach an S3 bucket.
+func S3Env(s3 keydbv1.S3StorageSpec) []corev1.EnvVar {
+	return s3Env(s3.CredentialsSecret, s3.Endpoint, s3.Region)
+}
+
+func s3Env(credentials corev1.LocalObjectReference, endpoint, region string) []corev1.EnvVar {
+	secretKey := func(key string) *corev1.EnvVarSource {
+		return &corev1.EnvVarSource{
+			SecretKeyRef: &corev1.SecretKeySelector{
+				LocalObjectReference: credentials,
+				Key:                  key,
+			},
+		}
+	}
+	env := []corev1.EnvVar{
+		{Name: "AWS_ACCESS_KEY_ID", ValueFrom: secretKey("AWS_ACCESS_KEY_ID")},
+		{Name: "AWS_SECRET_ACCESS_KEY", ValueFrom: secretKey("AWS_SECRET_ACCESS_KEY")},
+		{Name: "AWS_EC2_METADATA_DISABLED", Value: "true"},
+		// The aws CLI writes to $HOME, which does not exist for a non-root user
+		{Name: "HOME", Value: "/tmp"},
+		{Name: "S3_ENDPOINT", Value: endpoint},
+	}
+	if region != "" {
+		env = append(env, corev1.EnvVar{Name: "AWS_DEFAULT_REGION", Value: region})
+	}
+	return env
+}
+
+// GenerateBackupJob returns the Job that copies the snapshot written on
+// sourcePod and uploads it. With persistence the Job runs on the pod's node and
+// reads dump.rdb from its volume, so the uploaded file is the one BGSAVE
+// produced; otherwise it pulls a fresh RDB over the replication protocol.
+func GenerateBackupJob(b *keydbv1.KeydbBackup, k *keydbv1.Keydb, sourcePod *corev1.Pod, scheme *runtime.Scheme) *batchv1.Job {
+	labels := map[string]string{LabelBackup: b.Name}
+	backoffLimit := int32(2)
+
+	image := b.Spec.Image
+	if image == "" {
+		image = keydbv1.DefaultBackupImage
+	}
+	fetchImage := keydbImage(k)
+
+	volumes := []corev1.Volume{
+		{
+			Name:         "backup",
+			VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}},
+		},
+	}
+	backupMount := corev1.VolumeMount{Name: "backup", MountPath: "/backup"}
+
+	var fetch corev1.Container
+	nodeName := ""
+	if k.Spec.Persistence.Enabled {
+		nodeName = sourcePod.Spec.NodeName
+		volumes = append(volumes, corev1.Volume{
+			Name: "data",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: DataPVCName(k, sourcePod.Name),
+					ReadOnly:  true,
+				},
+			},
+		})
+		fetch = corev1.Container{
+			Name:         "fetch",
+			Image:        fetchImage,
+			Command:      []string{"cp", jobDataDir + "/dump.rdb", "/backup/dump.rdb"},
+			VolumeMounts: []corev1.VolumeMount{backupMount, {Name: "data", MountPath: jobDataDir, ReadOnly: true}},
+		}
+	} else {
+		secretName, secretKey := PasswordSecretRef(k)
+		fetch = corev1.Container{
+			Name:  "fetch",
+			Image: fetchImage,
+			Command: []string{"keydb-cli",
+				"-h", PodFQDN(k, sourcePod.Name),
+				"-p", "6379",
+				"--rdb", "/backup/dump.rdb",
+			},
+			Env: []corev1.EnvVar{
+				{
+					Name: "REDISCLI_AUTH",
+					ValueFrom: &corev1.EnvVarSource{
+						SecretKeyRef: &corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: secretName},
+							Key:                  secretKey,
+						},
+					},
+				},
+			},
+			VolumeMounts: []corev1.VolumeMount{backupMount},
+		}
+		if tlsSecret := TLSSecretName(k); tlsSecret != "" {
+			fetch.Command = append(fetch.Command, strings.Fields(keydbCLITLSArgs)...)
+			fetch.VolumeMounts = append(fetch.VolumeMounts, corev1.VolumeMount{Name: "tls", MountPath: TLSMountPath, ReadOnly: true})
+			volumes = append(volumes, corev1.Volume{
+				Name:         "tls",
+				VolumeSource: corev1.VolumeSource{Secret: &corev1.SecretVolumeSource{SecretName: tlsSecret}},
+			})
+		}
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BackupJobName(b),
+			Namespace: b.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					NodeName:      nodeName,
+					// The Job shares the node of the KeyDB pod, taints included
+					Tolerations: k.Spec.Tolerations,
+					// Run as the KeyDB user, which owns dump.rdb
+					SecurityContext: podSecurityContext(k),
+					InitContainers:  []corev1.Container{fetch},
+					Containers: []corev1.Container{
+						{
+							Name:    BackupUploadContainer,
+							Image:   image,
+							Command: []string{"/bin/sh", "-c", uploadScript},
+							Env: append(S3Env(b.Spec.Storage.S3),
+								corev1.EnvVar{Name: "S3_URL", Value: BackupLocation(b)},
+							),
+							VolumeMounts: []corev1.VolumeMount{backupMount},
+						},
+					},
+					Volumes: volumes,
+				},
+			},
+		},
+	}
+
+	restrictContainers(k, &job.Spec.Template.Spec, false)
+
+	_ = ctrl.SetControllerReference(b, job, scheme)
+	return job
+}
+
+// BackupCleanupJobName returns the name of the Job deleting a backup's snapshot.
+func BackupCleanupJobName(b *keydbv1.KeydbBackup) string {
+	return b.Name + "-cleanup"
+}
+
+// GenerateBackupCleanupJob returns the Job that removes an uploaded snapshot
+// from the bucket when its KeydbBackup is deleted.
+func GenerateBackupCleanupJob(b *keydbv1.KeydbBackup, scheme *runtime.Scheme) *batchv1.Job {
+	labels := map[string]string{LabelBackup: b.Name}
+	backoffLimit := int32(2)
+
+	image := b.Spec.Image
+	if image == "" {
+		image = keydbv1.DefaultBackupImage
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      BackupCleanupJobName(b),
+			Namespace: b.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy: corev1.RestartPolicyNever,
+					// The Keydb may be gone, so its securityContext is not used
+					SecurityContext: podSecurityContext(nil),
+					Containers: []corev1.Container{
+						{
+							Name:    "cleanup",
+							Image:   image,
+							Command: []string{"/bin/sh", "-c", cleanupScript},
+							Env: append(S3Env(b.Spec.Storage.S3),
+								corev1.EnvVar{Name: "S3_URL", Value: b.Status.Location},
+							),
+						},
+					},
+				},
+			},
+		},
+	}
+
+	restrictContainers(nil, &job.Spec.Template.Spec, false)
+
+	_ = ctrl.SetControllerReference(b, job, scheme)
+	return job
+}
@@ -5,46 +5,58 @@ import (
 	"strings"
 
 	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	"github.com/rsingh0101/keydb-operator/internal/keydbconf"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
-const replicationModeMasterMaster = "master-master"
+// persistenceConfig are the persistence directives of keydb.conf.
+var persistenceConfig = []string{"appendonly yes"}
+
+// AppendOnlyConfigured reports whether keydb.conf runs KeyDB with AOF.
+func AppendOnlyConfigured(k *keydbv1.Keydb) bool {
+	enabled := false
+	for _, line := range keydbconf.Merge(persistenceConfig, k.Spec.Config) {
+		if value, ok := strings.CutPrefix(line, "appendonly "); ok {
+			enabled = value == "yes"
+		}
+	}
+	return enabled
+}
 
 func GenerateKeydbConfigMap(k *keydbv1.Keydb, scheme *runtime.Scheme) ([]*corev1.ConfigMap, error) {
 	labels := map[string]string{"apps": k.Name}
+	profile := profileFor(k)
 
 	// Base config
 	config := []string{
 		"bind 0.0.0.0 ::",
 		"protected-mode yes",
-		"dir /bitnami/keydb/data",
+		"dir " + profile.DataDir,
 		"port 6379",
 		"loglevel notice",
-		"appendonly yes",
+	}
+
+	config = keydbconf.Merge(append(config, persistenceConfig...), k.Spec.Config)
+
+	if k.Spec.TLS != nil {
+		// The later "port 0" wins over "port 6379", so 6379 only speaks TLS
+		config = append(config, tlsConfig(k)...)
 	}
 
 	switch k.Spec.Replication.Mode {
-	case "master-replica":
-		if len(k.Spec.Replication.Domain) == 0 {
-			return nil, fmt.Errorf("replication mode master-replica requires atleast one domain")
-		}
-		masterhost := fmt.Sprintf("%s-0.%s-headless.%s.svc.cluster.local", k.Name, k.Name, k.Namespace)
+	case keydbv1.ReplicationModeMasterReplica:
+		// Every pod points at the elected primary; the primary drops this line
+		// at startup (see fix_replication_config.sh) and runs without replicaof.
 		config = append(config,
-			fmt.Sprintf("replicaof %s %d", masterhost, 6379),
-			"repl-diskless-sync yes",
-			"repl-diskless-sync-delay 0",
-		)
-		host := NormalizeFQDN(k.Spec.Replication.Domain[0])
-		port := k.Spec.Replication.Port
-		config = append(config,
-			fmt.Sprintf("replicaof %s %d", host, port),
+			fmt.Sprintf("replicaof %s %d", PodFQDN(k, PrimaryPodName(k)), 6379),
+			"replica-read-only yes",
 			"repl-diskless-sync yes",
 			"repl-diskless-sync-delay 0",
 		)
-	case replicationModeMasterMaster:
+	case keydbv1.ReplicationModeMasterMaster:
 		config = append(config,
 			"active-replica yes",
 			"multi-master yes",
@@ -88,41 +100,30 @@ func GenerateKeydbConfigMap(k *keydbv1.Keydb, scheme *runtime.Scheme) ([]*corev1
 		return nil, err
 	}
 
-	// Add init script to handle pod-specific config for master-master mode
+	// Add init script to handle pod-specific config for master-master and master-replica modes
 	initScript := ""
-	if k.Spec.Replication.Mode == "master-master" {
-		masterhost := fmt.Sprintf("%s-0.%s-headless.%s.svc.cluster.local", k.Name, k.Name, k.Namespace)
-		// Escape dots and special chars for sed
-		masterhostEscaped := strings.ReplaceAll(masterhost, ".", "\\.")
-		initScript = fmt.Sprintf(`#!/bin/bash
-# Fix config for master-master mode: pod-0 should not replicate from itself
-CONFIG_SOURCE="/opt/bitnami/keydb/etc/keydb.conf"
+	if k.Spec.Replication.Mode == keydbv1.ReplicationModeMasterMaster || k.Spec.Replication.Mode == keydbv1.ReplicationModeMasterReplica {
+		initScript = `#!/bin/bash
+# Fix config for the current pod: a pod should never replicate from itself
+CONFIG_SOURCE="` + profile.configFile() + `"
 CONFIG_FILE="/tmp/keydb.conf"
 POD_NAME="${HOSTNAME}"
 
 # Copy config to writable location
 cp "${CONFIG_SOURCE}" "${CONFIG_FILE}"
 
-# Extract pod index from hostname (format: keydb-0, keydb-1, etc.)
-POD_INDEX=$(echo "${POD_NAME}" | grep -oE '[0-9]+$' || echo "")
-
-# If this is pod-0, remove the replicaof line that points to itself
-if [ "${POD_INDEX}" = "0" ]; then
-	# Remove replicaof line that points to pod-0 (match hostname and port 6379)
-	sed -i '/^replicaof %s 6379$/d' "${CONFIG_FILE}"
-fi
+# Remove replicaof lines that point to this pod (pod-0 in master-master mode,
+# the elected primary in master-replica mode)
+sed -i "/^replicaof ${POD_NAME}\\./d" "${CONFIG_FILE}"
 
 # Export the modified config path for use by keydb-server
-export KEYDB_MODIFIED_CONFIG="${CONFIG_FILE}"`, masterhostEscaped)
+export KEYDB_MODIFIED_CONFIG="${CONFIG_FILE}"`
 	}
 
 	healthData := map[string]string{
-		"ping_readiness_local.sh": `#!/bin/bash
-				. /opt/bitnami/scripts/keydb-env.sh
-				. /opt/bitnami/scripts/liblog.sh
-				response=$(
+		"ping_readiness_local.sh": "#!/bin/bash\n" + profile.Prelude + `response=$(
 					timeout -s 15 $1 \
-					keydb-cli \
+					keydb-cli $KEYDB_CLI_TLS_ARGS \
 						-h localhost \
 						-a "$KEYDB_PASSWORD" \
 						-p $KEYDB_PORT_NUMBER \
@@ -137,13 +138,9 @@ export KEYDB_MODIFIED_CONFIG="${CONFIG_FILE}"`, masterhostEscaped)
 					error "$response"
 					exit 1
 				fi`,
-		"ping_liveness_local.sh": `#!/bin/bash
-
-			. /opt/bitnami/scripts/keydb-env.sh
-			. /opt/bitnami/scripts/liblog.sh
-			response=$(
+		"ping_liveness_local.sh": "#!/bin/bash\n" + profile.Prelude + `response=$(
 				timeout -s 15 $1 \
-				keydb-cli \
+				keydb-cli $KEYDB_CLI_TLS_ARGS \
 					-h localhost \
 					-a "$KEYDB_PASSWORD" \
 					-p $KEYDB_PORT_NUMBER \
@@ -159,13 +156,9 @@ export KEYDB_MODIFIED_CONFIG="${CONFIG_FILE}"`, masterhostEscaped)
 				error "$response"
 				exit 1
 			fi`,
-		"ping_readiness_master.sh": `#!/bin/bash
-
-			. /opt/bitnami/scripts/keydb-env.sh
-			. /opt/bitnami/scripts/liblog.sh
-			response=$(
+		"ping_readiness_master.sh": "#!/bin/bash\n" + profile.Prelude + `response=$(
 				timeout -s 15 $1 \
-				keydb-cli \
+				keydb-cli $KEYDB_CLI_TLS_ARGS \
 					-h keydb-headless \
 					-p 6379 \
 					-a "$KEYDB_MASTER_PASSWORD" \
@@ -180,12 +173,9 @@ export KEYDB_MODIFIED_CONFIG="${CONFIG_FILE}"`, masterhostEscaped)
 				error "$response"
 				exit 1
 			fi`,
-		"ping_liveness_master.sh": `#!/bin/bash
-					. /opt/bitnami/scripts/keydb-env.sh
-					. /opt/bitnami/scripts/liblog.sh
-					response=$(
+		"ping_liveness_master.sh": "#!/bin/bash\n" + profile.Prelude + `response=$(
 						timeout -s 15 $1 \
-						keydb-cli \
+						keydb-cli $KEYDB_CLI_TLS_ARGS \
 							-h keydb-headless \
 							-p 6379 \
 							-a "$KEYDB_MASTER_PASSWORD" \
@@ -216,37 +206,9 @@ export KEYDB_MODIFIED_CONFIG="${CONFIG_FILE}"`, masterhostEscaped)
 				"$script_dir/ping_liveness_master.sh" "$1" || exit_status=$?
 				exit $exit_status
 			`,
-		"config_reloader.sh": `#!/bin/bash
-LAST_HASH=""
-while true; do
-  if [ ! -f /opt/bitnami/keydb/etc/keydb.conf ]; then
-    sleep 5
-    continue
-  fi
-  HASH=$(md5sum /opt/bitnami/keydb/etc/keydb.conf | awk '{print $1}')
-  if [ -n "$LAST_HASH" ] && [ "$HASH" != "$LAST_HASH" ]; then
-    echo "Configuration change detected. Reloading KeyDB dynamically..."
-    
-    while read line || [ -n "$line" ]; do
-      if [[ -z "$line" || "$line" == \#* ]]; then continue; fi
-      key=$(echo "$line" | awk '{print $1}')
-      val=$(echo "$line" | cut -d' ' -f2-)
-      
-      if [[ "$key" != "replicaof" && "$key" != "dir" && "$key" != "port" && "$key" != "bind" ]]; then
-        echo "Applying CONFIG SET $key $val"
-        keydb-cli -h localhost -p $KEYDB_PORT_NUMBER -a "$KEYDB_PASSWORD" CONFIG SET "$key" "$val"
-      elif echo "$line" | grep -q "replicaof"; then
-        echo "Applying REPLICAOF $val"
-        keydb-cli -h localhost -p $KEYDB_PORT_NUMBER -a "$KEYDB_PASSWORD" REPLICAOF $val
-      fi
-    done < /opt/bitnami/keydb/etc/keydb.conf
-  fi
-  LAST_HASH=$HASH
-  sleep 10
-done`,
 	}
 
-	// Only add fix script if it's not empty (i.e., for master-master mode)
+	// Only add fix script if it's not empty (i.e., for master-master and master-replica modes)
 	if initScript != "" {
 		healthData["fix_replication_config.sh"] = initScript
 	}
@@ -0,0 +1,116 @@
+package k8sresources
+
+import (
+	"fmt"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+// The operator's own mounts are at the same paths whatever the image flavor.
+const (
+	healthScriptsDir = "/opt/bitnami/scripts/health"
+	secretsDir       = "/opt/bitnami/keydb/secrets"
+	passwordFile     = secretsDir + "/password"
+	// exporterDir holds the password file of the metrics exporter
+	exporterDir          = "/opt/keydb-exporter"
+	exporterPasswordFile = exporterDir + "/password.json"
+)
+
+// imageProfile is the layout of a KeyDB image: where keydb.conf and the data
+// live, which binary to start and how the password reaches it.
+type imageProfile struct {
+	ConfigDir string
+	DataDir   string
+	Server    string
+	// Prelude is sourced by the startup script and the probes. It sets
+	// KEYDB_PASSWORD and KEYDB_MASTER_PASSWORD from the *_FILE variables and
+	// defines the error function the probes log with.
+	Prelude string
+}
+
+// bitnamiPrelude uses the image's own scripts, which read KEYDB_PASSWORD_FILE.
+const bitnamiPrelude = `. /opt/bitnami/scripts/keydb-env.sh
+. /opt/bitnami/scripts/liblog.sh
+`
+
+// filePrelude is used by images without the Bitnami scripts.
+const filePrelude = `KEYDB_PASSWORD="$(cat "$KEYDB_PASSWORD_FILE")"
+KEYDB_MASTER_PASSWORD="$(cat "$KEYDB_MASTER_PASSWORD_FILE")"
+error() { echo "$*" >&2; }
+`
+
+// profileFor returns the layout of spec.image according to spec.imageFlavor.
+func profileFor(k *keydbv1.Keydb) imageProfile {
+	switch k.Spec.ImageFlavor {
+	case keydbv1.ImageFlavorUpstream:
+		return imageProfile{ConfigDir: "/etc/keydb", DataDir: "/data", Server: "keydb-server", Prelude: filePrelude}
+	case keydbv1.ImageFlavorCustom:
+		p := imageProfile{ConfigDir: "/etc/keydb", DataDir: "/data", Server: "keydb-server", Prelude: filePrelude}
+		if c := k.Spec.ImageProfile; c != nil {
+			if c.ConfigDir != "" {
+				p.ConfigDir = c.ConfigDir
+			}
+			if c.DataDir != "" {
+				p.DataDir = c.DataDir
+			}
+			if c.Server != "" {
+				p.Server = c.Server
+			}
+			if c.EnvScript != "" {
+				p.Prelude = fmt.Sprintf(". %s\n", c.EnvScript) + p.Prelude
+			}
+		}
+		return p
+	default:
+		return imageProfile{
+			ConfigDir: "/opt/bitnami/keydb/etc",
+			DataDir:   "/bitnami/keydb/data",
+			Server:    "keydb-server",
+			Prelude:   bitnamiPrelude,
+		}
+	}
+}
+
+// configFile returns the path of keydb.conf.
+func (p imageProfile) configFile() string {
+	return p.ConfigDir + "/keydb.conf"
+}
+
+// startupScript starts keydb-server with the password and, on pods that
+// replicate, without a replicaof line pointing at the pod itself.
+func (p imageProfile) startupScript() string {
+	return p.Prelude + fmt.Sprintf(`if [ -f %[1]s/fix_replication_config.sh ]; then bash %[1]s/fix_replication_config.sh || true; fi
+CONFIG_FILE="${KEYDB_MODIFIED_CONFIG:-%[2]s}"
+args=("${CONFIG_FILE}")
+args+=("--requirepass" "$KEYDB_PASSWORD")
+args+=("--masterauth" "$KEYDB_MASTER_PASSWORD")
+%[3]s
+exec %[4]s "${args[@]}"`, healthScriptsDir, p.configFile(), p.appendOnlyRestoreArgs(), p.Server)
+}
+
+// appendOnlyRestoreArgs starts KeyDB without AOF the first time it runs on
+// data a restore just seeded; with appendonly on KeyDB would ignore the
+// restored dump.rdb and start empty. The operator turns AOF back on once the
+// data is loaded, and the marker is dropped at the next start that finds the
+// AOF. The marker is written by the spec.restore init container and by the
+// KeydbRestore Job, which replaces the data of a running pod, so data that was
+// not restored keeps the configured appendonly. It is checked at startup
+// rather than added to the pod template while a restore runs, which would
+// roll every pod as the restore starts and ends.
+func (p imageProfile) appendOnlyRestoreArgs() string {
+	return fmt.Sprintf(`if [ -f %[1]s/%[2]s ]; then
+  if [ -e %[1]s/appendonly.aof ] || [ -e %[1]s/appendonlydir ]; then rm -f %[1]s/%[2]s; else args+=("--appendonly" "no"); fi
+fi`, p.DataDir, restoreLoadMarker)
+}
+
+// keydbImage returns spec.image, or the default image of the flavor.
+func keydbImage(k *keydbv1.Keydb) string {
+	switch {
+	case k.Spec.Image != "":
+		return k.Spec.Image
+	case k.Spec.ImageFlavor == keydbv1.ImageFlavorUpstream:
+		return keydbv1.DefaultUpstreamImage
+	default:
+		return keydbv1.DefaultImage
+	}
+}
@@ -0,0 +1,156 @@
+package k8sresources
+
+import (
+	"fmt"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// ServiceMonitorGVK and PrometheusRuleGVK are the Prometheus Operator kinds.
+// Like cert-manager Certificates they are handled as unstructured so the
+// operator does not depend on the Prometheus Operator's API module.
+var (
+	ServiceMonitorGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "ServiceMonitor"}
+	PrometheusRuleGVK = schema.GroupVersionKind{Group: "monitoring.coreos.com", Version: "v1", Kind: "PrometheusRule"}
+)
+
+// Defaults of spec.metrics.prometheusRule, for objects created without the
+// defaulting of the CRD
+const (
+	defaultAlertFor           = "5m"
+	defaultMemoryUsagePercent = int32(90)
+)
+
+// GenerateServiceMonitor returns the ServiceMonitor scraping the exporter of
+// every pod through the headless Service, or nil when none is wanted.
+func GenerateServiceMonitor(k *keydbv1.Keydb, scheme *runtime.Scheme) *unstructured.Unstructured {
+	sm := k.Spec.Metrics.ServiceMonitor
+	if !k.Spec.Metrics.Enabled || sm == nil {
+		return nil
+	}
+
+	endpoint := map[string]interface{}{"port": "metrics", "path": "/metrics"}
+	if sm.Interval != "" {
+		endpoint["interval"] = sm.Interval
+	}
+	if sm.ScrapeTimeout != "" {
+		endpoint["scrapeTimeout"] = sm.ScrapeTimeout
+	}
+	spec := map[string]interface{}{
+		"selector": map[string]interface{}{
+			"matchLabels": map[string]interface{}{
+				"apps":               k.Name,
+				keydbv1.LabelMetrics: "true",
+			},
+		},
+		"namespaceSelector": map[string]interface{}{
+			"matchNames": []interface{}{k.Namespace},
+		},
+		"endpoints": []interface{}{endpoint},
+	}
+
+	obj := monitoringObject(k, ServiceMonitorGVK, sm.Labels, spec)
+	_ = ctrl.SetControllerReference(k, obj, scheme)
+	return obj
+}
+
+// GeneratePrometheusRule returns the PrometheusRule with the default KeyDB
+// alerts, or nil when none is wanted.
+func GeneratePrometheusRule(k *keydbv1.Keydb, scheme *runtime.Scheme) *unstructured.Unstructured {
+	pr := k.Spec.Metrics.PrometheusRule
+	if !k.Spec.Metrics.Enabled || pr == nil {
+		return nil
+	}
+
+	forDuration := pr.For
+	if forDuration == "" {
+		forDuration = defaultAlertFor
+	}
+	memoryPercent := pr.MemoryUsagePercent
+	if memoryPercent == 0 {
+		memoryPercent = defaultMemoryUsagePercent
+	}
+	// The series of this Keydb, as scraped by the generated ServiceMonitor
+	selector := fmt.Sprintf(`namespace=%q,service=%q`, k.Namespace, k.Name+"-headless")
+
+	alerts := []struct {
+		name, expr, summary, description string
+	}{
+		{
+			name:        "KeydbInstanceDown",
+			expr:        fmt.Sprintf(`redis_up{%[1]s} == 0 or up{%[1]s} == 0`, selector),
+			summary:     "KeyDB pod is down",
+			description: "KeyDB on {{ $labels.pod }} of " + k.Name + " has not answered the exporter for " + forDuration + ".",
+		},
+		{
+			name:        "KeydbReplicationBroken",
+			expr:        fmt.Sprintf(`redis_master_link_up{%s} == 0`, selector),
+			summary:     "KeyDB replication is broken",
+			description: "{{ $labels.pod }} of " + k.Name + " has lost the link to its primary for " + forDuration + ".",
+		},
+		{
+			name: "KeydbMemoryNearLimit",
+			expr: fmt.Sprintf(`redis_memory_used_bytes{%[1]s} / redis_memory_max_bytes{%[1]s} * 100 > %[2]d and redis_memory_max_bytes{%[1]s} > 0`,
+				selector, memoryPercent),
+			summary:     "KeyDB memory is near maxmemory",
+			description: "{{ $labels.pod }} of " + k.Name + " uses {{ $value | humanize }}% of maxmemory.",
+		},
+		{
+			name:        "KeydbRejectedConnections",
+			expr:        fmt.Sprintf(`increase(redis_rejected_connections_total{%s}[5m]) > %d`, selector, pr.RejectedConnections),
+			summary:     "KeyDB rejects connections",
+			description: "{{ $labels.pod }} of " + k.Name + " rejected {{ $value | humanize }} connections in the last 5 minutes.",
+		},
+	}
+
+	var rules []interface{}
+	for _, a := range alerts {
+		labels := map[string]interface{}{}
+		for key, value := range pr.AlertLabels {
+			labels[key] = value
+		}
+		rule := map[string]interface{}{
+			"alert": a.name,
+			"expr":  a.expr,
+			"for":   forDuration,
+			"annotations": map[string]interface{}{
+				"summary":     a.summary,
+				"description": a.description,
+			},
+		}
+		if len(labels) > 0 {
+			rule["labels"] = labels
+		}
+		rules = append(rules, rule)
+	}
+	spec := map[string]interface{}{
+		"groups": []interface{}{
+			map[string]interface{}{"name": "keydb-" + k.Name, "rules": rules},
+		},
+	}
+
+	obj := monitoringObject(k, PrometheusRuleGVK, pr.Labels, spec)
+	_ = ctrl.SetControllerReference(k, obj, scheme)
+	return obj
+}
+
+// monitoringObject returns a Prometheus Operator object named after the Keydb.
+// The apps label wins over user labels, like on the pods.
+func monitoringObject(k *keydbv1.Keydb, gvk schema.GroupVersionKind, userLabels map[string]string, spec map[string]interface{}) *unstructured.Unstructured {
+	labels := map[string]string{}
+	for key, value := range userLabels {
+		labels[key] = value
+	}
+	labels["apps"] = k.Name
+
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	obj.SetGroupVersionKind(gvk)
+	obj.SetName(k.Name)
+	obj.SetNamespace(k.Namespace)
+	obj.SetLabels(labels)
+	return obj
+}
@@ -0,0 +1,200 @@
+package k8sresources
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	"github.com/rsingh0101/keydb-operator/internal/configreloader"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// operatorPodLabels select the operator, which queries KeyDB and the config
+// reloader directly. config/manager sets them on the manager pods.
+var operatorPodLabels = map[string]string{
+	"control-plane":          "controller-manager",
+	"app.kubernetes.io/name": "keydb-operator",
+}
+
+// GenerateNetworkPolicy returns the NetworkPolicy of a Keydb, or nil when
+// spec.networkPolicy is unset.
+func GenerateNetworkPolicy(k *keydbv1.Keydb, scheme *runtime.Scheme) *networkingv1.NetworkPolicy {
+	spec := k.Spec.NetworkPolicy
+	if spec == nil {
+		return nil
+	}
+	labels := map[string]string{"apps": k.Name}
+
+	keydbPort := []networkingv1.NetworkPolicyPort{tcpPort(6379)}
+	keydbPods := networkingv1.NetworkPolicyPeer{PodSelector: &metav1.LabelSelector{MatchLabels: labels}}
+	operator := networkingv1.NetworkPolicyPeer{
+		NamespaceSelector: &metav1.LabelSelector{},
+		PodSelector:       &metav1.LabelSelector{MatchLabels: operatorPodLabels},
+	}
+	backups := networkingv1.NetworkPolicyPeer{
+		PodSelector: &metav1.LabelSelector{
+			MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: LabelBackup, Operator: metav1.LabelSelectorOpExists},
+			},
+		},
+	}
+	peers := append(replicationPeers(k), spec.ReplicationPeers...)
+
+	clients := []networkingv1.NetworkPolicyPeer{keydbPods, operator, backups}
+	clients = append(clients, peers...)
+	clients = append(clients, spec.Clients...)
+	ingress := []networkingv1.NetworkPolicyIngressRule{
+		{Ports: keydbPort, From: clients},
+		{Ports: []networkingv1.NetworkPolicyPort{tcpPort(configreloader.Port)}, From: []networkingv1.NetworkPolicyPeer{operator}},
+	}
+	if k.Spec.Metrics.Enabled && spec.MetricsNamespaceSelector != nil {
+		ingress = append(ingress, networkingv1.NetworkPolicyIngressRule{
+			Ports: []networkingv1.NetworkPolicyPort{tcpPort(9121)},
+			From:  []networkingv1.NetworkPolicyPeer{{NamespaceSelector: spec.MetricsNamespaceSelector}},
+		})
+	}
+
+	udp := corev1.ProtocolUDP
+	dnsPort := intstr.FromInt32(53)
+	egress := []networkingv1.NetworkPolicyEgressRule{
+		{Ports: []networkingv1.NetworkPolicyPort{{Protocol: &udp, Port: &dnsPort}, tcpPort(53)}},
+		{Ports: keydbPort, To: []networkingv1.NetworkPolicyPeer{keydbPods}},
+	}
+	if len(peers) > 0 {
+		egress = append(egress, networkingv1.NetworkPolicyEgressRule{
+			Ports: []networkingv1.NetworkPolicyPort{tcpPort(replicationPort(k))},
+			To:    peers,
+		})
+	}
+	if rule := restoreEgress(k); rule != nil {
+		egress = append(egress, *rule)
+	}
+	egress = append(egress, spec.Egress...)
+
+	np := &networkingv1.NetworkPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      k.Name,
+			Namespace: k.Namespace,
+			Labels:    labels,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: metav1.LabelSelector{MatchLabels: labels},
+			PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			Ingress:     ingress,
+			Egress:      egress,
+		},
+	}
+
+	_ = ctrl.SetControllerReference(k, np, scheme)
+	return np
+}
+
+// replicationPeers returns the peers for the spec.replication.domain hosts
+// the policy can match: IPs, and Services by their namespace. Other host
+// names are left to spec.networkPolicy.replicationPeers.
+func replicationPeers(k *keydbv1.Keydb) []networkingv1.NetworkPolicyPeer {
+	if k.Spec.Replication.Mode != keydbv1.ReplicationModeMasterMaster {
+		return nil
+	}
+	var peers []networkingv1.NetworkPolicyPeer
+	seen := map[string]bool{}
+	for _, domain := range k.Spec.Replication.Domain {
+		var peer networkingv1.NetworkPolicyPeer
+		if ip := net.ParseIP(domain); ip != nil {
+			bits := 32
+			if ip.To4() == nil {
+				bits = 128
+			}
+			cidr := (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String()
+			if seen[cidr] {
+				continue
+			}
+			seen[cidr] = true
+			peer.IPBlock = &networkingv1.IPBlock{CIDR: cidr}
+		} else {
+			namespace, ok := serviceNamespace(k, domain)
+			if !ok || seen[namespace] {
+				continue
+			}
+			seen[namespace] = true
+			peer.NamespaceSelector = &metav1.LabelSelector{
+				MatchLabels: map[string]string{corev1.LabelMetadataName: namespace},
+			}
+		}
+		peers = append(peers, peer)
+	}
+	return peers
+}
+
+// restoreEgress lets the spec.restore init container download its S3 object
+// until the restore is done. Endpoints outside the cluster are allowed by
+// port only, since a NetworkPolicy cannot match host names.
+func restoreEgress(k *keydbv1.Keydb) *networkingv1.NetworkPolicyEgressRule {
+	s3 := RestoreS3Source(k)
+	if s3 == nil {
+		return nil
+	}
+	if status := k.Status.Restore; status != nil &&
+		(status.Phase == keydbv1.RestorePhaseCompleted || status.Phase == keydbv1.RestorePhaseSkipped) {
+		return nil
+	}
+	port, host := int32(443), ""
+	if u, err := url.Parse(s3.Endpoint); s3.Endpoint != "" && err == nil {
+		host = u.Hostname()
+		if p, err := strconv.ParseInt(u.Port(), 10, 32); err == nil {
+			port = int32(p)
+		} else if u.Scheme == "http" {
+			port = 80
+		}
+	}
+	rule := &networkingv1.NetworkPolicyEgressRule{Ports: []networkingv1.NetworkPolicyPort{tcpPort(port)}}
+	if ip := net.ParseIP(host); ip != nil {
+		bits := 32
+		if ip.To4() == nil {
+			bits = 128
+		}
+		cidr := (&net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}).String()
+		rule.To = []networkingv1.NetworkPolicyPeer{{IPBlock: &networkingv1.IPBlock{CIDR: cidr}}}
+	} else if namespace, ok := serviceNamespace(k, host); host != "" && ok {
+		rule.To = []networkingv1.NetworkPolicyPeer{{NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{corev1.LabelMetadataName: namespace},
+		}}}
+	}
+	return rule
+}
+
+// serviceNamespace returns the namespace of an in-cluster Service or pod host
+// name, normalized like the replicaof lines. ok is false for hosts outside
+// the cluster.
+func serviceNamespace(k *keydbv1.Keydb, host string) (string, bool) {
+	host = NormalizeFQDN(host)
+	rest, ok := strings.CutSuffix(host, ".svc.cluster.local")
+	if !ok {
+		return "", false
+	}
+	parts := strings.Split(rest, ".")
+	if len(parts) == 1 {
+		return k.Namespace, true
+	}
+	return parts[len(parts)-1], true
+}
+
+func replicationPort(k *keydbv1.Keydb) int32 {
+	if k.Spec.Replication.Port != 0 {
+		return k.Spec.Replication.Port
+	}
+	return keydbv1.DefaultPort
+}
+
+func tcpPort(port int32) networkingv1.NetworkPolicyPort {
+	tcp := corev1.ProtocolTCP
+	p := intstr.FromInt32(port)
+	return networkingv1.NetworkPolicyPort{Protocol: &tcp, Port: &p}
+}
@@ -27,7 +27,7 @@ func GeneratePodDisruptionBudget(k *keydbv1.Keydb, scheme *runtime.Scheme) *poli
 		// For master-master replication, maintain quorum (majority)
 		// For 3 replicas: minAvailable = 2 (quorum)
 		// For 5 replicas: minAvailable = 3 (quorum)
-		if k.Spec.Replication.Mode == "master-master" {
+		if k.Spec.Replication.Mode == keydbv1.ReplicationModeMasterMaster {
 			minAvailableInt := (replicas / 2) + 1
 			minAvailable = intstr.FromInt32(minAvailableInt)
 		} else {
@@ -0,0 +1,107 @@
+package k8sresources
+
+import (
+	"encoding/json"
+	"fmt"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// applyPodTemplate merges spec.podTemplate into the generated pod template.
+// The pod spec is merged strategically, so lists such as containers, env and
+// volumes are merged by name. Labels the operator sets always win, since the
+// selectors depend on them.
+func applyPodTemplate(k *keydbv1.Keydb, template *corev1.PodTemplateSpec) error {
+	override := k.Spec.PodTemplate
+	if override == nil {
+		return nil
+	}
+
+	labels := map[string]string{}
+	for key, value := range override.Labels {
+		labels[key] = value
+	}
+	for key, value := range template.Labels {
+		labels[key] = value
+	}
+	template.Labels = labels
+
+	if len(override.Annotations) > 0 && template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	for key, value := range override.Annotations {
+		template.Annotations[key] = value
+	}
+
+	patch := corev1.PodSpec{
+		Containers:         append([]corev1.Container(nil), override.Containers...),
+		InitContainers:     override.InitContainers,
+		Volumes:            override.Volumes,
+		ImagePullSecrets:   override.ImagePullSecrets,
+		ServiceAccountName: override.ServiceAccountName,
+	}
+	if len(override.Env) > 0 || len(override.VolumeMounts) > 0 {
+		keydb := -1
+		for i := range patch.Containers {
+			if patch.Containers[i].Name == "keydb" {
+				keydb = i
+			}
+		}
+		if keydb < 0 {
+			patch.Containers = append(patch.Containers, corev1.Container{Name: "keydb"})
+			keydb = len(patch.Containers) - 1
+		}
+		c := patch.Containers[keydb].DeepCopy()
+		c.Env = append(c.Env, override.Env...)
+		c.VolumeMounts = append(c.VolumeMounts, override.VolumeMounts...)
+		patch.Containers[keydb] = *c
+	}
+
+	original, err := json.Marshal(template.Spec)
+	if err != nil {
+		return err
+	}
+	patchJSON, err := json.Marshal(patch)
+	if err != nil {
+		return err
+	}
+	merged, err := strategicpatch.StrategicMergePatch(original, patchJSON, corev1.PodSpec{})
+	if err != nil {
+		return fmt.Errorf("merge spec.podTemplate: %w", err)
+	}
+	var spec corev1.PodSpec
+	if err := json.Unmarshal(merged, &spec); err != nil {
+		return fmt.Errorf("merge spec.podTemplate: %w", err)
+	}
+	// The merge puts added containers first; keep the operator's in front,
+	// so keydb stays the default container and init containers run in order
+	spec.Containers = keepOrder(template.Spec.Containers, spec.Containers)
+	spec.InitContainers = keepOrder(template.Spec.InitContainers, spec.InitContainers)
+	template.Spec = spec
+	return nil
+}
+
+// keepOrder returns merged with the containers of generated first, in their
+// original order, followed by the added ones.
+func keepOrder(generated, merged []corev1.Container) []corev1.Container {
+	byName := map[string]corev1.Container{}
+	for _, c := range merged {
+		byName[c.Name] = c
+	}
+	ordered := make([]corev1.Container, 0, len(merged))
+	for _, c := range generated {
+		ordered = append(ordered, byName[c.Name])
+		delete(byName, c.Name)
+	}
+	for _, c := range merged {
+		if _, added := byName[c.Name]; added {
+			ordered = append(ordered, c)
+		}
+	}
+	if len(ordered) == 0 {
+		return nil
+	}
+	return ordered
+}
@@ -5,14 +5,28 @@ import (
 	"context"
 
 	"github.com/go-logr/logr"
-	appsv1 "k8s.io/api/apps/v1"
-	corev1 "k8s.io/api/core/v1"
-	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/util/csaupgrade"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
 
+// FieldManager owns the fields the operator applies to its resources.
+const FieldManager = "keydb-operator"
+
+// legacyFieldManagers are the managers of the fields the operator wrote with
+// Update before it used server-side apply: the name of the manager binary.
+var legacyFieldManagers = sets.New("manager")
+
+// ApplyResource server-side applies desired, owned by owner, and reports
+// whether it was created or changed. The operator only owns the fields it
+// generates, so labels, annotations and fields set by other controllers,
+// admission webhooks or defaulting are left alone, and an apply that changes
+// nothing is not an update.
 func ApplyResource(
 	ctx context.Context,
 	c client.Client,
@@ -20,75 +34,64 @@ func ApplyResource(
 	owner client.Object,
 	desired client.Object,
 	log logr.Logger,
-) error {
+) (controllerutil.OperationResult, error) {
 	// Set controller reference
 	if err := controllerutil.SetControllerReference(owner, desired, scheme); err != nil {
-		return err
+		return controllerutil.OperationResultNone, err
 	}
+	// Apply requests carry the kind, which typed objects leave empty
+	gvk, err := apiutil.GVKForObject(desired, scheme)
+	if err != nil {
+		return controllerutil.OperationResultNone, err
+	}
+	desired.GetObjectKind().SetGroupVersionKind(gvk)
+	// A resourceVersion left on desired makes the apply conditional on it
+	desired.SetManagedFields(nil)
 
-	// Create an empty object of the same type to use with CreateOrUpdate
 	existing := desired.DeepCopyObject().(client.Object)
-	existing.SetResourceVersion("")
-	existing.SetGeneration(0)
-	existing.SetUID("")
-
-	op, err := controllerutil.CreateOrUpdate(ctx, c, existing, func() error {
-		// Copy metadata annotations and labels
-		existing.SetLabels(desired.GetLabels())
-
-		// Map specific struct fields
-		switch e := existing.(type) {
-		case *appsv1.StatefulSet:
-			d := desired.(*appsv1.StatefulSet)
-			e.Spec = d.Spec
-
-			// Copy template annotations that might have been dynamically added (like restartedAt)
-			if d.Spec.Template.Annotations != nil {
-				if e.Spec.Template.Annotations == nil {
-					e.Spec.Template.Annotations = make(map[string]string)
-				}
-				for k, v := range d.Spec.Template.Annotations {
-					e.Spec.Template.Annotations[k] = v
-				}
-			}
-
-		case *corev1.Service:
-			d := desired.(*corev1.Service)
-			clusterIP := e.Spec.ClusterIP
-			e.Spec = d.Spec
-			if clusterIP != "" && clusterIP != "None" {
-				e.Spec.ClusterIP = clusterIP
-			}
-
-		case *corev1.ConfigMap:
-			d := desired.(*corev1.ConfigMap)
-			e.Data = d.Data
-
-		case *corev1.Secret:
-			d := desired.(*corev1.Secret)
-			e.Data = d.Data
-			e.StringData = d.StringData
-
-		case *policyv1.PodDisruptionBudget:
-			d := desired.(*policyv1.PodDisruptionBudget)
-			e.Spec = d.Spec
-
-		case *corev1.ServiceAccount:
-			// No spec to copy
+	op := controllerutil.OperationResultUpdated
+	err = c.Get(ctx, client.ObjectKeyFromObject(desired), existing)
+	switch {
+	case apierrors.IsNotFound(err):
+		op = controllerutil.OperationResultCreated
+	case err != nil:
+		log.Error(err, "reconcile failed", "kind", gvk.Kind, "name", desired.GetName())
+		return controllerutil.OperationResultNone, err
+	default:
+		if err := upgradeManagedFields(ctx, c, existing); err != nil {
+			log.Error(err, "failed to migrate field ownership", "kind", gvk.Kind, "name", desired.GetName())
+			return controllerutil.OperationResultNone, err
 		}
+	}
 
-		return nil
-	})
-
-	if err != nil {
-		log.Error(err, "reconcile failed", "kind", desired.GetObjectKind().GroupVersionKind().Kind, "name", desired.GetName())
-		return err
+	// Apply the generated fields; the operator wins conflicts over fields it generates
+	if err := c.Patch(ctx, desired, client.Apply, client.FieldOwner(FieldManager), client.ForceOwnership); err != nil {
+		log.Error(err, "reconcile failed", "kind", gvk.Kind, "name", desired.GetName())
+		return controllerutil.OperationResultNone, err
+	}
+	if op == controllerutil.OperationResultUpdated && desired.GetResourceVersion() == existing.GetResourceVersion() {
+		op = controllerutil.OperationResultNone
 	}
 
 	if op != controllerutil.OperationResultNone {
-		log.Info("reconciled", "kind", desired.GetObjectKind().GroupVersionKind().Kind, "name", desired.GetName(), "operation", op)
+		log.Info("reconciled", "kind", gvk.Kind, "name", desired.GetName(), "operation", op)
 	}
+	return op, nil
+}
 
-	desired.SetResourceVersion(existing.GetResourceVersion())
-	return nil
+// upgradeManagedFields hands the fields the operator wrote with Update to
+// FieldManager the first time an existing resource is applied. Without it
+// those fields would stay owned by the old manager, and the operator could not
+// remove them once it stops generating them.
+func upgradeManagedFields(ctx context.Context, c client.Client, existing client.Object) error {
+	for _, entry := range existing.GetManagedFields() {
+		if entry.Manager == FieldManager {
+			return nil
+		}
+	}
+	patch, err := csaupgrade.UpgradeManagedFieldsPatch(existing, legacyFieldManagers, FieldManager)
+	if err != nil || patch == nil {
+		return err
+	}
+	return c.Patch(ctx, existing, client.RawPatch(types.JSONPatchType, patch))
 }
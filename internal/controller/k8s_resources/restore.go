@@ -0,0 +1,251 @@
+package k8sresources
+
+import (
+	"fmt"
+	"strings"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// RestoreContainer is the container that seeds the data directory, either as
+// an init container of the Keydb pods or in the Job of a KeydbRestore.
+const RestoreContainer = "restore"
+
+// LabelRestore is set on the Jobs and pods of a KeydbRestore.
+const LabelRestore = "keydb.keydb/restore"
+
+// restoreLoadMarker is written next to restored data so the next start of
+// KeyDB loads dump.rdb without AOF (see appendOnlyRestoreArgs).
+const restoreLoadMarker = ".keydb-load-rdb"
+
+// jobDataDir is where the backup and restore containers mount the data
+// volume, whatever the image flavor mounts it at in the KeyDB pods.
+const jobDataDir = "/bitnami/keydb/data"
+
+// restorePrelude runs before the source-specific copy. Only the first pod is
+// seeded, and only once: a marker file in the data directory, or any existing
+// data, makes later starts a no-op. The result is written to the termination
+// log as {"result":"restored|skipped|failed","message":"..."}.
+const restorePrelude = `set -eu
+DATA=` + jobDataDir + `
+MARKER="$DATA/.keydb-restored"
+report() { printf '{"result":"%s","message":"%s"}' "$1" "$2" > /dev/termination-log; }
+if [ "$HOSTNAME" != "$RESTORE_POD" ]; then
+  exit 0
+fi
+if [ -f "$MARKER" ]; then
+  report skipped "data was already restored"
+  exit 0
+fi
+if [ -e "$DATA/dump.rdb" ] || [ -e "$DATA/appendonly.aof" ] || [ -e "$DATA/appendonlydir" ]; then
+  touch "$MARKER"
+  report skipped "data directory is not empty"
+  exit 0
+fi
+`
+
+const restoreFromS3 = `if [ -n "${S3_ENDPOINT:-}" ]; then
+  aws --endpoint-url "$S3_ENDPOINT" s3 cp "$S3_URL" "$DATA/dump.rdb.part"
+else
+  aws s3 cp "$S3_URL" "$DATA/dump.rdb.part"
+fi
+mv "$DATA/dump.rdb.part" "$DATA/dump.rdb"
+touch "$DATA/` + restoreLoadMarker + `" "$MARKER"
+report restored "restored dump.rdb from $S3_URL"
+`
+
+const restoreFromPVC = `FOUND=""
+for f in dump.rdb appendonly.aof; do
+  if [ -f "/restore/$f" ]; then
+    cp "/restore/$f" "$DATA/$f.part"
+    mv "$DATA/$f.part" "$DATA/$f"
+    FOUND="$FOUND $f"
+  fi
+done
+if [ -d /restore/appendonlydir ]; then
+  cp -r /restore/appendonlydir "$DATA/appendonlydir"
+  FOUND="$FOUND appendonlydir"
+fi
+if [ -z "$FOUND" ]; then
+  report failed "no dump.rdb or AOF files found in the claim"
+  exit 1
+fi
+touch "$DATA/` + restoreLoadMarker + `" "$MARKER"
+report restored "restored$FOUND from the claim"
+`
+
+// inPlaceRestoreScript replaces the data of a running pod with $S3_URL. The
+// AOF is removed last so a failed download leaves the old data in place.
+const inPlaceRestoreScript = `set -eu
+DATA=` + jobDataDir + `
+if [ -n "${S3_ENDPOINT:-}" ]; then
+  aws --endpoint-url "$S3_ENDPOINT" s3 cp "$S3_URL" "$DATA/dump.rdb.restore"
+else
+  aws s3 cp "$S3_URL" "$DATA/dump.rdb.restore"
+fi
+rm -rf "$DATA/appendonly.aof" "$DATA/appendonlydir"
+mv "$DATA/dump.rdb.restore" "$DATA/dump.rdb"
+touch "$DATA/` + restoreLoadMarker + `" "$DATA/.keydb-restored"
+`
+
+// RestoreS3Source returns the S3 object to restore from: spec.restore.s3, or
+// the backup resolved into status.restore.s3.
+func RestoreS3Source(k *keydbv1.Keydb) *keydbv1.S3RestoreSource {
+	if k.Spec.Restore == nil {
+		return nil
+	}
+	if k.Spec.Restore.S3 != nil {
+		return k.Spec.Restore.S3
+	}
+	if k.Spec.Restore.BackupName != "" && k.Status.Restore != nil {
+		return k.Status.Restore.S3
+	}
+	return nil
+}
+
+// BackupS3Source returns the S3 object a completed KeydbBackup was uploaded to.
+func BackupS3Source(b *keydbv1.KeydbBackup) *keydbv1.S3RestoreSource {
+	s3 := b.Spec.Storage.S3
+	return &keydbv1.S3RestoreSource{
+		Bucket:            s3.Bucket,
+		Key:               strings.TrimPrefix(b.Status.Location, "s3://"+s3.Bucket+"/"),
+		Endpoint:          s3.Endpoint,
+		Region:            s3.Region,
+		CredentialsSecret: s3.CredentialsSecret,
+	}
+}
+
+// RestoreS3URL returns the s3:// URL of an S3 restore source.
+func RestoreS3URL(s3 *keydbv1.S3RestoreSource) string {
+	return fmt.Sprintf("s3://%s/%s", s3.Bucket, s3.Key)
+}
+
+// restorePod is the pod seeded by a restore. It is fixed to ordinal 0, which
+// is the primary when the Keydb is created, so a later failover does not
+// change the pod template.
+func restorePod(k *keydbv1.Keydb) string {
+	return k.Name + "-0"
+}
+
+// generateRestoreInitContainer returns the init container and extra volumes
+// for spec.restore, or nil when there is nothing to restore. dataMount is the
+// pod's data volume mount.
+func generateRestoreInitContainer(k *keydbv1.Keydb, dataMount corev1.VolumeMount) (*corev1.Container, []corev1.Volume) {
+	if k.Spec.Restore == nil {
+		return nil, nil
+	}
+	env := []corev1.EnvVar{{Name: "RESTORE_POD", Value: restorePod(k)}}
+	dataMount.MountPath = jobDataDir
+
+	if pvc := k.Spec.Restore.PersistentVolumeClaim; pvc != nil {
+		container := &corev1.Container{
+			Name:    RestoreContainer,
+			Image:   keydbImage(k),
+			Command: []string{"/bin/sh", "-c", restorePrelude + restoreFromPVC},
+			Env:     env,
+			VolumeMounts: []corev1.VolumeMount{
+				dataMount,
+				{Name: "restore-source", MountPath: "/restore", SubPath: pvc.SubPath, ReadOnly: true},
+			},
+		}
+		volume := corev1.Volume{
+			Name: "restore-source",
+			VolumeSource: corev1.VolumeSource{
+				PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+					ClaimName: pvc.ClaimName,
+					ReadOnly:  true,
+				},
+			},
+		}
+		return container, []corev1.Volume{volume}
+	}
+
+	s3 := RestoreS3Source(k)
+	if s3 == nil {
+		// The backup has not been resolved yet; the controller waits for it
+		return nil, nil
+	}
+	image := k.Spec.Restore.Image
+	if image == "" {
+		image = keydbv1.DefaultBackupImage
+	}
+	env = append(env, s3Env(s3.CredentialsSecret, s3.Endpoint, s3.Region)...)
+	env = append(env, corev1.EnvVar{Name: "S3_URL", Value: RestoreS3URL(s3)})
+	return &corev1.Container{
+		Name:         RestoreContainer,
+		Image:        image,
+		Command:      []string{"/bin/sh", "-c", restorePrelude + restoreFromS3},
+		Env:          env,
+		VolumeMounts: []corev1.VolumeMount{dataMount},
+	}, nil
+}
+
+// RestoreJobName returns the name of the Job replacing the data of a KeydbRestore.
+func RestoreJobName(r *keydbv1.KeydbRestore) string {
+	return r.Name + "-restore"
+}
+
+// GenerateRestoreJob returns the Job that downloads s3 into the data volume of
+// pod while it is still running. The Job runs on the pod's node so it can
+// mount the ReadWriteOnce claim, and as the KeyDB user so the server can
+// write to the files afterwards.
+func GenerateRestoreJob(r *keydbv1.KeydbRestore, k *keydbv1.Keydb, pod *corev1.Pod, s3 *keydbv1.S3RestoreSource, scheme *runtime.Scheme) *batchv1.Job {
+	labels := map[string]string{LabelRestore: r.Name}
+	backoffLimit := int32(2)
+
+	image := r.Spec.Image
+	if image == "" {
+		image = keydbv1.DefaultBackupImage
+	}
+
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      RestoreJobName(r),
+			Namespace: r.Namespace,
+			Labels:    labels,
+		},
+		Spec: batchv1.JobSpec{
+			BackoffLimit: &backoffLimit,
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{Labels: labels},
+				Spec: corev1.PodSpec{
+					RestartPolicy:   corev1.RestartPolicyNever,
+					NodeName:        pod.Spec.NodeName,
+					Tolerations:     k.Spec.Tolerations,
+					SecurityContext: podSecurityContext(k),
+					Containers: []corev1.Container{
+						{
+							Name:    RestoreContainer,
+							Image:   image,
+							Command: []string{"/bin/sh", "-c", inPlaceRestoreScript},
+							Env: append(s3Env(s3.CredentialsSecret, s3.Endpoint, s3.Region),
+								corev1.EnvVar{Name: "S3_URL", Value: RestoreS3URL(s3)},
+							),
+							VolumeMounts: []corev1.VolumeMount{{Name: "data", MountPath: jobDataDir}},
+						},
+					},
+					Volumes: []corev1.Volume{
+						{
+							Name: "data",
+							VolumeSource: corev1.VolumeSource{
+								PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+									ClaimName: DataPVCName(k, pod.Name),
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	restrictContainers(k, &job.Spec.Template.Spec, false)
+
+	_ = ctrl.SetControllerReference(r, job, scheme)
+	return job
+}
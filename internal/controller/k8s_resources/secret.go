@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/base64"
+	"fmt"
 
 	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
 	corev1 "k8s.io/api/core/v1"
@@ -14,6 +15,14 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// Keys of the generated password Secret. The pods only mount SecretKeyPassword;
+// the other two hold the incoming and outgoing passwords during a rotation.
+const (
+	SecretKeyPassword         = "password"
+	SecretKeyNextPassword     = "next-password"
+	SecretKeyPreviousPassword = "previous-password"
+)
+
 func GenerateSecret(k *keydbv1.Keydb, scheme *runtime.Scheme, c client.Client) *corev1.Secret {
 	// If the user provided a secret, we don't need to generate one
 	if k.Spec.PasswordSecret != nil {
@@ -27,6 +36,7 @@ func GenerateSecret(k *keydbv1.Keydb, scheme *runtime.Scheme, c client.Client) *
 	setPassword := ""
 
 	// Try to fetch existing secret to reuse password
+	data := map[string][]byte{}
 	var existing corev1.Secret
 	err := c.Get(context.TODO(), types.NamespacedName{
 		Name:      secretName,
@@ -34,9 +44,15 @@ func GenerateSecret(k *keydbv1.Keydb, scheme *runtime.Scheme, c client.Client) *
 	}, &existing)
 
 	if err == nil {
-		if pw, ok := existing.Data["password"]; ok && len(pw) > 0 {
+		if pw, ok := existing.Data[SecretKeyPassword]; ok && len(pw) > 0 {
 			setPassword = string(pw)
 		}
+		// Keep the passwords of a rotation in progress
+		for _, key := range []string{SecretKeyNextPassword, SecretKeyPreviousPassword} {
+			if pw, ok := existing.Data[key]; ok {
+				data[key] = pw
+			}
+		}
 	}
 
 	// If still empty, generate a random one
@@ -49,15 +65,18 @@ func GenerateSecret(k *keydbv1.Keydb, scheme *runtime.Scheme, c client.Client) *
 		setPassword = pw
 	}
 
+	data[SecretKeyPassword] = []byte(setPassword)
+
 	secret := &corev1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      secretName,
 			Namespace: k.Namespace,
 			Labels:    labels,
+			// Apply only over the Secret read above, so that a stale read
+			// cannot bring back the passwords a rotation just removed
+			ResourceVersion: existing.ResourceVersion,
 		},
-		Data: map[string][]byte{
-			"password": []byte(setPassword),
-		},
+		Data: data,
 	}
 
 	_ = ctrl.SetControllerReference(k, secret, scheme)
@@ -71,3 +90,27 @@ func Generatepassword(length int) (string, error) {
 	}
 	return base64.RawURLEncoding.EncodeToString(bytes), nil
 }
+
+// PasswordSecretRef returns the Secret name and key holding the KeyDB password:
+// the user-provided PasswordSecret or the generated <name>-secret.
+func PasswordSecretRef(k *keydbv1.Keydb) (string, string) {
+	if k.Spec.PasswordSecret != nil {
+		return k.Spec.PasswordSecret.Name, k.Spec.PasswordSecret.Key
+	}
+	return k.Name + "-secret", SecretKeyPassword
+}
+
+// ResolvePassword returns the password KeyDB is configured with.
+func ResolvePassword(ctx context.Context, c client.Reader, k *keydbv1.Keydb) (string, error) {
+	name, key := PasswordSecretRef(k)
+
+	var secret corev1.Secret
+	if err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: k.Namespace}, &secret); err != nil {
+		return "", err
+	}
+	pw, ok := secret.Data[key]
+	if !ok {
+		return "", fmt.Errorf("secret %s/%s has no key %q", k.Namespace, name, key)
+	}
+	return string(pw), nil
+}
@@ -0,0 +1,70 @@
+package k8sresources
+
+import (
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// podSecurityContext returns the restricted pod SecurityContext with the
+// identity and seccomp profile of spec.securityContext. k may be nil for
+// Jobs that outlive their Keydb.
+func podSecurityContext(k *keydbv1.Keydb) *corev1.PodSecurityContext {
+	spec := securityContextSpec(k)
+	seccomp := &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault}
+	if spec.SeccompProfile != nil {
+		seccomp = spec.SeccompProfile.DeepCopy()
+	}
+	return &corev1.PodSecurityContext{
+		RunAsNonRoot:   &[]bool{true}[0],
+		RunAsUser:      int64OrDefault(spec.RunAsUser),
+		RunAsGroup:     int64OrDefault(spec.RunAsGroup),
+		FSGroup:        int64OrDefault(spec.FSGroup),
+		SeccompProfile: seccomp,
+	}
+}
+
+// containerSecurityContext returns the restricted container SecurityContext.
+// readOnlyRoot is false for Jobs whose images write to their home directory,
+// and for KeyDB pods when spec.securityContext.readOnlyRootFilesystem is off.
+func containerSecurityContext(k *keydbv1.Keydb, readOnlyRoot bool) *corev1.SecurityContext {
+	spec := securityContextSpec(k)
+	if spec.ReadOnlyRootFilesystem != nil && !*spec.ReadOnlyRootFilesystem {
+		readOnlyRoot = false
+	}
+	return &corev1.SecurityContext{
+		RunAsNonRoot:             &[]bool{true}[0],
+		RunAsUser:                int64OrDefault(spec.RunAsUser),
+		RunAsGroup:               int64OrDefault(spec.RunAsGroup),
+		AllowPrivilegeEscalation: &[]bool{false}[0],
+		ReadOnlyRootFilesystem:   &readOnlyRoot,
+		Capabilities: &corev1.Capabilities{
+			Drop: []corev1.Capability{"ALL"},
+		},
+	}
+}
+
+// restrictContainers sets the restricted SecurityContext on every container
+// of spec that does not have one yet.
+func restrictContainers(k *keydbv1.Keydb, spec *corev1.PodSpec, readOnlyRoot bool) {
+	for _, containers := range [][]corev1.Container{spec.InitContainers, spec.Containers} {
+		for i := range containers {
+			if containers[i].SecurityContext == nil {
+				containers[i].SecurityContext = containerSecurityContext(k, readOnlyRoot)
+			}
+		}
+	}
+}
+
+func securityContextSpec(k *keydbv1.Keydb) keydbv1.SecurityContextSpec {
+	if k == nil || k.Spec.SecurityContext == nil {
+		return keydbv1.SecurityContextSpec{}
+	}
+	return *k.Spec.SecurityContext
+}
+
+func int64OrDefault(v *int64) *int64 {
+	if v == nil {
+		return &[]int64{keydbv1.DefaultUID}[0]
+	}
+	return &[]int64{*v}[0]
+}
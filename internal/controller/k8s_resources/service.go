@@ -1,7 +1,10 @@
 package k8sresources
 
 import (
+	"fmt"
+
 	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -13,6 +16,13 @@ func GenerateService(k *keydbv1.Keydb, scheme *runtime.Scheme) ([]*corev1.Servic
 		"apps": k.Name,
 	}
 
+	// Client-facing Services only select pods that take traffic; the headless
+	// Service keeps every pod so replication is never cut off.
+	clientSelector := map[string]string{
+		"apps":               k.Name,
+		keydbv1.LabelTraffic: keydbv1.TrafficEnabled,
+	}
+
 	// ClusterIP Service (default access point)
 	clusterSvc := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
@@ -23,7 +33,7 @@ func GenerateService(k *keydbv1.Keydb, scheme *runtime.Scheme) ([]*corev1.Servic
 		Spec: corev1.ServiceSpec{
 			PublishNotReadyAddresses: true,
 			ClusterIP:                corev1.ClusterIPNone,
-			Selector:                 labels,
+			Selector:                 clientSelector,
 			Ports: []corev1.ServicePort{
 				{
 					Name: "redis",
@@ -33,6 +43,10 @@ func GenerateService(k *keydbv1.Keydb, scheme *runtime.Scheme) ([]*corev1.Servic
 			Type: corev1.ServiceTypeClusterIP,
 		},
 	}
+	if exposeService(k, clusterSvc) {
+		// NodePort and LoadBalancer Services need a cluster IP
+		clusterSvc.Spec.ClusterIP = ""
+	}
 	if err := ctrl.SetControllerReference(k, clusterSvc, scheme); err != nil {
 		return nil, err
 	}
@@ -64,11 +78,126 @@ func GenerateService(k *keydbv1.Keydb, scheme *runtime.Scheme) ([]*corev1.Servic
 		}
 		clusterSvc.Spec.Ports = append(clusterSvc.Spec.Ports, metricsPort)
 		headlessSvc.Spec.Ports = append(headlessSvc.Spec.Ports, metricsPort)
+		// The headless Service reaches every pod, ready or not
+		headlessSvc.Labels = map[string]string{"apps": k.Name, keydbv1.LabelMetrics: "true"}
 	}
 
 	if err := ctrl.SetControllerReference(k, headlessSvc, scheme); err != nil {
 		return nil, err
 	}
 
-	return []*corev1.Service{clusterSvc, headlessSvc}, nil
+	services := []*corev1.Service{clusterSvc, headlessSvc}
+
+	// In master-replica mode, expose the writable primary and the read-only
+	// replicas separately using the role label maintained by the operator.
+	if k.Spec.Replication.Mode == keydbv1.ReplicationModeMasterReplica {
+		for _, role := range []struct{ suffix, role string }{
+			{"-primary", keydbv1.RolePrimary},
+			{"-replicas", keydbv1.RoleReplica},
+		} {
+			svc := &corev1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      k.Name + role.suffix,
+					Namespace: k.Namespace,
+					Labels:    labels,
+				},
+				Spec: corev1.ServiceSpec{
+					Selector: map[string]string{
+						"apps":               k.Name,
+						keydbv1.LabelRole:    role.role,
+						keydbv1.LabelTraffic: keydbv1.TrafficEnabled,
+					},
+					Ports: []corev1.ServicePort{
+						{
+							Name: "redis",
+							Port: 6379,
+						},
+					},
+					Type: corev1.ServiceTypeClusterIP,
+				},
+			}
+			exposeService(k, svc)
+			if err := ctrl.SetControllerReference(k, svc, scheme); err != nil {
+				return nil, err
+			}
+			services = append(services, svc)
+		}
+	}
+
+	if k.Spec.Service.PerPod {
+		podServices, err := generatePodServices(k, scheme)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, podServices...)
+	}
+
+	return services, nil
+}
+
+// generatePodServices returns a Service per pod ordinal, named after the pod.
+// They select the pod whether or not it takes client traffic, since peers
+// replicate through them.
+func generatePodServices(k *keydbv1.Keydb, scheme *runtime.Scheme) ([]*corev1.Service, error) {
+	replicas := int32(1)
+	if k.Spec.Replicas != nil {
+		replicas = *k.Spec.Replicas
+	}
+
+	var services []*corev1.Service
+	for i := int32(0); i < replicas; i++ {
+		pod := fmt.Sprintf("%s-%d", k.Name, i)
+		svc := &corev1.Service{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      pod,
+				Namespace: k.Namespace,
+				Labels: map[string]string{
+					"apps":                  k.Name,
+					keydbv1.LabelPodService: "true",
+				},
+			},
+			Spec: corev1.ServiceSpec{
+				PublishNotReadyAddresses: true,
+				Selector: map[string]string{
+					"apps":                         k.Name,
+					appsv1.StatefulSetPodNameLabel: pod,
+				},
+				Ports: []corev1.ServicePort{
+					{
+						Name: "redis",
+						Port: 6379,
+					},
+				},
+				Type: corev1.ServiceTypeClusterIP,
+			},
+		}
+		exposeService(k, svc)
+		if err := ctrl.SetControllerReference(k, svc, scheme); err != nil {
+			return nil, err
+		}
+		services = append(services, svc)
+	}
+	return services, nil
+}
+
+// exposeService applies spec.service to a client-facing Service and reports
+// whether it is reachable from outside the cluster.
+func exposeService(k *keydbv1.Keydb, svc *corev1.Service) bool {
+	spec := k.Spec.Service
+	if len(spec.Annotations) > 0 {
+		svc.Annotations = map[string]string{}
+		for key, value := range spec.Annotations {
+			svc.Annotations[key] = value
+		}
+	}
+	if spec.Type != corev1.ServiceTypeNodePort && spec.Type != corev1.ServiceTypeLoadBalancer {
+		return false
+	}
+
+	svc.Spec.Type = spec.Type
+	svc.Spec.ExternalTrafficPolicy = spec.ExternalTrafficPolicy
+	if spec.Type == corev1.ServiceTypeLoadBalancer {
+		svc.Spec.LoadBalancerSourceRanges = spec.LoadBalancerSourceRanges
+	}
+	return true
 }
@@ -1,10 +1,12 @@
 package k8sresources
 
 import (
+	"fmt"
 	"net"
 	"strings"
 
 	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	"github.com/rsingh0101/keydb-operator/internal/configreloader"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/resource"
@@ -13,10 +15,13 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 )
 
-func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.StatefulSet {
+// GenerateStatefulSet returns the KeyDB StatefulSet. reloaderImage runs the
+// config reloader sidecar, which ships in the operator image.
+func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme, reloaderImage string) (*appsv1.StatefulSet, error) {
 	labels := map[string]string{
 		"apps": k.Name,
 	}
+	profile := profileFor(k)
 	storageClassName := k.Spec.Persistence.StorageClassName
 	var volumeClaimTemplates []corev1.PersistentVolumeClaim
 	if k.Spec.Persistence.Size != "" {
@@ -44,33 +49,31 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 		}
 	}
 
+	// The root filesystem is read-only, so everything written outside the
+	// data directory goes to /tmp
+	tmpMount := corev1.VolumeMount{
+		Name:      "empty-dir",
+		MountPath: "/tmp",
+		SubPath:   "tmp-dir",
+	}
 	volumeMounts := []corev1.VolumeMount{
 		{
 			Name:      k.Name + "-config",
-			MountPath: "/opt/bitnami/keydb/etc/",
+			MountPath: profile.ConfigDir,
 		},
 		{
 			Name:      k.Name + "-healthz",
-			MountPath: "/opt/bitnami/scripts/health",
+			MountPath: healthScriptsDir,
 			ReadOnly:  true,
 		},
 		{
 			Name:      k.Name + "-secret",
-			MountPath: "/opt/bitnami/keydb/secrets",
-		},
-		{
-			Name:      "empty-dir",
-			MountPath: "/tmp",
-			SubPath:   "tmp-dir",
+			MountPath: secretsDir,
 		},
+		tmpMount,
 	}
 
-	secretName := k.Name + "-secret"
-	secretKey := "password"
-	if k.Spec.PasswordSecret != nil {
-		secretName = k.Spec.PasswordSecret.Name
-		secretKey = k.Spec.PasswordSecret.Key
-	}
+	secretName, secretKey := PasswordSecretRef(k)
 
 	volumes := []corev1.Volume{
 		{
@@ -119,7 +122,7 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 	if k.Spec.Persistence.Enabled {
 		volumeMounts = append(volumeMounts, corev1.VolumeMount{
 			Name:      "data-" + k.Name + "-pvc",
-			MountPath: "/bitnami/keydb/data/",
+			MountPath: profile.DataDir,
 		})
 
 		volumes = append(volumes, corev1.Volume{
@@ -134,7 +137,7 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 		// use EmptyDir as fallback
 		volumeMounts = append(volumeMounts, corev1.VolumeMount{
 			Name:      "data-empty-dir",
-			MountPath: "/bitnami/keydb/data/",
+			MountPath: profile.DataDir,
 		})
 
 		volumes = append(volumes, corev1.Volume{
@@ -160,32 +163,27 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 			ServiceName:          k.Name + "-headless",
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Labels: labels,
+					// Pods take client traffic from the start, so the
+					// Services keep their endpoints while the operator is
+					// down; a restore relabels them to drain them
+					Labels: map[string]string{
+						"apps":               k.Name,
+						keydbv1.LabelTraffic: keydbv1.TrafficEnabled,
+					},
 				},
 				Spec: corev1.PodSpec{
 					ServiceAccountName: k.Name,
-					SecurityContext: &corev1.PodSecurityContext{
-						RunAsNonRoot: &[]bool{true}[0],
-						RunAsUser:    &[]int64{1001}[0],
-						RunAsGroup:   &[]int64{1001}[0],
-						FSGroup:      &[]int64{1001}[0],
-					},
+					SecurityContext:    podSecurityContext(k),
 					Containers: []corev1.Container{
 						{
-
-							SecurityContext: &corev1.SecurityContext{
-								RunAsNonRoot: &[]bool{true}[0],
-								RunAsUser:    &[]int64{1001}[0],
-								RunAsGroup:   &[]int64{1001}[0],
-							},
 							Name:  "keydb",
-							Image: k.Spec.Image,
+							Image: keydbImage(k),
 							Command: []string{
 								"/bin/bash",
 							},
 							Args: []string{
 								"-ec",
-								`. /opt/bitnami/scripts/keydb-env.sh;if [ -f /opt/bitnami/scripts/health/fix_replication_config.sh ]; then bash /opt/bitnami/scripts/health/fix_replication_config.sh || true; fi;CONFIG_FILE="${KEYDB_MODIFIED_CONFIG:-/opt/bitnami/keydb/etc/keydb.conf}";args=("${CONFIG_FILE}");args+=("--requirepass" "$KEYDB_PASSWORD");args+=("--masterauth" "$KEYDB_MASTER_PASSWORD");exec keydb-server "${args[@]}"`,
+								profile.startupScript(),
 							},
 							Ports: []corev1.ContainerPort{
 								{
@@ -199,7 +197,7 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 										Command: []string{
 											"sh",
 											"-c",
-											"/opt/bitnami/scripts/health/ping_liveness_local_and_master.sh 5",
+											healthScriptsDir + "/ping_liveness_local_and_master.sh 5",
 										},
 									},
 								},
@@ -215,7 +213,7 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 										Command: []string{
 											"sh",
 											"-c",
-											"/opt/bitnami/scripts/health/ping_readiness_local_and_master.sh 5",
+											healthScriptsDir + "/ping_readiness_local_and_master.sh 5",
 										},
 									},
 								},
@@ -228,11 +226,11 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 							Env: []corev1.EnvVar{
 								{
 									Name:  "KEYDB_PASSWORD_FILE",
-									Value: "/opt/bitnami/keydb/secrets/password",
+									Value: passwordFile,
 								},
 								{
 									Name:  "KEYDB_MASTER_PASSWORD_FILE",
-									Value: "/opt/bitnami/keydb/secrets/password",
+									Value: passwordFile,
 								},
 								{
 									Name:  "KEYDB_PORT_NUMBER",
@@ -248,28 +246,17 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 							Resources: getContainerResources(k),
 						},
 						{
-							SecurityContext: &corev1.SecurityContext{
-								RunAsNonRoot: &[]bool{true}[0],
-								RunAsUser:    &[]int64{1001}[0],
-								RunAsGroup:   &[]int64{1001}[0],
-							},
-							Name:  "config-reloader",
-							Image: k.Spec.Image,
-							Command: []string{
-								"/bin/bash",
-							},
+							Name:    "config-reloader",
+							Image:   reloaderImage,
+							Command: []string{"/config-reloader"},
 							Args: []string{
-								"-c",
-								". /opt/bitnami/scripts/keydb-env.sh; exec bash /opt/bitnami/scripts/health/config_reloader.sh",
+								"--config-file=" + profile.configFile(),
+								"--password-file=" + passwordFile,
 							},
-							Env: []corev1.EnvVar{
+							Ports: []corev1.ContainerPort{
 								{
-									Name:  "KEYDB_PASSWORD_FILE",
-									Value: "/opt/bitnami/keydb/secrets/password",
-								},
-								{
-									Name:  "KEYDB_PORT_NUMBER",
-									Value: "6379",
+									Name:          "reloader",
+									ContainerPort: configreloader.Port,
 								},
 							},
 							VolumeMounts: volumeMounts,
@@ -287,31 +274,42 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 					},
 					Volumes: volumes,
 					// Add pod anti-affinity for better distribution
-					Affinity: getPodAffinity(labels),
+					Affinity:                  getPodAffinity(k, labels),
+					NodeSelector:              k.Spec.NodeSelector,
+					Tolerations:               k.Spec.Tolerations,
+					TopologySpreadConstraints: getTopologySpreadConstraints(k, labels),
+					PriorityClassName:         k.Spec.PriorityClassName,
 				},
 			},
 		},
 	}
 
 	if k.Spec.Metrics.Enabled {
-		metricsImage := "oliver006/redis_exporter:latest"
+		metricsImage := keydbv1.DefaultMetricsImage
 		if k.Spec.Metrics.Image != "" {
 			metricsImage = k.Spec.Metrics.Image
 		}
+		// The config reloader keeps the exporter's password file in sync with
+		// the mounted Secret, so a rotation does not restart the pods
+		exporterMount := corev1.VolumeMount{
+			Name:      "empty-dir",
+			MountPath: exporterDir,
+			SubPath:   "exporter-dir",
+		}
+		for i := range sts.Spec.Template.Spec.Containers {
+			c := &sts.Spec.Template.Spec.Containers[i]
+			if c.Name == "config-reloader" {
+				c.Args = append(c.Args, "--exporter-password-file="+exporterPasswordFile)
+				c.VolumeMounts = append(c.VolumeMounts, exporterMount)
+			}
+		}
 		sts.Spec.Template.Spec.Containers = append(sts.Spec.Template.Spec.Containers, corev1.Container{
 			Name:  "metrics",
 			Image: metricsImage,
 			Env: []corev1.EnvVar{
 				{
-					Name: "REDIS_PASSWORD",
-					ValueFrom: &corev1.EnvVarSource{
-						SecretKeyRef: &corev1.SecretKeySelector{
-							LocalObjectReference: corev1.LocalObjectReference{
-								Name: secretName,
-							},
-							Key: secretKey,
-						},
-					},
+					Name:  "REDIS_PASSWORD_FILE",
+					Value: exporterPasswordFile,
 				},
 				{
 					Name:  "REDIS_ADDR",
@@ -324,6 +322,7 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 					ContainerPort: 9121,
 				},
 			},
+			VolumeMounts: []corev1.VolumeMount{exporterMount},
 			Resources: corev1.ResourceRequirements{
 				Requests: corev1.ResourceList{
 					corev1.ResourceCPU:    resource.MustParse("10m"),
@@ -337,8 +336,29 @@ func GenerateStatefulSet(k *keydbv1.Keydb, scheme *runtime.Scheme) *appsv1.State
 		})
 	}
 
+	addTLS(k, &sts.Spec.Template.Spec)
+
+	podSpec := &sts.Spec.Template.Spec
+
+	// Seed the data directory of the first pod from spec.restore
+	dataMount := volumeMounts[len(volumeMounts)-1]
+	if restore, restoreVolumes := generateRestoreInitContainer(k, dataMount); restore != nil {
+		// The root filesystem is read-only; the aws CLI writes to HOME=/tmp
+		restore.VolumeMounts = append(restore.VolumeMounts, tmpMount)
+		podSpec.InitContainers = append(podSpec.InitContainers, *restore)
+		podSpec.Volumes = append(podSpec.Volumes, restoreVolumes...)
+	}
+
+	// Meet the restricted Pod Security Standard. Containers added through
+	// spec.podTemplate bring their own SecurityContext.
+	restrictContainers(k, podSpec, true)
+
+	if err := applyPodTemplate(k, &sts.Spec.Template); err != nil {
+		return nil, err
+	}
+
 	_ = ctrl.SetControllerReference(k, sts, scheme)
-	return sts
+	return sts, nil
 }
 
 // getContainerResources returns resource requirements for the container
@@ -366,23 +386,55 @@ func isEmptyResources(res corev1.ResourceRequirements) bool {
 	return len(res.Requests) == 0 && len(res.Limits) == 0
 }
 
-// getPodAffinity returns pod affinity configuration for better pod distribution
-func getPodAffinity(labels map[string]string) *corev1.Affinity {
-	return &corev1.Affinity{
-		PodAntiAffinity: &corev1.PodAntiAffinity{
-			PreferredDuringSchedulingIgnoredDuringExecution: []corev1.WeightedPodAffinityTerm{
-				{
-					Weight: 100,
-					PodAffinityTerm: corev1.PodAffinityTerm{
-						LabelSelector: &metav1.LabelSelector{
-							MatchLabels: labels,
-						},
-						TopologyKey: "kubernetes.io/hostname",
-					},
-				},
-			},
+// getPodAffinity returns spec.affinity with the pod anti-affinity selected
+// by spec.podAntiAffinity added, spreading the pods over nodes.
+func getPodAffinity(k *keydbv1.Keydb, labels map[string]string) *corev1.Affinity {
+	affinity := &corev1.Affinity{}
+	if k.Spec.Affinity != nil {
+		affinity = k.Spec.Affinity.DeepCopy()
+	}
+
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{
+			MatchLabels: labels,
 		},
+		TopologyKey: "kubernetes.io/hostname",
+	}
+	switch k.Spec.PodAntiAffinity {
+	case keydbv1.PodAntiAffinityNone:
+	case keydbv1.PodAntiAffinityRequired:
+		if affinity.PodAntiAffinity == nil {
+			affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+	default:
+		if affinity.PodAntiAffinity == nil {
+			affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+		}
+		affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			corev1.WeightedPodAffinityTerm{Weight: 100, PodAffinityTerm: term})
+	}
+
+	if affinity.NodeAffinity == nil && affinity.PodAffinity == nil && affinity.PodAntiAffinity == nil {
+		return nil
+	}
+	return affinity
+}
+
+// getTopologySpreadConstraints returns spec.topologySpreadConstraints, with
+// constraints lacking a label selector selecting the pods of the Keydb.
+func getTopologySpreadConstraints(k *keydbv1.Keydb, labels map[string]string) []corev1.TopologySpreadConstraint {
+	var constraints []corev1.TopologySpreadConstraint
+	for _, c := range k.Spec.TopologySpreadConstraints {
+		c := *c.DeepCopy()
+		if c.LabelSelector == nil {
+			c.LabelSelector = &metav1.LabelSelector{MatchLabels: labels}
+		}
+		constraints = append(constraints, c)
 	}
+	return constraints
 }
 
 // normalizeFQDN appends .svc.cluster.local if the user provided a short service name.
@@ -401,3 +453,22 @@ func NormalizeFQDN(h string) string {
 	// single token (service in same ns) -> best-effort suffix
 	return h + ".svc.cluster.local"
 }
+
+// PrimaryPodName returns the pod currently elected as primary, defaulting to ordinal 0.
+func PrimaryPodName(k *keydbv1.Keydb) string {
+	if k.Status.Primary != "" {
+		return k.Status.Primary
+	}
+	return k.Name + "-0"
+}
+
+// PodFQDN returns the stable DNS name of a pod behind the headless service.
+func PodFQDN(k *keydbv1.Keydb, podName string) string {
+	return fmt.Sprintf("%s.%s-headless.%s.svc.cluster.local", podName, k.Name, k.Namespace)
+}
+
+// DataPVCName returns the PersistentVolumeClaim the StatefulSet controller
+// creates for a pod's data volume.
+func DataPVCName(k *keydbv1.Keydb, podName string) string {
+	return "data-" + k.Name + "-pvc-" + podName
+}
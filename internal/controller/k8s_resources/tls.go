@@ -0,0 +1,175 @@
+package k8sresources
+
+import (
+	"fmt"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// TLSMountPath is where the certificate Secret is mounted in KeyDB pods.
+const TLSMountPath = "/opt/bitnami/keydb/certs"
+
+// keydbCLITLSArgs is exported to the pod scripts as KEYDB_CLI_TLS_ARGS so
+// every keydb-cli call speaks TLS when it is enabled.
+const keydbCLITLSArgs = "--tls --cacert " + TLSMountPath + "/ca.crt --cert " + TLSMountPath + "/tls.crt --key " + TLSMountPath + "/tls.key"
+
+// CertificateGVK is the cert-manager Certificate kind. It is handled as
+// unstructured so the operator does not depend on cert-manager's API module.
+var CertificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// TLSSecretName returns the Secret holding the serving certificate, or "" when
+// TLS is disabled.
+func TLSSecretName(k *keydbv1.Keydb) string {
+	switch {
+	case k.Spec.TLS == nil:
+		return ""
+	case k.Spec.TLS.SecretName != "":
+		return k.Spec.TLS.SecretName
+	default:
+		return k.Name + "-tls"
+	}
+}
+
+// tlsConfig returns the keydb.conf directives serving TLS on port 6379. The
+// plaintext port is closed.
+func tlsConfig(k *keydbv1.Keydb) []string {
+	authClients := k.Spec.TLS.AuthClients
+	if authClients == "" {
+		authClients = "no"
+	}
+	return []string{
+		"port 0",
+		"tls-port 6379",
+		"tls-cert-file " + TLSMountPath + "/tls.crt",
+		"tls-key-file " + TLSMountPath + "/tls.key",
+		"tls-ca-cert-file " + TLSMountPath + "/ca.crt",
+		"tls-auth-clients " + authClients,
+		"tls-replication yes",
+		"tls-cluster yes",
+	}
+}
+
+// TLSDNSNames returns the names the serving certificate must cover: the
+// Services, every pod behind the headless Service, and localhost for the probes.
+func TLSDNSNames(k *keydbv1.Keydb) []string {
+	var names []string
+	services := []string{k.Name + "-svc", k.Name + "-headless"}
+	if k.Spec.Replication.Mode == keydbv1.ReplicationModeMasterReplica {
+		services = append(services, k.Name+"-primary", k.Name+"-replicas")
+	}
+	for _, svc := range services {
+		names = append(names,
+			svc,
+			fmt.Sprintf("%s.%s", svc, k.Namespace),
+			fmt.Sprintf("%s.%s.svc", svc, k.Namespace),
+			fmt.Sprintf("%s.%s.svc.cluster.local", svc, k.Namespace),
+		)
+	}
+	replicas := int32(1)
+	if k.Spec.Replicas != nil {
+		replicas = *k.Spec.Replicas
+	}
+	for i := int32(0); i < replicas; i++ {
+		pod := fmt.Sprintf("%s-%d", k.Name, i)
+		names = append(names,
+			fmt.Sprintf("%s.%s-headless.%s.svc", pod, k.Name, k.Namespace),
+			PodFQDN(k, pod),
+		)
+		if k.Spec.Service.PerPod {
+			names = append(names,
+				fmt.Sprintf("%s.%s.svc", pod, k.Namespace),
+				fmt.Sprintf("%s.%s.svc.cluster.local", pod, k.Namespace),
+			)
+		}
+	}
+	return append(names, "localhost")
+}
+
+// GenerateCertificate returns the cert-manager Certificate for a Keydb, or nil
+// when the certificate comes from an existing Secret.
+func GenerateCertificate(k *keydbv1.Keydb, scheme *runtime.Scheme) *unstructured.Unstructured {
+	if k.Spec.TLS == nil || k.Spec.TLS.CertManager == nil {
+		return nil
+	}
+	cm := k.Spec.TLS.CertManager
+
+	issuerRef := map[string]interface{}{"name": cm.IssuerRef.Name}
+	if cm.IssuerRef.Kind != "" {
+		issuerRef["kind"] = cm.IssuerRef.Kind
+	}
+	if cm.IssuerRef.Group != "" {
+		issuerRef["group"] = cm.IssuerRef.Group
+	}
+	dnsNames := []interface{}{}
+	for _, name := range TLSDNSNames(k) {
+		dnsNames = append(dnsNames, name)
+	}
+	spec := map[string]interface{}{
+		"secretName": TLSSecretName(k),
+		"commonName": fmt.Sprintf("%s-svc.%s.svc", k.Name, k.Namespace),
+		"dnsNames":   dnsNames,
+		"issuerRef":  issuerRef,
+		// The certificate is presented to primaries when replicating too
+		"usages": []interface{}{"server auth", "client auth"},
+	}
+	if cm.Duration != nil {
+		spec["duration"] = cm.Duration.Duration.String()
+	}
+	if cm.RenewBefore != nil {
+		spec["renewBefore"] = cm.RenewBefore.Duration.String()
+	}
+
+	cert := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	cert.SetGroupVersionKind(CertificateGVK)
+	cert.SetName(k.Name + "-tls")
+	cert.SetNamespace(k.Namespace)
+	cert.SetLabels(map[string]string{"apps": k.Name})
+
+	_ = ctrl.SetControllerReference(k, cert, scheme)
+	return cert
+}
+
+// addTLS mounts the certificate into the KeyDB, config reloader and metrics
+// containers and switches them to TLS.
+func addTLS(k *keydbv1.Keydb, podSpec *corev1.PodSpec) {
+	secretName := TLSSecretName(k)
+	if secretName == "" {
+		return
+	}
+	podSpec.Volumes = append(podSpec.Volumes, corev1.Volume{
+		Name: "tls",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{SecretName: secretName},
+		},
+	})
+	mount := corev1.VolumeMount{Name: "tls", MountPath: TLSMountPath, ReadOnly: true}
+
+	for i := range podSpec.Containers {
+		c := &podSpec.Containers[i]
+		switch c.Name {
+		case "keydb":
+			c.VolumeMounts = append(c.VolumeMounts, mount)
+			c.Env = append(c.Env, corev1.EnvVar{Name: "KEYDB_CLI_TLS_ARGS", Value: keydbCLITLSArgs})
+		case "config-reloader":
+			c.VolumeMounts = append(c.VolumeMounts, mount)
+			c.Env = append(c.Env, corev1.EnvVar{Name: "KEYDB_TLS_DIR", Value: TLSMountPath})
+		case "metrics":
+			c.VolumeMounts = append(c.VolumeMounts, mount)
+			for j := range c.Env {
+				if c.Env[j].Name == "REDIS_ADDR" {
+					c.Env[j].Value = "rediss://localhost:6379"
+				}
+			}
+			c.Env = append(c.Env,
+				corev1.EnvVar{Name: "REDIS_EXPORTER_TLS_CA_CERT_FILE", Value: TLSMountPath + "/ca.crt"},
+				corev1.EnvVar{Name: "REDIS_EXPORTER_TLS_CLIENT_CERT_FILE", Value: TLSMountPath + "/tls.crt"},
+				corev1.EnvVar{Name: "REDIS_EXPORTER_TLS_CLIENT_KEY_FILE", Value: TLSMountPath + "/tls.key"},
+			)
+		}
+	}
+}
@@ -19,11 +19,17 @@ package controller
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
 	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
 	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	"github.com/rsingh0101/keydb-operator/internal/keydbconf"
+	"github.com/rsingh0101/keydb-operator/internal/metrics"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
 	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
@@ -31,7 +37,9 @@ import (
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 )
 
@@ -42,15 +50,24 @@ type KeydbReconciler struct {
 	client.Client
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
+	// KeydbClients talks to KeyDB pods; failover and node status are disabled when nil
+	KeydbClients *keydbclient.Pool
+	// ConfigReloaderImage runs the config reloader sidecar in KeyDB pods
+	ConfigReloaderImage string
 }
 
 // +kubebuilder:rbac:groups=keydb.keydb,resources=keydbs,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=keydb.keydb,resources=keydbs/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=keydb.keydb,resources=keydbs/finalizers,verbs=update
 // +kubebuilder:rbac:groups=apps,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=networking.k8s.io,resources=networkpolicies,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=apps,resources=statefulsets/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=policy,resources=poddisruptionbudgets,verbs=get;list;watch;create;update;patch;delete
-// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch;patch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=monitoring.coreos.com,resources=servicemonitors;prometheusrules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=services;configmaps;secrets;serviceaccounts,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -61,7 +78,7 @@ type KeydbReconciler struct {
 
 // For more details, check Reconcile and its Result here:
 // - https:// pkg.go.dev/sigs.k8s.io/controller-runtime@v0.21.0/pkg/reconcile
-func (r *KeydbReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+func (r *KeydbReconciler) Reconcile(ctx context.Context, req ctrl.Request) (result ctrl.Result, err error) {
 	logger := log.FromContext(ctx)
 
 	var keydb keydbv1.Keydb
@@ -69,83 +86,177 @@ func (r *KeydbReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		logger.Error(err, "unable to fetch Keydb")
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
+	// step names what the reconcile was doing when it failed
+	var step string
+	// Retries of a failing reconcile repeat the same event, which the
+	// recorder deduplicates
+	defer func() {
+		if err != nil {
+			r.event(&keydb, corev1.EventTypeWarning, keydbv1.ReasonReconcileError, err.Error())
+			if step != "" {
+				r.reportReconcileError(ctx, &keydb, step, err)
+			}
+		}
+	}()
 
 	// Handle deletion
 	if !keydb.DeletionTimestamp.IsZero() {
 		if controllerutil.ContainsFinalizer(&keydb, keydbFinalizer) {
 			// finalizer logic
+			if r.KeydbClients != nil {
+				r.KeydbClients.Forget(clusterKey(&keydb))
+			}
+			forgetTLSConfig(&keydb)
+			metrics.Forget(keydb.Namespace, keydb.Name)
 			controllerutil.RemoveFinalizer(&keydb, keydbFinalizer)
 			if err := r.Update(ctx, &keydb); err != nil {
 				logger.Error(err, "failed to remove finalizer from keydb")
 				return ctrl.Result{}, err
 			}
+			r.event(&keydb, corev1.EventTypeNormal, "FinalizerRemoved",
+				"Released the connections and metrics of the Keydb and removed the finalizer")
 		}
 		return ctrl.Result{}, nil
 	}
 
 	// Ensure finalizer is present
+	step = "finalizer"
 	if !controllerutil.ContainsFinalizer(&keydb, keydbFinalizer) {
 		controllerutil.AddFinalizer(&keydb, keydbFinalizer)
 		if err := r.Update(ctx, &keydb); err != nil {
 			logger.Error(err, "failed to add finalizer to keydb")
 			return ctrl.Result{}, err
 		}
+		r.event(&keydb, corev1.EventTypeNormal, "FinalizerAdded", "Added finalizer "+keydbFinalizer)
+	}
+
+	// Promote a replica before generating config so it points at the new primary
+	step = "failover"
+	failoverRequeue, err := r.reconcileFailover(ctx, &keydb)
+	if err != nil {
+		logger.Error(err, "failover failed")
+		return ctrl.Result{}, err
+	}
+
+	// Hold back the StatefulSet until a backup to restore from has completed
+	step = "restore"
+	if proceed, err := r.resolveRestore(ctx, &keydb); err != nil {
+		return ctrl.Result{}, err
+	} else if !proceed {
+		return ctrl.Result{RequeueAfter: restoreRequeue}, nil
 	}
 
 	// inside your Reconcile after you’ve fetched Keydb CR
+	step, start := "configmap", time.Now()
 	cmList, err := k8sresources.GenerateKeydbConfigMap(&keydb, r.Scheme) // returns []*corev1.ConfigMap
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 	for _, cm := range cmList {
-		if err := k8sresources.ApplyResource(ctx, r.Client, r.Scheme, &keydb, cm, logger); err != nil {
+		if err := r.apply(ctx, &keydb, cm); err != nil {
 			return ctrl.Result{}, err
 		}
 	}
-
-	secret := k8sresources.GenerateSecret(&keydb, r.Scheme, r.Client)
-	var secretHash string
-	if secret != nil {
-		secretHash = k8sresources.HashSecret(secret)
-		if err := k8sresources.ApplyResource(ctx, r.Client, r.Scheme, &keydb, secret, logger); err != nil {
+	metrics.ObserveStep("configmap", start)
+
+	step, start = "secret", time.Now()
+	// KeyDB, its scripts and, through the config reloader, the metrics
+	// exporter read the mounted password file, which follows the Secret, so
+	// a rotation needs no restart
+	if secret := k8sresources.GenerateSecret(&keydb, r.Scheme, r.Client); secret != nil {
+		if err := r.apply(ctx, &keydb, secret); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	metrics.ObserveStep("secret", start)
+
+	// cert-manager Certificate for spec.tls.certManager
+	if cert := k8sresources.GenerateCertificate(&keydb, r.Scheme); cert != nil {
+		step, start = "certificate", time.Now()
+		if err := r.apply(ctx, &keydb, cert); err != nil {
+			if meta.IsNoMatchError(err) {
+				r.event(&keydb, corev1.EventTypeWarning, "CertManagerMissing",
+					"spec.tls.certManager is set but cert-manager is not installed")
+			}
 			return ctrl.Result{}, err
 		}
-	} else if keydb.Spec.PasswordSecret != nil {
-		secretHash = "custom-" + keydb.Spec.PasswordSecret.Name
+		metrics.ObserveStep("certificate", start)
 	}
 
-	// Now statefulset: inject hashes as podTemplate annotations
-	sts := k8sresources.GenerateStatefulSet(&keydb, r.Scheme)
+	// Now statefulset: inject the config hash as a podTemplate annotation
+	step, start = "statefulset", time.Now()
+	sts, err := k8sresources.GenerateStatefulSet(&keydb, r.Scheme, r.ConfigReloaderImage)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
 	if sts.Spec.Template.Annotations == nil {
 		sts.Spec.Template.Annotations = map[string]string{}
 	}
-	sts.Spec.Template.Annotations["checksum/secret"] = secretHash
+	// Directives KeyDB only reads at startup roll the pods, as does adding or
+	// removing one, since KeyDB cannot go back to a default; the rest are
+	// applied by the config reloader
+	if configHash := keydbconf.RestartHash(keydb.Spec.Config); configHash != "" {
+		sts.Spec.Template.Annotations["checksum/config"] = configHash
+	}
+
+	var previousSts appsv1.StatefulSet
+	if err := r.Get(ctx, client.ObjectKeyFromObject(sts), &previousSts); err == nil {
+		r.reportStatefulSetChanges(&keydb, &previousSts, sts)
+	}
+	if err := r.apply(ctx, &keydb, sts); err != nil {
+		return ctrl.Result{}, err
+	}
+	metrics.ObserveStep("statefulset", start)
 
-	if err := k8sresources.ApplyResource(ctx, r.Client, r.Scheme, &keydb, sts, logger); err != nil {
+	// Keep the role and traffic labels in sync before the Services select on them
+	step, start = "pod-labels", time.Now()
+	if err := r.reconcilePodLabels(ctx, &keydb); err != nil {
+		logger.Error(err, "failed to update pod labels")
 		return ctrl.Result{}, err
 	}
+	metrics.ObserveStep("pod-labels", start)
 
 	// services
-	svcList, err := k8sresources.GenerateService(&keydb, r.Scheme)
-	if err != nil {
+	step, start = "service", time.Now()
+	if err := r.reconcileServices(ctx, &keydb); err != nil {
 		return ctrl.Result{}, err
 	}
-	for _, svc := range svcList {
-		if err := k8sresources.ApplyResource(ctx, r.Client, r.Scheme, &keydb, svc, logger); err != nil {
-			return ctrl.Result{}, err
-		}
+	metrics.ObserveStep("service", start)
+
+	step, start = "networkpolicy", time.Now()
+	if err := r.reconcileNetworkPolicy(ctx, &keydb); err != nil {
+		return ctrl.Result{}, err
+	}
+	metrics.ObserveStep("networkpolicy", start)
+
+	step, start = "monitoring", time.Now()
+	if err := r.reconcileMonitoring(ctx, &keydb); err != nil {
+		return ctrl.Result{}, err
 	}
+	metrics.ObserveStep("monitoring", start)
 
 	// ServiceAccount
-	if err := k8sresources.ApplyResource(ctx, r.Client, r.Scheme, &keydb, k8sresources.GenerateServiceAccount(&keydb, r.Scheme), logger); err != nil {
+	step, start = "serviceaccount", time.Now()
+	if err := r.apply(ctx, &keydb, k8sresources.GenerateServiceAccount(&keydb, r.Scheme)); err != nil {
 		return ctrl.Result{}, err
 	}
+	metrics.ObserveStep("serviceaccount", start)
 
 	// Pod Disruption Budget
+	step, start = "poddisruptionbudget", time.Now()
 	pdb := k8sresources.GeneratePodDisruptionBudget(&keydb, r.Scheme)
-	if err := k8sresources.ApplyResource(ctx, r.Client, r.Scheme, &keydb, pdb, logger); err != nil {
-		logger.Error(err, "failed to create/update PodDisruptionBudget")
-		// Don't fail reconciliation if PDB fails, but log it
+	pdbErr := r.apply(ctx, &keydb, pdb)
+	if pdbErr != nil {
+		logger.Error(pdbErr, "failed to create/update PodDisruptionBudget")
+		// Don't fail reconciliation if PDB fails, but report it in Reconciled
+	}
+	metrics.ObserveStep("poddisruptionbudget", start)
+
+	step = "password-rotation"
+	rotationRequeue, err := r.reconcilePasswordRotation(ctx, &keydb)
+	if err != nil {
+		logger.Error(err, "password rotation failed")
+		return ctrl.Result{}, err
 	}
 
 	// Get the current StatefulSet to update status
@@ -158,17 +269,28 @@ func (r *KeydbReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		logger.V(1).Info("StatefulSet not found yet, will update status on next reconcile", "error", err)
 	} else {
 		// Update status based on StatefulSet
-		if err := r.updateStatus(ctx, &keydb, &currentSts); err != nil {
+		start = time.Now()
+		failedStep := ""
+		if pdbErr != nil {
+			failedStep = "poddisruptionbudget"
+		}
+		if err := r.updateStatus(ctx, &keydb, &currentSts, failedStep, pdbErr); err != nil {
 			logger.Error(err, "failed to update status")
 		}
+		metrics.ObserveStep("status", start)
 	}
 
 	logger.Info("reconcile cycle completed successfully")
-	return ctrl.Result{}, nil
+	requeue := failoverRequeue
+	if rotationRequeue > 0 && (requeue == 0 || rotationRequeue < requeue) {
+		requeue = rotationRequeue
+	}
+	return ctrl.Result{RequeueAfter: requeue}, nil
 }
 
-// updateStatus updates the KeyDB status based on the StatefulSet status
-func (r *KeydbReconciler) updateStatus(ctx context.Context, keydb *keydbv1.Keydb, sts *appsv1.StatefulSet) error {
+// updateStatus updates the KeyDB status based on the StatefulSet status.
+// stepErr is the error of a step that did not stop the reconcile.
+func (r *KeydbReconciler) updateStatus(ctx context.Context, keydb *keydbv1.Keydb, sts *appsv1.StatefulSet, failedStep string, stepErr error) error {
 	logger := log.FromContext(ctx)
 
 	// Get current statefulset status
@@ -202,13 +324,32 @@ func (r *KeydbReconciler) updateStatus(ctx context.Context, keydb *keydbv1.Keydb
 	} else {
 		phase = "Unknown"
 	}
+	if _, restoring := keydb.Annotations[keydbv1.AnnotationRestore]; restoring {
+		phase = "Restoring"
+	}
+	if keydb.Status.Phase != phase {
+		message := "Phase is " + phase
+		if keydb.Status.Phase != "" {
+			message = fmt.Sprintf("Phase changed from %s to %s", keydb.Status.Phase, phase)
+		}
+		r.event(keydb, corev1.EventTypeNormal, "PhaseChanged", message)
+	}
 	keydb.Status.Phase = phase
 
+	if keydb.Spec.Replication.Mode == keydbv1.ReplicationModeMasterReplica {
+		keydb.Status.Primary = k8sresources.PrimaryPodName(keydb)
+	} else {
+		keydb.Status.Primary = ""
+	}
+
+	// Update replica status, which Degraded is computed from
+	r.updateReplicaStatus(ctx, keydb, sts)
+
 	// Update conditions
 	r.updateConditions(keydb, readyReplicas, desiredReplicas, currentReplicas)
+	setReconciledCondition(keydb, failedStep, stepErr)
 
-	// Update replica status
-	r.updateReplicaStatus(ctx, keydb, sts)
+	r.updateRestoreStatus(ctx, keydb)
 
 	if err := r.Status().Update(ctx, keydb); err != nil {
 		logger.Error(err, "failed to update KeyDB status")
@@ -225,7 +366,6 @@ func (r *KeydbReconciler) updateConditions(keydb *keydbv1.Keydb, readyReplicas,
 		Type:               keydbv1.ConditionTypeReady,
 		Status:             metav1.ConditionFalse,
 		ObservedGeneration: keydb.Generation,
-		LastTransitionTime: metav1.Now(),
 	}
 
 	if readyReplicas == desiredReplicas && currentReplicas == desiredReplicas {
@@ -244,7 +384,6 @@ func (r *KeydbReconciler) updateConditions(keydb *keydbv1.Keydb, readyReplicas,
 		Type:               keydbv1.ConditionTypeProgressing,
 		Status:             metav1.ConditionFalse,
 		ObservedGeneration: keydb.Generation,
-		LastTransitionTime: metav1.Now(),
 	}
 
 	if currentReplicas != desiredReplicas {
@@ -262,6 +401,76 @@ func (r *KeydbReconciler) updateConditions(keydb *keydbv1.Keydb, readyReplicas,
 	}
 
 	meta.SetStatusCondition(&keydb.Status.Conditions, progressingCondition)
+
+	meta.SetStatusCondition(&keydb.Status.Conditions, degradedCondition(keydb, readyReplicas))
+}
+
+// degradedCondition is True when the Keydb serves clients but a replica lost
+// its primary, a pod cannot be queried or a pod fails to persist its data.
+func degradedCondition(keydb *keydbv1.Keydb, readyReplicas int32) metav1.Condition {
+	condition := metav1.Condition{
+		Type:               keydbv1.ConditionTypeDegraded,
+		Status:             metav1.ConditionFalse,
+		ObservedGeneration: keydb.Generation,
+		Reason:             keydbv1.ReasonHealthy,
+		Message:            "Replication and persistence are healthy",
+	}
+	if readyReplicas == 0 {
+		condition.Reason = keydbv1.ReasonSomeReplicasNotReady
+		condition.Message = "No replicas are ready"
+		return condition
+	}
+
+	var replication, persistence []string
+	for _, node := range keydb.Status.Nodes {
+		switch {
+		case node.Error != "":
+			replication = append(replication, fmt.Sprintf("%s: %s", node.Pod, node.Error))
+		case node.LinkStatus == "down":
+			replication = append(replication, fmt.Sprintf("%s: link to %s is down", node.Pod, node.MasterHost))
+		}
+		if node.PersistenceError != "" {
+			persistence = append(persistence, fmt.Sprintf("%s: %s", node.Pod, node.PersistenceError))
+		}
+	}
+	switch {
+	case len(replication) > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = keydbv1.ReasonReplicationUnhealthy
+		condition.Message = strings.Join(append(replication, persistence...), "; ")
+	case len(persistence) > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = keydbv1.ReasonPersistenceUnhealthy
+		condition.Message = strings.Join(persistence, "; ")
+	}
+	return condition
+}
+
+// setReconciledCondition records whether every step of the reconcile
+// succeeded, naming the step that failed if not.
+func setReconciledCondition(keydb *keydbv1.Keydb, failedStep string, err error) {
+	condition := metav1.Condition{
+		Type:               keydbv1.ConditionTypeReconciled,
+		Status:             metav1.ConditionTrue,
+		ObservedGeneration: keydb.Generation,
+		Reason:             keydbv1.ReasonReconcileSuccess,
+		Message:            "All resources are up to date",
+	}
+	if err != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = keydbv1.ReasonReconcileError
+		condition.Message = fmt.Sprintf("Failed to reconcile %s: %v", failedStep, err)
+	}
+	meta.SetStatusCondition(&keydb.Status.Conditions, condition)
+}
+
+// reportReconcileError sets Reconciled=False for a reconcile that stopped at
+// step, so the error shows on the Keydb and not only in the operator logs.
+func (r *KeydbReconciler) reportReconcileError(ctx context.Context, keydb *keydbv1.Keydb, step string, err error) {
+	setReconciledCondition(keydb, step, err)
+	if updateErr := r.Status().Update(ctx, keydb); updateErr != nil {
+		log.FromContext(ctx).V(1).Info("unable to report reconcile error", "error", updateErr.Error())
+	}
 }
 
 // updateReplicaStatus updates the replica status in the KeyDB status
@@ -305,6 +514,13 @@ func (r *KeydbReconciler) updateReplicaStatus(ctx context.Context, keydb *keydbv
 		NotReady: notReady,
 		Failed:   failed,
 	}
+	previousNodes := keydb.Status.Nodes
+	keydb.Status.Nodes = r.collectNodeStatus(ctx, keydb, podList.Items)
+	r.reportReplicationLinks(keydb, previousNodes)
+
+	previous := keydb.Status.ConfigReload
+	keydb.Status.ConfigReload = r.collectConfigReloadStatus(ctx, keydb, podList.Items)
+	r.reportConfigReloadFailures(keydb, previous)
 }
 
 // isPodReady checks if a pod is ready
@@ -317,6 +533,32 @@ func isPodReady(pod *corev1.Pod) bool {
 	return false
 }
 
+func (r *KeydbReconciler) event(keydb *keydbv1.Keydb, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(keydb, eventType, reason, message)
+	}
+}
+
+// apply creates or updates a resource of the Keydb and records an event when
+// it is created or, for ConfigMaps, when the configuration changed.
+func (r *KeydbReconciler) apply(ctx context.Context, keydb *keydbv1.Keydb, obj client.Object) error {
+	op, err := k8sresources.ApplyResource(ctx, r.Client, r.Scheme, keydb, obj, log.FromContext(ctx))
+	if err != nil {
+		return err
+	}
+	kind := obj.GetObjectKind().GroupVersionKind().Kind
+	if gvk, err := apiutil.GVKForObject(obj, r.Scheme); err == nil {
+		kind = gvk.Kind
+	}
+	switch {
+	case op == controllerutil.OperationResultCreated:
+		r.event(keydb, corev1.EventTypeNormal, "Created", fmt.Sprintf("Created %s %s", kind, obj.GetName()))
+	case op == controllerutil.OperationResultUpdated && kind == "ConfigMap":
+		r.event(keydb, corev1.EventTypeNormal, "ConfigChanged", fmt.Sprintf("Updated ConfigMap %s", obj.GetName()))
+	}
+	return nil
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *KeydbReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
@@ -326,6 +568,8 @@ func (r *KeydbReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Owns(&corev1.ConfigMap{}).
 		Owns(&corev1.Secret{}).
 		Owns(&corev1.ServiceAccount{}).
+		Owns(&networkingv1.NetworkPolicy{}).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(podToKeydb)).
 		Named("keydb").
 		Complete(r)
 }
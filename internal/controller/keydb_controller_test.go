@@ -21,13 +21,16 @@ import (
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	appsv1 "k8s.io/api/apps/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
 )
 
 var _ = Describe("Keydb Controller", func() {
@@ -81,4 +84,59 @@ var _ = Describe("Keydb Controller", func() {
 			// Example: If you expect a certain status condition after reconciliation, verify it here.
 		})
 	})
+
+	Context("When applying the generated resources", func() {
+		const resourceName = "apply-resource"
+
+		ctx := context.Background()
+		key := types.NamespacedName{Name: resourceName, Namespace: "default"}
+		var controllerReconciler *KeydbReconciler
+
+		BeforeEach(func() {
+			controllerReconciler = &KeydbReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			Expect(k8sClient.Create(ctx, &keydbv1.Keydb{
+				ObjectMeta: metav1.ObjectMeta{Name: resourceName, Namespace: "default"},
+			})).To(Succeed())
+		})
+
+		AfterEach(func() {
+			resource := &keydbv1.Keydb{}
+			Expect(k8sClient.Get(ctx, key, resource)).To(Succeed())
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			// Let the reconciler remove its finalizer
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should leave fields set by other managers alone", func() {
+			_, err := controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			By("editing the StatefulSet as another field manager")
+			sts := &appsv1.StatefulSet{}
+			Expect(k8sClient.Get(ctx, key, sts)).To(Succeed())
+			patch := client.MergeFrom(sts.DeepCopy())
+			sts.Labels["team"] = "cache"
+			sts.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = "2025-01-01T00:00:00Z"
+			Expect(k8sClient.Patch(ctx, sts, patch, client.FieldOwner("kubectl-edit"))).To(Succeed())
+			resourceVersion := sts.ResourceVersion
+
+			By("reconciling again")
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: key})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(k8sClient.Get(ctx, key, sts)).To(Succeed())
+			Expect(sts.ResourceVersion).To(Equal(resourceVersion))
+			Expect(sts.Labels).To(HaveKeyWithValue("team", "cache"))
+			Expect(sts.Spec.Template.Annotations).To(HaveKey("kubectl.kubernetes.io/restartedAt"))
+			var managers []string
+			for _, entry := range sts.ManagedFields {
+				managers = append(managers, entry.Manager)
+			}
+			Expect(managers).To(ContainElements(k8sresources.FieldManager, "kubectl-edit"))
+		})
+	})
 })
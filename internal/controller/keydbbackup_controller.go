@@ -0,0 +1,390 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// backupCleanupFinalizer removes the uploaded snapshot of a backup with the
+// Delete deletion policy before the KeydbBackup goes away.
+const backupCleanupFinalizer = "keydb.keydb/backup-cleanup"
+
+const (
+	// backupPollInterval is how often a running BGSAVE is checked
+	backupPollInterval = 2 * time.Second
+	// backupWaitInterval is how long to wait for the Keydb or a source pod
+	backupWaitInterval = 30 * time.Second
+)
+
+// backupResult is what the upload container writes to its termination log.
+type backupResult struct {
+	Size   int64  `json:"size"`
+	Sha256 string `json:"sha256"`
+}
+
+// KeydbBackupReconciler reconciles a KeydbBackup object
+type KeydbBackupReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// KeydbClients talks to KeyDB pods; backups stay Pending when nil
+	KeydbClients *keydbclient.Pool
+}
+
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbbackups,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbbackups/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbbackups/finalizers,verbs=update
+// +kubebuilder:rbac:groups=batch,resources=jobs,verbs=get;list;watch;create;update;patch;delete
+
+// Reconcile drives a KeydbBackup through BGSAVE, the upload Job and the final
+// status. Completed and Failed backups are never retried.
+func (r *KeydbBackupReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	backup := &keydbv1.KeydbBackup{}
+	if err := r.Get(ctx, req.NamespacedName, backup); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !backup.DeletionTimestamp.IsZero() {
+		return r.reconcileBackupDeletion(ctx, backup)
+	}
+	wantFinalizer := backup.Spec.DeletionPolicy == keydbv1.BackupDeletionPolicyDelete
+	if wantFinalizer != controllerutil.ContainsFinalizer(backup, backupCleanupFinalizer) {
+		if wantFinalizer {
+			controllerutil.AddFinalizer(backup, backupCleanupFinalizer)
+		} else {
+			controllerutil.RemoveFinalizer(backup, backupCleanupFinalizer)
+		}
+		if err := r.Update(ctx, backup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if backup.Status.Phase == keydbv1.BackupPhaseCompleted || backup.Status.Phase == keydbv1.BackupPhaseFailed {
+		return ctrl.Result{}, nil
+	}
+
+	keydb := &keydbv1.Keydb{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Spec.KeydbName, Namespace: backup.Namespace}, keydb); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.setPending(ctx, backup, fmt.Sprintf("Keydb %s not found", backup.Spec.KeydbName))
+		}
+		return ctrl.Result{}, err
+	}
+	if r.KeydbClients == nil {
+		return r.setPending(ctx, backup, "operator has no KeyDB client configured")
+	}
+
+	// Step 1: request a snapshot on the source pod
+	if backup.Status.SourcePod == "" {
+		pod, err := r.selectBackupSource(ctx, backup, keydb)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if pod == nil {
+			return r.setPending(ctx, backup, "waiting for a ready source pod")
+		}
+
+		opts, err := keydbOptions(ctx, r.Client, keydb)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		// Read LASTSAVE before BGSAVE: the snapshot is ours once it changes.
+		// It is in the pod's clock, so it can't be compared with StartTime.
+		now := metav1.Now()
+		var previousSave int64
+		if err := withPod(ctx, r.KeydbClients, keydb, opts, pod, func(c *keydbclient.Client) error {
+			var err error
+			if previousSave, err = c.LastSave(ctx); err != nil {
+				return err
+			}
+			err = c.BgSave(ctx)
+			var replyErr keydbclient.Error
+			if errors.As(err, &replyErr) && strings.Contains(string(replyErr), "in progress") {
+				// A running save also changes LASTSAVE, so it is good enough
+				return nil
+			}
+			return err
+		}); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to start BGSAVE on %s: %w", pod.Name, err)
+		}
+
+		logger.Info("started snapshot", "pod", pod.Name)
+		backup.Status.Phase = keydbv1.BackupPhaseRunning
+		backup.Status.Message = "Waiting for BGSAVE to complete"
+		backup.Status.SourcePod = pod.Name
+		backup.Status.StartTime = &now
+		backup.Status.PreviousSaveTime = previousSave
+		backup.Status.Location = k8sresources.BackupLocation(backup)
+		if err := r.Status().Update(ctx, backup); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: backupPollInterval}, nil
+	}
+
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: backup.Status.SourcePod, Namespace: backup.Namespace}, pod); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.setFailed(ctx, backup, fmt.Sprintf("source pod %s disappeared", backup.Status.SourcePod))
+		}
+		return ctrl.Result{}, err
+	}
+
+	// Step 2: wait until the snapshot is on disk
+	if backup.Status.SnapshotTime == nil {
+		opts, err := keydbOptions(ctx, r.Client, keydb)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		info, err := podInfo(ctx, r.KeydbClients, keydb, opts, pod)
+		if err != nil {
+			logger.V(1).Info("unable to query source pod", "pod", pod.Name, "error", err.Error())
+			return ctrl.Result{RequeueAfter: backupPollInterval}, nil
+		}
+		lastSave, _ := strconv.ParseInt(info["rdb_last_save_time"], 10, 64)
+		if info["rdb_bgsave_in_progress"] == "1" || lastSave == backup.Status.PreviousSaveTime {
+			return ctrl.Result{RequeueAfter: backupPollInterval}, nil
+		}
+		if status := info["rdb_last_bgsave_status"]; status != "" && status != "ok" {
+			return r.setFailed(ctx, backup, fmt.Sprintf("BGSAVE on %s failed: %s", pod.Name, status))
+		}
+
+		snapshotTime := metav1.NewTime(time.Unix(lastSave, 0))
+		backup.Status.SnapshotTime = &snapshotTime
+		backup.Status.ReplicationOffset, _ = strconv.ParseInt(info["master_repl_offset"], 10, 64)
+		backup.Status.Message = "Uploading snapshot"
+		if err := r.Status().Update(ctx, backup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Step 3: upload it with a Job
+	job := &batchv1.Job{}
+	err := r.Get(ctx, types.NamespacedName{Name: k8sresources.BackupJobName(backup), Namespace: backup.Namespace}, job)
+	if apierrors.IsNotFound(err) {
+		job = k8sresources.GenerateBackupJob(backup, keydb, pod, r.Scheme)
+		if err := r.Create(ctx, job); err != nil {
+			return ctrl.Result{}, err
+		}
+		logger.Info("created backup job", "job", job.Name)
+		return ctrl.Result{}, nil
+	}
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	switch {
+	case jobHasCondition(job, batchv1.JobComplete):
+		result, err := r.backupResult(ctx, job)
+		if err != nil {
+			return r.setFailed(ctx, backup, err.Error())
+		}
+		now := metav1.Now()
+		backup.Status.Phase = keydbv1.BackupPhaseCompleted
+		backup.Status.Message = "Backup uploaded"
+		backup.Status.Size = result.Size
+		backup.Status.Checksum = "sha256:" + result.Sha256
+		backup.Status.CompletionTime = &now
+		if err := r.Status().Update(ctx, backup); err != nil {
+			return ctrl.Result{}, err
+		}
+		r.event(backup, corev1.EventTypeNormal, "BackupCompleted",
+			fmt.Sprintf("Uploaded %d bytes from %s to %s", result.Size, backup.Status.SourcePod, backup.Status.Location))
+	case jobHasCondition(job, batchv1.JobFailed):
+		return r.setFailed(ctx, backup, fmt.Sprintf("backup job %s failed", job.Name))
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// reconcileBackupDeletion deletes the uploaded snapshot with a Job and then
+// releases the finalizer. A failing cleanup keeps the finalizer so the object
+// is not silently lost; removing the finalizer by hand skips the cleanup.
+func (r *KeydbBackupReconciler) reconcileBackupDeletion(ctx context.Context, backup *keydbv1.KeydbBackup) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(backup, backupCleanupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+	logger := log.FromContext(ctx)
+
+	// Only a completed upload left an object behind
+	if backup.Status.Phase == keydbv1.BackupPhaseCompleted && backup.Status.Location != "" {
+		job := &batchv1.Job{}
+		err := r.Get(ctx, types.NamespacedName{Name: k8sresources.BackupCleanupJobName(backup), Namespace: backup.Namespace}, job)
+		if apierrors.IsNotFound(err) {
+			job = k8sresources.GenerateBackupCleanupJob(backup, r.Scheme)
+			if err := r.Create(ctx, job); err != nil {
+				return ctrl.Result{}, err
+			}
+			logger.Info("created backup cleanup job", "job", job.Name, "location", backup.Status.Location)
+			return ctrl.Result{}, nil
+		}
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		switch {
+		case jobHasCondition(job, batchv1.JobComplete):
+			r.event(backup, corev1.EventTypeNormal, "BackupDeleted",
+				fmt.Sprintf("Deleted %s", backup.Status.Location))
+		case jobHasCondition(job, batchv1.JobFailed):
+			message := fmt.Sprintf("Failed to delete %s, see job %s", backup.Status.Location, job.Name)
+			if backup.Status.Message != message {
+				backup.Status.Message = message
+				if err := r.Status().Update(ctx, backup); err != nil {
+					return ctrl.Result{}, err
+				}
+				r.event(backup, corev1.EventTypeWarning, "BackupCleanupFailed", message)
+			}
+			return ctrl.Result{}, nil
+		default:
+			return ctrl.Result{}, nil
+		}
+	}
+
+	controllerutil.RemoveFinalizer(backup, backupCleanupFinalizer)
+	return ctrl.Result{}, r.Update(ctx, backup)
+}
+
+// selectBackupSource returns the pod to snapshot, or nil if none is ready yet.
+func (r *KeydbBackupReconciler) selectBackupSource(ctx context.Context, backup *keydbv1.KeydbBackup, keydb *keydbv1.Keydb) (*corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList,
+		client.InNamespace(keydb.Namespace),
+		client.MatchingLabels{"apps": keydb.Name},
+	); err != nil {
+		return nil, err
+	}
+	sort.Slice(podList.Items, func(i, j int) bool { return podList.Items[i].Name < podList.Items[j].Name })
+
+	primary := k8sresources.PrimaryPodName(keydb)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.DeletionTimestamp != nil || !isPodReady(pod) {
+			continue
+		}
+		switch {
+		case backup.Spec.Pod != "":
+			if pod.Name == backup.Spec.Pod {
+				return pod, nil
+			}
+		case backup.Spec.Source == keydbv1.BackupSourceReplica:
+			if pod.Name != primary {
+				return pod, nil
+			}
+		default:
+			if pod.Name == primary {
+				return pod, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// backupResult reads the size and checksum the upload container reported.
+func (r *KeydbBackupReconciler) backupResult(ctx context.Context, job *batchv1.Job) (*backupResult, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList,
+		client.InNamespace(job.Namespace),
+		client.MatchingLabels{"job-name": job.Name},
+	); err != nil {
+		return nil, err
+	}
+	for _, pod := range podList.Items {
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name != k8sresources.BackupUploadContainer || cs.State.Terminated == nil || cs.State.Terminated.ExitCode != 0 {
+				continue
+			}
+			result := &backupResult{}
+			if err := json.Unmarshal([]byte(cs.State.Terminated.Message), result); err != nil {
+				return nil, fmt.Errorf("unable to parse result of backup job %s: %w", job.Name, err)
+			}
+			return result, nil
+		}
+	}
+	return nil, fmt.Errorf("backup job %s completed without reporting a result", job.Name)
+}
+
+func (r *KeydbBackupReconciler) setPending(ctx context.Context, backup *keydbv1.KeydbBackup, message string) (ctrl.Result, error) {
+	if backup.Status.Phase != keydbv1.BackupPhasePending || backup.Status.Message != message {
+		backup.Status.Phase = keydbv1.BackupPhasePending
+		backup.Status.Message = message
+		if err := r.Status().Update(ctx, backup); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: backupWaitInterval}, nil
+}
+
+func (r *KeydbBackupReconciler) setFailed(ctx context.Context, backup *keydbv1.KeydbBackup, message string) (ctrl.Result, error) {
+	now := metav1.Now()
+	backup.Status.Phase = keydbv1.BackupPhaseFailed
+	backup.Status.Message = message
+	backup.Status.CompletionTime = &now
+	if err := r.Status().Update(ctx, backup); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.event(backup, corev1.EventTypeWarning, "BackupFailed", message)
+	return ctrl.Result{}, nil
+}
+
+func (r *KeydbBackupReconciler) event(backup *keydbv1.KeydbBackup, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(backup, eventType, reason, message)
+	}
+}
+
+func jobHasCondition(job *batchv1.Job, conditionType batchv1.JobConditionType) bool {
+	for _, c := range job.Status.Conditions {
+		if c.Type == conditionType && c.Status == corev1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeydbBackupReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keydbv1.KeydbBackup{}).
+		Owns(&batchv1.Job{}).
+		Named("keydbbackup").
+		Complete(r)
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// maxMissedScheduleWindow bounds how far back missed runs are searched after
+// the operator was down. Only the most recent missed run is ever started.
+const maxMissedScheduleWindow = 7 * 24 * time.Hour
+
+// KeydbBackupScheduleReconciler reconciles a KeydbBackupSchedule object
+type KeydbBackupScheduleReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// Now returns the current time; defaults to time.Now
+	Now func() time.Time
+}
+
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbbackupschedules,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbbackupschedules/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbbackupschedules/finalizers,verbs=update
+
+// Reconcile creates KeydbBackups when they are due, applies retention and
+// reports the outcome of the most recent backup.
+//
+// Backups are not owned by their schedule, so deleting a schedule leaves its
+// backups and their snapshots in place.
+func (r *KeydbBackupScheduleReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	schedule := &keydbv1.KeydbBackupSchedule{}
+	if err := r.Get(ctx, req.NamespacedName, schedule); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !schedule.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, nil
+	}
+	now := time.Now()
+	if r.Now != nil {
+		now = r.Now()
+	}
+
+	sched, err := cron.ParseStandard(schedule.Spec.Schedule)
+	if err != nil {
+		message := fmt.Sprintf("Unable to parse schedule %q: %v", schedule.Spec.Schedule, err)
+		if r.setScheduleCondition(schedule, keydbv1.ConditionTypeReady, metav1.ConditionFalse, keydbv1.ReasonInvalidSchedule, message) {
+			r.event(schedule, corev1.EventTypeWarning, keydbv1.ReasonInvalidSchedule, message)
+		}
+		schedule.Status.NextScheduleTime = nil
+		// Retrying will not fix the expression; the next spec change triggers a reconcile
+		return ctrl.Result{}, r.Status().Update(ctx, schedule)
+	}
+	r.setScheduleCondition(schedule, keydbv1.ConditionTypeReady, metav1.ConditionTrue, keydbv1.ReasonScheduleValid, "Schedule is valid")
+
+	backupList := &keydbv1.KeydbBackupList{}
+	if err := r.List(ctx, backupList,
+		client.InNamespace(schedule.Namespace),
+		client.MatchingLabels{keydbv1.LabelBackupSchedule: schedule.Name},
+	); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	var active []*keydbv1.KeydbBackup
+	var lastFinished, lastSuccessful *keydbv1.KeydbBackup
+	for i := range backupList.Items {
+		b := &backupList.Items[i]
+		if !b.DeletionTimestamp.IsZero() {
+			continue
+		}
+		switch b.Status.Phase {
+		case keydbv1.BackupPhaseCompleted:
+			if lastSuccessful == nil || backupFinishTime(b).After(backupFinishTime(lastSuccessful)) {
+				lastSuccessful = b
+			}
+			fallthrough
+		case keydbv1.BackupPhaseFailed:
+			if lastFinished == nil || backupFinishTime(b).After(backupFinishTime(lastFinished)) {
+				lastFinished = b
+			}
+		default:
+			active = append(active, b)
+		}
+	}
+
+	if lastSuccessful != nil {
+		t := metav1.NewTime(backupFinishTime(lastSuccessful))
+		schedule.Status.LastSuccessfulTime = &t
+	}
+	if lastFinished != nil && lastFinished.Name != schedule.Status.LastBackup {
+		schedule.Status.LastBackup = lastFinished.Name
+		if lastFinished.Status.Phase == keydbv1.BackupPhaseFailed {
+			message := fmt.Sprintf("Backup %s failed: %s", lastFinished.Name, lastFinished.Status.Message)
+			r.setScheduleCondition(schedule, keydbv1.ConditionTypeBackupSucceeded, metav1.ConditionFalse, keydbv1.ReasonBackupFailed, message)
+			r.event(schedule, corev1.EventTypeWarning, keydbv1.ReasonBackupFailed, message)
+		} else {
+			message := fmt.Sprintf("Backup %s completed", lastFinished.Name)
+			r.setScheduleCondition(schedule, keydbv1.ConditionTypeBackupSucceeded, metav1.ConditionTrue, keydbv1.ReasonBackupCompleted, message)
+		}
+	}
+
+	// Retention
+	for _, b := range backupsToPrune(backupList.Items, schedule.Spec, now) {
+		if err := r.Delete(ctx, b); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		logger.Info("pruned backup", "backup", b.Name, "phase", b.Status.Phase)
+		r.event(schedule, corev1.EventTypeNormal, "BackupPruned", fmt.Sprintf("Deleted backup %s", b.Name))
+	}
+
+	missed, next := scheduleTimes(schedule, sched, now)
+	nextTime := metav1.NewTime(next)
+	schedule.Status.NextScheduleTime = &nextTime
+
+	policy := schedule.Spec.ConcurrencyPolicy
+	if policy == "" {
+		policy = keydbv1.ConcurrencyPolicyForbid
+	}
+	if !schedule.Spec.Suspend && !missed.IsZero() {
+		lastSchedule := metav1.NewTime(missed)
+		switch {
+		case len(active) > 0 && policy == keydbv1.ConcurrencyPolicyForbid:
+			r.event(schedule, corev1.EventTypeWarning, "BackupSkipped",
+				fmt.Sprintf("Skipped backup due at %s, %s is still running", missed.UTC().Format(time.RFC3339), active[0].Name))
+			schedule.Status.LastScheduleTime = &lastSchedule
+		default:
+			if policy == keydbv1.ConcurrencyPolicyReplace {
+				for _, b := range active {
+					if err := r.Delete(ctx, b); client.IgnoreNotFound(err) != nil {
+						return ctrl.Result{}, err
+					}
+					r.event(schedule, corev1.EventTypeNormal, "BackupReplaced", fmt.Sprintf("Deleted running backup %s", b.Name))
+				}
+				active = nil
+			}
+
+			backup := newScheduledBackup(schedule, missed)
+			if err := r.Create(ctx, backup); err != nil && !apierrors.IsAlreadyExists(err) {
+				r.event(schedule, corev1.EventTypeWarning, "BackupCreateFailed", err.Error())
+				return ctrl.Result{}, err
+			}
+			logger.Info("created scheduled backup", "backup", backup.Name, "scheduledTime", missed)
+			r.event(schedule, corev1.EventTypeNormal, "BackupCreated", fmt.Sprintf("Created backup %s", backup.Name))
+			schedule.Status.LastScheduleTime = &lastSchedule
+			active = append(active, backup)
+		}
+	}
+
+	schedule.Status.Active = nil
+	for _, b := range active {
+		schedule.Status.Active = append(schedule.Status.Active, b.Name)
+	}
+	if err := r.Status().Update(ctx, schedule); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: next.Sub(now)}, nil
+}
+
+// scheduleTimes returns the most recent run that is due but not yet started
+// (zero if none) and the next run after now.
+func scheduleTimes(schedule *keydbv1.KeydbBackupSchedule, sched cron.Schedule, now time.Time) (time.Time, time.Time) {
+	earliest := schedule.CreationTimestamp.Time
+	if schedule.Status.LastScheduleTime != nil {
+		earliest = schedule.Status.LastScheduleTime.Time
+	}
+	if limit := now.Add(-maxMissedScheduleWindow); earliest.Before(limit) {
+		earliest = limit
+	}
+
+	var missed time.Time
+	for t := sched.Next(earliest); !t.After(now); t = sched.Next(t) {
+		missed = t
+	}
+	return missed, sched.Next(now)
+}
+
+// newScheduledBackup returns the KeydbBackup for a scheduled run. The name is
+// derived from the scheduled time so a retried reconcile does not create a
+// second backup for the same run.
+func newScheduledBackup(schedule *keydbv1.KeydbBackupSchedule, scheduledTime time.Time) *keydbv1.KeydbBackup {
+	spec := *schedule.Spec.BackupTemplate.DeepCopy()
+	if spec.DeletionPolicy == "" {
+		spec.DeletionPolicy = keydbv1.BackupDeletionPolicyDelete
+	}
+	return &keydbv1.KeydbBackup{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      fmt.Sprintf("%s-%d", schedule.Name, scheduledTime.Unix()),
+			Namespace: schedule.Namespace,
+			Labels:    map[string]string{keydbv1.LabelBackupSchedule: schedule.Name},
+		},
+		Spec: spec,
+	}
+}
+
+// setScheduleCondition sets a condition and reports whether its status or reason changed.
+func (r *KeydbBackupScheduleReconciler) setScheduleCondition(schedule *keydbv1.KeydbBackupSchedule, conditionType string, status metav1.ConditionStatus, reason, message string) bool {
+	existing := meta.FindStatusCondition(schedule.Status.Conditions, conditionType)
+	changed := existing == nil || existing.Status != status || existing.Reason != reason
+	meta.SetStatusCondition(&schedule.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: schedule.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	return changed
+}
+
+func (r *KeydbBackupScheduleReconciler) event(schedule *keydbv1.KeydbBackupSchedule, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(schedule, eventType, reason, message)
+	}
+}
+
+// backupToSchedule maps a KeydbBackup created by a schedule back to it, so
+// status and retention follow backups as they finish.
+func backupToSchedule(_ context.Context, obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[keydbv1.LabelBackupSchedule]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()},
+	}}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeydbBackupScheduleReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keydbv1.KeydbBackupSchedule{}).
+		Watches(&keydbv1.KeydbBackup{}, handler.EnqueueRequestsFromMapFunc(backupToSchedule)).
+		Named("keydbbackupschedule").
+		Complete(r)
+}
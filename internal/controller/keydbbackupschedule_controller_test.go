@@ -0,0 +1,113 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+var _ = Describe("KeydbBackupSchedule Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-schedule"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		keydbbackupschedule := &keydbv1.KeydbBackupSchedule{}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind KeydbBackupSchedule")
+			err := k8sClient.Get(ctx, typeNamespacedName, keydbbackupschedule)
+			if err != nil && errors.IsNotFound(err) {
+				resource := &keydbv1.KeydbBackupSchedule{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resourceName,
+						Namespace: "default",
+					},
+					Spec: keydbv1.KeydbBackupScheduleSpec{
+						Schedule: "*/5 * * * *",
+						BackupTemplate: keydbv1.KeydbBackupSpec{
+							KeydbName: "keydb",
+							Storage: keydbv1.BackupStorageSpec{
+								S3: keydbv1.S3StorageSpec{Bucket: "backups"},
+							},
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			resource := &keydbv1.KeydbBackupSchedule{}
+			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Cleanup the specific resource instance KeydbBackupSchedule")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			Expect(k8sClient.DeleteAllOf(ctx, &keydbv1.KeydbBackup{},
+				client.InNamespace("default"),
+				client.MatchingLabels{keydbv1.LabelBackupSchedule: resourceName},
+			)).To(Succeed())
+		})
+		It("should create a backup once a run is due", func() {
+			By("Reconciling ten minutes after creation")
+			Expect(k8sClient.Get(ctx, typeNamespacedName, keydbbackupschedule)).To(Succeed())
+			controllerReconciler := &KeydbBackupScheduleReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+				Now: func() time.Time {
+					return keydbbackupschedule.CreationTimestamp.Add(10 * time.Minute)
+				},
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			backups := &keydbv1.KeydbBackupList{}
+			Expect(k8sClient.List(ctx, backups,
+				client.InNamespace("default"),
+				client.MatchingLabels{keydbv1.LabelBackupSchedule: resourceName},
+			)).To(Succeed())
+			Expect(backups.Items).To(HaveLen(1))
+			Expect(backups.Items[0].Spec.DeletionPolicy).To(Equal(keydbv1.BackupDeletionPolicyDelete))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, keydbbackupschedule)).To(Succeed())
+			Expect(keydbbackupschedule.Status.Active).To(ConsistOf(backups.Items[0].Name))
+			Expect(meta.IsStatusConditionTrue(keydbbackupschedule.Status.Conditions, keydbv1.ConditionTypeReady)).To(BeTrue())
+		})
+	})
+})
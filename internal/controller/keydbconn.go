@@ -0,0 +1,143 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// keydbPort is the port KeyDB listens on inside every pod.
+const keydbPort = 6379
+
+// clusterKey identifies a Keydb in the connection pool.
+func clusterKey(keydb *keydbv1.Keydb) string {
+	return keydb.Namespace + "/" + keydb.Name
+}
+
+// keydbOptions returns the connection options for a Keydb, without an address.
+func keydbOptions(ctx context.Context, c client.Reader, keydb *keydbv1.Keydb) (keydbclient.Options, error) {
+	password, err := k8sresources.ResolvePassword(ctx, c, keydb)
+	if err != nil {
+		return keydbclient.Options{}, fmt.Errorf("failed to resolve password: %w", err)
+	}
+	tlsConfig, err := clusterTLSConfig(ctx, c, keydb)
+	if err != nil {
+		return keydbclient.Options{}, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+	return keydbclient.Options{Password: password, TLSConfig: tlsConfig}, nil
+}
+
+// cachedTLSConfig is the client TLS config built from one version of a Secret.
+type cachedTLSConfig struct {
+	secretVersion string
+	config        *tls.Config
+}
+
+// tlsConfigs caches client TLS configs per cluster. The pool drops idle
+// connections when the config pointer changes, so a config is only rebuilt
+// when its Secret changes.
+var tlsConfigs = struct {
+	sync.Mutex
+	byCluster map[string]cachedTLSConfig
+}{byCluster: map[string]cachedTLSConfig{}}
+
+// forgetTLSConfig drops the cached TLS config of a deleted cluster.
+func forgetTLSConfig(keydb *keydbv1.Keydb) {
+	tlsConfigs.Lock()
+	defer tlsConfigs.Unlock()
+	delete(tlsConfigs.byCluster, clusterKey(keydb))
+}
+
+// clusterTLSConfig returns the TLS config for connecting to a Keydb's pods,
+// or nil when TLS is disabled. Pods are dialed by IP, which the certificate
+// does not cover, so the chain is verified against the cluster CA without
+// checking the host name. The serving certificate doubles as the client
+// certificate for tls-auth-clients.
+func clusterTLSConfig(ctx context.Context, c client.Reader, keydb *keydbv1.Keydb) (*tls.Config, error) {
+	secretName := k8sresources.TLSSecretName(keydb)
+	if secretName == "" {
+		return nil, nil
+	}
+	secret := &corev1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{Name: secretName, Namespace: keydb.Namespace}, secret); err != nil {
+		return nil, err
+	}
+
+	key := clusterKey(keydb)
+	tlsConfigs.Lock()
+	defer tlsConfigs.Unlock()
+	if cached, ok := tlsConfigs.byCluster[key]; ok && cached.secretVersion == secret.ResourceVersion {
+		return cached.config, nil
+	}
+
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(secret.Data["ca.crt"]) {
+		return nil, fmt.Errorf("secret %s has no valid ca.crt", secretName)
+	}
+	cert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+	if err != nil {
+		return nil, fmt.Errorf("secret %s: %w", secretName, err)
+	}
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		// Verification happens in VerifyConnection, without the host name
+		InsecureSkipVerify: true, //nolint:gosec
+		VerifyConnection:   keydbclient.VerifyChain(roots),
+	}
+	tlsConfigs.byCluster[key] = cachedTLSConfig{secretVersion: secret.ResourceVersion, config: config}
+	return config, nil
+}
+
+// podAddr returns the address KeyDB listens on in a pod. Tests point it at
+// fake servers.
+var podAddr = func(pod *corev1.Pod) string {
+	return net.JoinHostPort(pod.Status.PodIP, strconv.Itoa(keydbPort))
+}
+
+// withPod runs fn on a pooled connection to a pod of keydb.
+func withPod(ctx context.Context, pool *keydbclient.Pool, keydb *keydbv1.Keydb, opts keydbclient.Options, pod *corev1.Pod, fn func(*keydbclient.Client) error) error {
+	if pod.Status.PodIP == "" {
+		return fmt.Errorf("pod %s has no IP", pod.Name)
+	}
+	opts.Addr = podAddr(pod)
+	return pool.Do(ctx, clusterKey(keydb), opts, fn)
+}
+
+// podInfo runs INFO on a pod and returns the parsed fields.
+func podInfo(ctx context.Context, pool *keydbclient.Pool, keydb *keydbv1.Keydb, opts keydbclient.Options, pod *corev1.Pod, sections ...string) (map[string]string, error) {
+	var info map[string]string
+	err := withPod(ctx, pool, keydb, opts, pod, func(c *keydbclient.Client) error {
+		var err error
+		info, err = c.Info(ctx, sections...)
+		return err
+	})
+	return info, err
+}
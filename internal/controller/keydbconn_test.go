@@ -0,0 +1,169 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+// testCertificate returns a PEM CA and a leaf certificate and key signed by it.
+func testCertificate() (caPEM, certPEM, keyPEM []byte) {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "keydb-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	Expect(err).NotTo(HaveOccurred())
+	ca, err := x509.ParseCertificate(caDER)
+	Expect(err).NotTo(HaveOccurred())
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).NotTo(HaveOccurred())
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "keydb-svc.default.svc"},
+		DNSNames:     []string{"keydb-svc.default.svc"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	Expect(err).NotTo(HaveOccurred())
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).NotTo(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+var _ = Describe("Cluster TLS config", func() {
+	var (
+		ctx    context.Context
+		keydb  *keydbv1.Keydb
+		secret *corev1.Secret
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		keydb = &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "tls-keydb", Namespace: "default"},
+			Spec:       keydbv1.KeydbSpec{TLS: &keydbv1.TLSSpec{SecretName: "keydb-tls"}},
+		}
+		caPEM, certPEM, keyPEM := testCertificate()
+		secret = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb-tls", Namespace: "default"},
+			Type:       corev1.SecretTypeTLS,
+			Data: map[string][]byte{
+				"ca.crt":                caPEM,
+				corev1.TLSCertKey:       certPEM,
+				corev1.TLSPrivateKeyKey: keyPEM,
+			},
+		}
+		DeferCleanup(forgetTLSConfig, keydb)
+	})
+
+	It("should return nil when TLS is disabled", func() {
+		keydb.Spec.TLS = nil
+		config, err := clusterTLSConfig(ctx, fake.NewClientBuilder().Build(), keydb)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(config).To(BeNil())
+	})
+
+	It("should reuse the config until the Secret changes", func() {
+		c := fake.NewClientBuilder().WithObjects(secret).Build()
+		first, err := clusterTLSConfig(ctx, c, keydb)
+		Expect(err).NotTo(HaveOccurred())
+		second, err := clusterTLSConfig(ctx, c, keydb)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(second).To(BeIdenticalTo(first))
+
+		secret.Labels = map[string]string{"renewed": "true"}
+		Expect(c.Update(ctx, secret)).To(Succeed())
+		third, err := clusterTLSConfig(ctx, c, keydb)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(third).NotTo(BeIdenticalTo(first))
+	})
+
+	It("should trust the cluster CA when dialing a pod by IP", func() {
+		c := fake.NewClientBuilder().WithObjects(secret).Build()
+		config, err := clusterTLSConfig(ctx, c, keydb)
+		Expect(err).NotTo(HaveOccurred())
+
+		serverCert, err := tls.X509KeyPair(secret.Data[corev1.TLSCertKey], secret.Data[corev1.TLSPrivateKeyKey])
+		Expect(err).NotTo(HaveOccurred())
+		listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{serverCert}})
+		Expect(err).NotTo(HaveOccurred())
+		defer func() { _ = listener.Close() }()
+		go func() {
+			defer GinkgoRecover()
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}()
+
+		conn, err := tls.Dial("tcp", listener.Addr().String(), config)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(conn.Close()).To(Succeed())
+
+		By("rejecting a certificate from another CA")
+		otherCA, _, _ := testCertificate()
+		secret.Data["ca.crt"] = otherCA
+		Expect(c.Update(ctx, secret)).To(Succeed())
+		config, err = clusterTLSConfig(ctx, c, keydb)
+		Expect(err).NotTo(HaveOccurred())
+		go func() {
+			defer GinkgoRecover()
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}()
+		_, err = tls.Dial("tcp", listener.Addr().String(), config)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -0,0 +1,470 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// restoreLockFinalizer releases the Keydb when a KeydbRestore is deleted
+// before it finished.
+const restoreLockFinalizer = "keydb.keydb/restore-lock"
+
+const (
+	// restorePollInterval is how often the progress of a restore is checked
+	restorePollInterval = 5 * time.Second
+	// restorePauseTimeout bounds how long writes stay paused on the primary
+	// if the operator goes away mid-restore
+	restorePauseTimeout = 30 * time.Minute
+)
+
+// KeydbRestoreReconciler reconciles a KeydbRestore object
+type KeydbRestoreReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// KeydbClients talks to KeyDB pods; restores stay Pending when nil
+	KeydbClients *keydbclient.Pool
+}
+
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbrestores,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbrestores/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbrestores/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=pods,verbs=delete
+
+// Reconcile replaces the data of a running Keydb:
+//
+//   - Pending: resolve the source and take the Keydb, which drains its pods
+//     from the Services and blocks spec changes
+//   - Draining: pause writes on the primary and stop it from persisting
+//   - Restoring: download the snapshot into the primary's volume with a Job
+//     and restart the primary so it loads it
+//   - Resyncing: re-enable AOF and make every replica do a full sync
+//
+// The Keydb is released when the restore completes or fails.
+func (r *KeydbRestoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	restore := &keydbv1.KeydbRestore{}
+	if err := r.Get(ctx, req.NamespacedName, restore); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !restore.DeletionTimestamp.IsZero() {
+		if !controllerutil.ContainsFinalizer(restore, restoreLockFinalizer) {
+			return ctrl.Result{}, nil
+		}
+		keydb := &keydbv1.Keydb{}
+		err := r.Get(ctx, types.NamespacedName{Name: restore.Spec.KeydbName, Namespace: restore.Namespace}, keydb)
+		if client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+		if err == nil {
+			r.resumeWrites(ctx, keydb, restore)
+			if err := r.releaseKeydb(ctx, keydb, restore); err != nil {
+				return ctrl.Result{}, err
+			}
+		}
+		controllerutil.RemoveFinalizer(restore, restoreLockFinalizer)
+		return ctrl.Result{}, r.Update(ctx, restore)
+	}
+
+	if restore.Status.Phase == keydbv1.RestorePhaseCompleted || restore.Status.Phase == keydbv1.RestorePhaseFailed {
+		return ctrl.Result{}, nil
+	}
+	if !controllerutil.ContainsFinalizer(restore, restoreLockFinalizer) {
+		controllerutil.AddFinalizer(restore, restoreLockFinalizer)
+		if err := r.Update(ctx, restore); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	keydb := &keydbv1.Keydb{}
+	if err := r.Get(ctx, types.NamespacedName{Name: restore.Spec.KeydbName, Namespace: restore.Namespace}, keydb); err != nil {
+		if apierrors.IsNotFound(err) {
+			if restore.Status.Phase != "" && restore.Status.Phase != keydbv1.RestorePhasePending {
+				return r.setFailed(ctx, nil, restore, fmt.Sprintf("Keydb %s was deleted", restore.Spec.KeydbName))
+			}
+			return r.setPending(ctx, restore, fmt.Sprintf("Keydb %s not found", restore.Spec.KeydbName))
+		}
+		return ctrl.Result{}, err
+	}
+	if r.KeydbClients == nil {
+		return r.setPending(ctx, restore, "operator has no KeyDB client configured")
+	}
+
+	switch restore.Status.Phase {
+	case "", keydbv1.RestorePhasePending:
+		return r.startRestore(ctx, keydb, restore)
+	case keydbv1.RestorePhaseDraining:
+		return r.drain(ctx, keydb, restore)
+	case keydbv1.RestorePhaseRestoring:
+		return r.restoreData(ctx, keydb, restore)
+	case keydbv1.RestorePhaseResyncing:
+		return r.resync(ctx, keydb, restore)
+	}
+	return ctrl.Result{}, nil
+}
+
+// startRestore checks the restore can run, resolves its source and takes the Keydb.
+func (r *KeydbRestoreReconciler) startRestore(ctx context.Context, keydb *keydbv1.Keydb, restore *keydbv1.KeydbRestore) (ctrl.Result, error) {
+	if (restore.Spec.BackupName == "") == (restore.Spec.S3 == nil) {
+		return r.setFailed(ctx, keydb, restore, "exactly one of backupName or s3 must be set")
+	}
+	if !keydb.Spec.Persistence.Enabled {
+		return r.setFailed(ctx, keydb, restore, "in-place restore requires persistence")
+	}
+	replicas := int32(1)
+	if keydb.Spec.Replicas != nil {
+		replicas = *keydb.Spec.Replicas
+	}
+	if keydb.Spec.Replication.Mode != keydbv1.ReplicationModeMasterReplica && replicas > 1 {
+		// Active replicas would merge the old data back into the restored pod
+		return r.setFailed(ctx, keydb, restore, "in-place restore requires master-replica mode or a single pod")
+	}
+
+	s3 := restore.Spec.S3
+	source := ""
+	if restore.Spec.BackupName != "" {
+		backup := &keydbv1.KeydbBackup{}
+		err := r.Get(ctx, types.NamespacedName{Name: restore.Spec.BackupName, Namespace: restore.Namespace}, backup)
+		switch {
+		case apierrors.IsNotFound(err):
+			return r.setPending(ctx, restore, fmt.Sprintf("Waiting for KeydbBackup %s", restore.Spec.BackupName))
+		case err != nil:
+			return ctrl.Result{}, err
+		case backup.Status.Phase == keydbv1.BackupPhaseFailed:
+			return r.setFailed(ctx, keydb, restore, fmt.Sprintf("KeydbBackup %s failed: %s", backup.Name, backup.Status.Message))
+		case backup.Status.Phase != keydbv1.BackupPhaseCompleted:
+			return r.setPending(ctx, restore, fmt.Sprintf("Waiting for KeydbBackup %s to complete", backup.Name))
+		}
+		s3 = k8sresources.BackupS3Source(backup)
+		source = "backup/" + backup.Name
+	} else {
+		source = k8sresources.RestoreS3URL(s3)
+	}
+
+	if holder := keydb.Annotations[keydbv1.AnnotationRestore]; holder != "" && holder != restore.Name {
+		return r.setPending(ctx, restore, fmt.Sprintf("Waiting for KeydbRestore %s to finish", holder))
+	}
+	primary := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: k8sresources.PrimaryPodName(keydb), Namespace: keydb.Namespace}, primary); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.setPending(ctx, restore, "Waiting for the primary pod")
+		}
+		return ctrl.Result{}, err
+	}
+	if !isPodReady(primary) {
+		return r.setPending(ctx, restore, fmt.Sprintf("Waiting for primary %s to be ready", primary.Name))
+	}
+
+	if keydb.Annotations[keydbv1.AnnotationRestore] != restore.Name {
+		patch := client.MergeFrom(keydb.DeepCopy())
+		if keydb.Annotations == nil {
+			keydb.Annotations = map[string]string{}
+		}
+		keydb.Annotations[keydbv1.AnnotationRestore] = restore.Name
+		if err := r.Patch(ctx, keydb, patch); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	now := metav1.Now()
+	restore.Status.Phase = keydbv1.RestorePhaseDraining
+	restore.Status.Message = "Taking pods out of the Services"
+	restore.Status.Source = source
+	restore.Status.S3 = s3
+	restore.Status.Pod = primary.Name
+	restore.Status.StartTime = &now
+	if err := r.Status().Update(ctx, restore); err != nil {
+		return ctrl.Result{}, err
+	}
+	log.FromContext(ctx).Info("started in-place restore", "keydb", keydb.Name, "source", source, "pod", primary.Name)
+	r.event(restore, corev1.EventTypeNormal, "RestoreStarted", fmt.Sprintf("Restoring %s into %s", source, keydb.Name))
+	return ctrl.Result{RequeueAfter: restorePollInterval}, nil
+}
+
+// drain waits for the Keydb controller to take every pod out of the Services,
+// then stops writes and persistence on the primary and starts the restore Job.
+func (r *KeydbRestoreReconciler) drain(ctx context.Context, keydb *keydbv1.Keydb, restore *keydbv1.KeydbRestore) (ctrl.Result, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList,
+		client.InNamespace(keydb.Namespace),
+		client.MatchingLabels{"apps": keydb.Name},
+	); err != nil {
+		return ctrl.Result{}, err
+	}
+	var primary *corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Labels[keydbv1.LabelTraffic] == keydbv1.TrafficEnabled {
+			return ctrl.Result{RequeueAfter: restorePollInterval}, nil
+		}
+		if pod.Name == restore.Status.Pod {
+			primary = pod
+		}
+	}
+	if primary == nil {
+		return r.setFailed(ctx, keydb, restore, fmt.Sprintf("primary %s disappeared", restore.Status.Pod))
+	}
+
+	opts, err := keydbOptions(ctx, r.Client, keydb)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	// Writes are paused first so the AOF is complete if the restore has to be
+	// rolled back. With persistence off, shutting the pod down later does not
+	// overwrite the restored dump.rdb.
+	if err := withPod(ctx, r.KeydbClients, keydb, opts, primary, func(c *keydbclient.Client) error {
+		if err := c.ClientPause(ctx, restorePauseTimeout, true); err != nil {
+			return err
+		}
+		if err := c.ConfigSet(ctx, "save", ""); err != nil {
+			return err
+		}
+		return c.ConfigSet(ctx, "appendonly", "no")
+	}); err != nil {
+		return ctrl.Result{}, fmt.Errorf("failed to stop writes on %s: %w", primary.Name, err)
+	}
+
+	job := k8sresources.GenerateRestoreJob(restore, keydb, primary, restore.Status.S3, r.Scheme)
+	if err := r.Create(ctx, job); err != nil && !apierrors.IsAlreadyExists(err) {
+		return ctrl.Result{}, err
+	}
+	restore.Status.Phase = keydbv1.RestorePhaseRestoring
+	restore.Status.Message = fmt.Sprintf("Downloading the snapshot into %s", primary.Name)
+	return ctrl.Result{}, r.Status().Update(ctx, restore)
+}
+
+// restoreData waits for the restore Job and restarts the primary. The primary
+// is restarted on failure too, so it reloads its own configuration and AOF.
+func (r *KeydbRestoreReconciler) restoreData(ctx context.Context, keydb *keydbv1.Keydb, restore *keydbv1.KeydbRestore) (ctrl.Result, error) {
+	job := &batchv1.Job{}
+	if err := r.Get(ctx, types.NamespacedName{Name: k8sresources.RestoreJobName(restore), Namespace: restore.Namespace}, job); err != nil {
+		return ctrl.Result{}, err
+	}
+	failed := jobHasCondition(job, batchv1.JobFailed)
+	if !failed && !jobHasCondition(job, batchv1.JobComplete) {
+		return ctrl.Result{}, nil
+	}
+
+	primary := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: restore.Status.Pod, Namespace: restore.Namespace}, primary); client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	if primary.UID != "" {
+		if err := r.Delete(ctx, primary); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if failed {
+		return r.setFailed(ctx, keydb, restore, fmt.Sprintf("restore job %s failed, %s was restarted with its previous data", job.Name, restore.Status.Pod))
+	}
+	restore.Status.Phase = keydbv1.RestorePhaseResyncing
+	restore.Status.Message = fmt.Sprintf("Waiting for %s to load the snapshot", restore.Status.Pod)
+	restore.Status.PodUID = string(primary.UID)
+	if err := r.Status().Update(ctx, restore); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: restorePollInterval}, nil
+}
+
+// resync waits for the restarted primary, turns AOF back on and makes every
+// replica drop its data and do a full sync, then returns the Keydb to service.
+func (r *KeydbRestoreReconciler) resync(ctx context.Context, keydb *keydbv1.Keydb, restore *keydbv1.KeydbRestore) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	poll := ctrl.Result{RequeueAfter: restorePollInterval}
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList,
+		client.InNamespace(keydb.Namespace),
+		client.MatchingLabels{"apps": keydb.Name},
+	); err != nil {
+		return ctrl.Result{}, err
+	}
+	var primary *corev1.Pod
+	var replicas []*corev1.Pod
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		switch {
+		case pod.Name == restore.Status.Pod:
+			primary = pod
+		case pod.DeletionTimestamp == nil && pod.Status.Phase == corev1.PodRunning:
+			replicas = append(replicas, pod)
+		}
+	}
+	if primary == nil || string(primary.UID) == restore.Status.PodUID || !isPodReady(primary) {
+		return poll, nil
+	}
+
+	opts, err := keydbOptions(ctx, r.Client, keydb)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	info, err := podInfo(ctx, r.KeydbClients, keydb, opts, primary)
+	if err != nil || info["loading"] == "1" {
+		return poll, nil
+	}
+	if info["aof_enabled"] == "0" {
+		if err := withPod(ctx, r.KeydbClients, keydb, opts, primary, func(c *keydbclient.Client) error {
+			return c.ConfigSet(ctx, "appendonly", "yes")
+		}); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to re-enable appendonly on %s: %w", primary.Name, err)
+		}
+	}
+
+	primaryHost := k8sresources.PodFQDN(keydb, primary.Name)
+	for _, pod := range replicas {
+		if slices.Contains(restore.Status.ResyncedReplicas, pod.Name) {
+			continue
+		}
+		// Detaching gives the replica a new replication ID, so reattaching
+		// cannot continue the old stream and always does a full sync
+		if err := withPod(ctx, r.KeydbClients, keydb, opts, pod, func(c *keydbclient.Client) error {
+			if err := c.ReplicaOfNoOne(ctx); err != nil {
+				return err
+			}
+			return c.ReplicaOf(ctx, primaryHost, keydbPort)
+		}); err != nil {
+			logger.V(1).Info("unable to resync replica", "pod", pod.Name, "error", err.Error())
+			return poll, nil
+		}
+		restore.Status.ResyncedReplicas = append(restore.Status.ResyncedReplicas, pod.Name)
+		restore.Status.Message = "Waiting for replicas to resync"
+		if err := r.Status().Update(ctx, restore); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	for _, pod := range replicas {
+		info, err := podInfo(ctx, r.KeydbClients, keydb, opts, pod)
+		if err != nil || info["master_link_status"] != "up" || info["master_sync_in_progress"] == "1" {
+			return poll, nil
+		}
+	}
+
+	if err := r.releaseKeydb(ctx, keydb, restore); err != nil {
+		return ctrl.Result{}, err
+	}
+	now := metav1.Now()
+	restore.Status.Phase = keydbv1.RestorePhaseCompleted
+	restore.Status.Message = "Keydb is back in service"
+	restore.Status.CompletionTime = &now
+	if err := r.Status().Update(ctx, restore); err != nil {
+		return ctrl.Result{}, err
+	}
+	logger.Info("completed in-place restore", "keydb", keydb.Name, "source", restore.Status.Source)
+	r.event(restore, corev1.EventTypeNormal, "RestoreCompleted",
+		fmt.Sprintf("Restored %s into %s and resynced %d replicas", restore.Status.Source, keydb.Name, len(restore.Status.ResyncedReplicas)))
+	return ctrl.Result{}, nil
+}
+
+// resumeWrites undoes drain on the primary of a restore that was deleted
+// before the primary was restarted. Save points come back with the next
+// restart. Errors are ignored; the pause times out on its own.
+func (r *KeydbRestoreReconciler) resumeWrites(ctx context.Context, keydb *keydbv1.Keydb, restore *keydbv1.KeydbRestore) {
+	if r.KeydbClients == nil {
+		return
+	}
+	if restore.Status.Phase != keydbv1.RestorePhaseDraining && restore.Status.Phase != keydbv1.RestorePhaseRestoring {
+		return
+	}
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: restore.Status.Pod, Namespace: restore.Namespace}, pod); err != nil {
+		return
+	}
+	opts, err := keydbOptions(ctx, r.Client, keydb)
+	if err != nil {
+		return
+	}
+	_ = withPod(ctx, r.KeydbClients, keydb, opts, pod, func(c *keydbclient.Client) error {
+		if err := c.ConfigSet(ctx, "appendonly", "yes"); err != nil {
+			return err
+		}
+		return c.ClientUnpause(ctx)
+	})
+}
+
+// releaseKeydb removes the restore annotation if this restore holds it.
+func (r *KeydbRestoreReconciler) releaseKeydb(ctx context.Context, keydb *keydbv1.Keydb, restore *keydbv1.KeydbRestore) error {
+	if keydb.Annotations[keydbv1.AnnotationRestore] != restore.Name {
+		return nil
+	}
+	patch := client.MergeFrom(keydb.DeepCopy())
+	delete(keydb.Annotations, keydbv1.AnnotationRestore)
+	return r.Patch(ctx, keydb, patch)
+}
+
+func (r *KeydbRestoreReconciler) setPending(ctx context.Context, restore *keydbv1.KeydbRestore, message string) (ctrl.Result, error) {
+	if restore.Status.Phase != keydbv1.RestorePhasePending || restore.Status.Message != message {
+		restore.Status.Phase = keydbv1.RestorePhasePending
+		restore.Status.Message = message
+		if err := r.Status().Update(ctx, restore); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	return ctrl.Result{RequeueAfter: backupWaitInterval}, nil
+}
+
+// setFailed marks the restore as failed and releases keydb if it is set.
+func (r *KeydbRestoreReconciler) setFailed(ctx context.Context, keydb *keydbv1.Keydb, restore *keydbv1.KeydbRestore, message string) (ctrl.Result, error) {
+	if keydb != nil {
+		if err := r.releaseKeydb(ctx, keydb, restore); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	now := metav1.Now()
+	restore.Status.Phase = keydbv1.RestorePhaseFailed
+	restore.Status.Message = message
+	restore.Status.CompletionTime = &now
+	if err := r.Status().Update(ctx, restore); err != nil {
+		return ctrl.Result{}, err
+	}
+	r.event(restore, corev1.EventTypeWarning, "RestoreFailed", message)
+	return ctrl.Result{}, nil
+}
+
+func (r *KeydbRestoreReconciler) event(restore *keydbv1.KeydbRestore, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(restore, eventType, reason, message)
+	}
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeydbRestoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keydbv1.KeydbRestore{}).
+		Owns(&batchv1.Job{}).
+		Named("keydbrestore").
+		Complete(r)
+}
@@ -0,0 +1,95 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+var _ = Describe("KeydbRestore Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-restore"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		keydbrestore := &keydbv1.KeydbRestore{}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind KeydbRestore")
+			err := k8sClient.Get(ctx, typeNamespacedName, keydbrestore)
+			if err != nil && errors.IsNotFound(err) {
+				resource := &keydbv1.KeydbRestore{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resourceName,
+						Namespace: "default",
+					},
+					Spec: keydbv1.KeydbRestoreSpec{
+						KeydbName:  "missing-keydb",
+						BackupName: "nightly",
+					},
+				}
+				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			resource := &keydbv1.KeydbRestore{}
+			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Cleanup the specific resource instance KeydbRestore")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			controllerReconciler := &KeydbRestoreReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+		})
+		It("should wait for the Keydb to exist", func() {
+			By("Reconciling the created resource")
+			controllerReconciler := &KeydbRestoreReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, keydbrestore)).To(Succeed())
+			Expect(keydbrestore.Status.Phase).To(Equal(keydbv1.RestorePhasePending))
+			Expect(keydbrestore.Status.Message).To(ContainSubstring("missing-keydb"))
+		})
+	})
+})
@@ -0,0 +1,330 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// userCleanupFinalizer removes the ACL user from the pods when a KeydbUser is deleted.
+const userCleanupFinalizer = "keydb.keydb/user-cleanup"
+
+// userResyncInterval re-applies users as a safety net; pod restarts and
+// Secret changes are watched and applied right away.
+const userResyncInterval = 5 * time.Minute
+
+// KeydbUserReconciler reconciles a KeydbUser object
+type KeydbUserReconciler struct {
+	client.Client
+	Scheme   *runtime.Scheme
+	Recorder record.EventRecorder
+	// KeydbClients talks to KeyDB pods; users stay Pending when nil
+	KeydbClients *keydbclient.Pool
+}
+
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbusers,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbusers/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=keydb.keydb,resources=keydbusers/finalizers,verbs=update
+
+// Reconcile applies the user with ACL SETUSER on every running pod of its
+// Keydb. ACL users live in memory only, so a restarted pod gets them back
+// when the pod watch fires.
+func (r *KeydbUserReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	user := &keydbv1.KeydbUser{}
+	if err := r.Get(ctx, req.NamespacedName, user); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	if !user.DeletionTimestamp.IsZero() {
+		return r.reconcileUserDeletion(ctx, user)
+	}
+	if !controllerutil.ContainsFinalizer(user, userCleanupFinalizer) {
+		controllerutil.AddFinalizer(user, userCleanupFinalizer)
+		if err := r.Update(ctx, user); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	keydb := &keydbv1.Keydb{}
+	if err := r.Get(ctx, types.NamespacedName{Name: user.Spec.KeydbName, Namespace: user.Namespace}, keydb); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.setUserStatus(ctx, user, keydbv1.UserPhasePending, fmt.Sprintf("Keydb %s not found", user.Spec.KeydbName), nil)
+		}
+		return ctrl.Result{}, err
+	}
+	if r.KeydbClients == nil {
+		return r.setUserStatus(ctx, user, keydbv1.UserPhasePending, "operator has no KeyDB client configured", nil)
+	}
+
+	owner, err := r.usernameOwner(ctx, user)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if owner != user.Name {
+		return r.setUserStatus(ctx, user, keydbv1.UserPhaseFailed,
+			fmt.Sprintf("user %s on Keydb %s is already managed by KeydbUser %s", user.Spec.Username, keydb.Name, owner), nil)
+	}
+
+	secret := &corev1.Secret{}
+	secretKey := types.NamespacedName{Name: user.Spec.PasswordSecret.Name, Namespace: user.Namespace}
+	if err := r.Get(ctx, secretKey, secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return r.setUserStatus(ctx, user, keydbv1.UserPhasePending, fmt.Sprintf("Secret %s not found", secretKey.Name), nil)
+		}
+		return ctrl.Result{}, err
+	}
+	password, ok := secret.Data[user.Spec.PasswordSecret.Key]
+	if !ok || len(password) == 0 {
+		return r.setUserStatus(ctx, user, keydbv1.UserPhaseFailed,
+			fmt.Sprintf("Secret %s has no key %q", secretKey.Name, user.Spec.PasswordSecret.Key), nil)
+	}
+
+	pods, err := userPods(ctx, r.Client, keydb)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if len(pods) == 0 {
+		return r.setUserStatus(ctx, user, keydbv1.UserPhasePending, "Waiting for pods of Keydb "+keydb.Name, nil)
+	}
+	opts, err := keydbOptions(ctx, r.Client, keydb)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	rules := aclRules(&user.Spec, string(password))
+	synced := make([]string, 0, len(pods))
+	for i := range pods {
+		pod := &pods[i]
+		if err := withPod(ctx, r.KeydbClients, keydb, opts, pod, func(c *keydbclient.Client) error {
+			return c.ACLSetUser(ctx, user.Spec.Username, rules...)
+		}); err != nil {
+			logger.Error(err, "failed to apply ACL user", "pod", pod.Name, "username", user.Spec.Username)
+			if _, statusErr := r.setUserStatus(ctx, user, keydbv1.UserPhasePending,
+				fmt.Sprintf("failed to apply to pod %s: %v", pod.Name, err), synced); statusErr != nil {
+				return ctrl.Result{}, statusErr
+			}
+			return ctrl.Result{}, err
+		}
+		synced = append(synced, pod.Name)
+	}
+
+	if user.Status.Phase != keydbv1.UserPhaseReady {
+		r.event(user, corev1.EventTypeNormal, "UserReady",
+			fmt.Sprintf("Applied user %s to %d pods of Keydb %s", user.Spec.Username, len(synced), keydb.Name))
+	}
+	if _, err := r.setUserStatus(ctx, user, keydbv1.UserPhaseReady, "", synced); err != nil {
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{RequeueAfter: userResyncInterval}, nil
+}
+
+// reconcileUserDeletion removes the user from every running pod before
+// letting the KeydbUser go. A Keydb that no longer exists has nothing to clean up.
+func (r *KeydbUserReconciler) reconcileUserDeletion(ctx context.Context, user *keydbv1.KeydbUser) (ctrl.Result, error) {
+	if !controllerutil.ContainsFinalizer(user, userCleanupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	keydb := &keydbv1.Keydb{}
+	err := r.Get(ctx, types.NamespacedName{Name: user.Spec.KeydbName, Namespace: user.Namespace}, keydb)
+	if client.IgnoreNotFound(err) != nil {
+		return ctrl.Result{}, err
+	}
+	// A user that lost a name conflict must not delete the owner's user
+	owner, ownerErr := r.usernameOwner(ctx, user)
+	if ownerErr != nil {
+		return ctrl.Result{}, ownerErr
+	}
+	if err == nil && keydb.DeletionTimestamp.IsZero() && r.KeydbClients != nil && owner == user.Name {
+		pods, err := userPods(ctx, r.Client, keydb)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		opts, err := keydbOptions(ctx, r.Client, keydb)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		for i := range pods {
+			pod := &pods[i]
+			if err := withPod(ctx, r.KeydbClients, keydb, opts, pod, func(c *keydbclient.Client) error {
+				return c.ACLDelUser(ctx, user.Spec.Username)
+			}); err != nil {
+				r.event(user, corev1.EventTypeWarning, "UserCleanupFailed",
+					fmt.Sprintf("Failed to delete user %s from pod %s: %v", user.Spec.Username, pod.Name, err))
+				return ctrl.Result{}, err
+			}
+		}
+	}
+
+	controllerutil.RemoveFinalizer(user, userCleanupFinalizer)
+	return ctrl.Result{}, r.Update(ctx, user)
+}
+
+// usernameOwner returns the name of the KeydbUser that manages the username
+// of user on its Keydb: the oldest one, ties broken by name.
+func (r *KeydbUserReconciler) usernameOwner(ctx context.Context, user *keydbv1.KeydbUser) (string, error) {
+	list := &keydbv1.KeydbUserList{}
+	if err := r.List(ctx, list, client.InNamespace(user.Namespace)); err != nil {
+		return "", err
+	}
+	owner := user
+	for i := range list.Items {
+		other := &list.Items[i]
+		if other.Spec.KeydbName != user.Spec.KeydbName || other.Spec.Username != user.Spec.Username {
+			continue
+		}
+		if other.CreationTimestamp.Before(&owner.CreationTimestamp) ||
+			(other.CreationTimestamp.Equal(&owner.CreationTimestamp) && other.Name < owner.Name) {
+			owner = other
+		}
+	}
+	return owner.Name, nil
+}
+
+// userPods returns the pods of keydb that can take ACL commands.
+func userPods(ctx context.Context, c client.Reader, keydb *keydbv1.Keydb) ([]corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	if err := c.List(ctx, podList,
+		client.InNamespace(keydb.Namespace),
+		client.MatchingLabels{"apps": keydb.Name},
+	); err != nil {
+		return nil, err
+	}
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp == nil && pod.Status.Phase == corev1.PodRunning && pod.Status.PodIP != "" {
+			pods = append(pods, pod)
+		}
+	}
+	return pods, nil
+}
+
+// aclRules renders a KeydbUser spec as ACL SETUSER rules. Starting with reset
+// makes the call idempotent and drops anything removed from the spec.
+func aclRules(spec *keydbv1.KeydbUserSpec, password string) []string {
+	rules := []string{"reset"}
+	if spec.Enabled == nil || *spec.Enabled {
+		rules = append(rules, "on")
+	} else {
+		rules = append(rules, "off")
+	}
+	rules = append(rules, ">"+password)
+	for _, key := range spec.Keys {
+		rules = append(rules, "~"+key)
+	}
+	for _, channel := range spec.Channels {
+		rules = append(rules, "&"+channel)
+	}
+	for _, category := range spec.Categories {
+		rules = append(rules, aclPermission(category, "@"))
+	}
+	for _, command := range spec.Commands {
+		rules = append(rules, aclPermission(command, ""))
+	}
+	return rules
+}
+
+// aclPermission turns "name" or "+name" into an allow rule and "-name" into a deny rule.
+func aclPermission(name, prefix string) string {
+	if rest, ok := strings.CutPrefix(name, "-"); ok {
+		return "-" + prefix + rest
+	}
+	return "+" + prefix + strings.TrimPrefix(name, "+")
+}
+
+// setUserStatus records phase and requeues: Ready users are re-applied
+// periodically, others are retried sooner.
+func (r *KeydbUserReconciler) setUserStatus(ctx context.Context, user *keydbv1.KeydbUser, phase, message string, pods []string) (ctrl.Result, error) {
+	if user.Status.Phase != phase || user.Status.Message != message ||
+		!slices.Equal(user.Status.Pods, pods) || user.Status.ObservedGeneration != user.Generation {
+		if phase == keydbv1.UserPhaseFailed && user.Status.Message != message {
+			r.event(user, corev1.EventTypeWarning, "UserFailed", message)
+		}
+		user.Status.Phase = phase
+		user.Status.Message = message
+		user.Status.Pods = pods
+		user.Status.ObservedGeneration = user.Generation
+		if err := r.Status().Update(ctx, user); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+	if phase == keydbv1.UserPhaseReady {
+		return ctrl.Result{RequeueAfter: userResyncInterval}, nil
+	}
+	return ctrl.Result{RequeueAfter: backupWaitInterval}, nil
+}
+
+func (r *KeydbUserReconciler) event(user *keydbv1.KeydbUser, eventType, reason, message string) {
+	if r.Recorder != nil {
+		r.Recorder.Event(user, eventType, reason, message)
+	}
+}
+
+// usersForObject maps a pod or Secret to the KeydbUsers that depend on it, so
+// restarted pods and changed passwords are applied right away.
+func (r *KeydbUserReconciler) usersForObject(ctx context.Context, obj client.Object) []reconcile.Request {
+	list := &keydbv1.KeydbUserList{}
+	if err := r.List(ctx, list, client.InNamespace(obj.GetNamespace())); err != nil {
+		return nil
+	}
+	var requests []reconcile.Request
+	for _, user := range list.Items {
+		var match bool
+		switch obj.(type) {
+		case *corev1.Pod:
+			match = obj.GetLabels()["apps"] == user.Spec.KeydbName
+		case *corev1.Secret:
+			match = obj.GetName() == user.Spec.PasswordSecret.Name
+		}
+		if match {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: user.Name, Namespace: user.Namespace},
+			})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *KeydbUserReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&keydbv1.KeydbUser{}).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.usersForObject)).
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.usersForObject)).
+		Named("keydbuser").
+		Complete(r)
+}
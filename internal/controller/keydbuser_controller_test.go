@@ -0,0 +1,122 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+var _ = Describe("KeydbUser Controller", func() {
+	Context("When reconciling a resource", func() {
+		const resourceName = "test-user"
+
+		ctx := context.Background()
+
+		typeNamespacedName := types.NamespacedName{
+			Name:      resourceName,
+			Namespace: "default",
+		}
+		keydbuser := &keydbv1.KeydbUser{}
+
+		BeforeEach(func() {
+			By("creating the custom resource for the Kind KeydbUser")
+			err := k8sClient.Get(ctx, typeNamespacedName, keydbuser)
+			if err != nil && errors.IsNotFound(err) {
+				resource := &keydbv1.KeydbUser{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      resourceName,
+						Namespace: "default",
+					},
+					Spec: keydbv1.KeydbUserSpec{
+						KeydbName: "missing-keydb",
+						Username:  "app",
+						PasswordSecret: corev1.SecretKeySelector{
+							LocalObjectReference: corev1.LocalObjectReference{Name: "app-password"},
+							Key:                  "password",
+						},
+					},
+				}
+				Expect(k8sClient.Create(ctx, resource)).To(Succeed())
+			}
+		})
+
+		AfterEach(func() {
+			resource := &keydbv1.KeydbUser{}
+			err := k8sClient.Get(ctx, typeNamespacedName, resource)
+			Expect(err).NotTo(HaveOccurred())
+
+			By("Cleanup the specific resource instance KeydbUser")
+			Expect(k8sClient.Delete(ctx, resource)).To(Succeed())
+			controllerReconciler := &KeydbUserReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+			_, err = controllerReconciler.Reconcile(ctx, reconcile.Request{NamespacedName: typeNamespacedName})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, typeNamespacedName, resource))).To(BeTrue())
+		})
+		It("should wait for the Keydb to exist", func() {
+			By("Reconciling the created resource")
+			controllerReconciler := &KeydbUserReconciler{
+				Client: k8sClient,
+				Scheme: k8sClient.Scheme(),
+			}
+
+			result, err := controllerReconciler.Reconcile(ctx, reconcile.Request{
+				NamespacedName: typeNamespacedName,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.RequeueAfter).To(BeNumerically(">", 0))
+
+			Expect(k8sClient.Get(ctx, typeNamespacedName, keydbuser)).To(Succeed())
+			Expect(keydbuser.Finalizers).To(ContainElement(userCleanupFinalizer))
+			Expect(keydbuser.Status.Phase).To(Equal(keydbv1.UserPhasePending))
+			Expect(keydbuser.Status.Message).To(ContainSubstring("missing-keydb"))
+		})
+	})
+})
+
+var _ = Describe("aclRules", func() {
+	It("should render the spec in ACL SETUSER order", func() {
+		spec := &keydbv1.KeydbUserSpec{
+			Categories: []string{"read", "+write", "-dangerous"},
+			Commands:   []string{"ping", "-flushall"},
+			Keys:       []string{"app:*"},
+			Channels:   []string{"app.*"},
+		}
+		Expect(aclRules(spec, "s3cr3t")).To(Equal([]string{
+			"reset", "on", ">s3cr3t", "~app:*", "&app.*",
+			"+@read", "+@write", "-@dangerous", "+ping", "-flushall",
+		}))
+	})
+
+	It("should turn a disabled user off", func() {
+		enabled := false
+		Expect(aclRules(&keydbv1.KeydbUserSpec{Enabled: &enabled}, "pw")).To(Equal([]string{"reset", "off", ">pw"}))
+	})
+})
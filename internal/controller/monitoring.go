@@ -0,0 +1,81 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// reconcileMonitoring applies the ServiceMonitor and PrometheusRule of
+// spec.metrics and deletes those no longer wanted. Monitoring is optional, so
+// without the Prometheus Operator CRDs the Keydb only gets a warning event.
+func (r *KeydbReconciler) reconcileMonitoring(ctx context.Context, keydb *keydbv1.Keydb) error {
+	logger := log.FromContext(ctx)
+
+	for _, m := range []struct {
+		field   string
+		gvk     schema.GroupVersionKind
+		desired *unstructured.Unstructured
+	}{
+		{"serviceMonitor", k8sresources.ServiceMonitorGVK, k8sresources.GenerateServiceMonitor(keydb, r.Scheme)},
+		{"prometheusRule", k8sresources.PrometheusRuleGVK, k8sresources.GeneratePrometheusRule(keydb, r.Scheme)},
+	} {
+		if m.desired != nil {
+			err := r.apply(ctx, keydb, m.desired)
+			if meta.IsNoMatchError(err) {
+				r.event(keydb, corev1.EventTypeWarning, "PrometheusOperatorMissing",
+					fmt.Sprintf("spec.metrics.%s is set but the Prometheus Operator CRDs are not installed", m.field))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		existing := &unstructured.Unstructured{}
+		existing.SetGroupVersionKind(m.gvk)
+		err := r.Get(ctx, types.NamespacedName{Name: keydb.Name, Namespace: keydb.Namespace}, existing)
+		if apierrors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		if !metav1.IsControlledBy(existing, keydb) {
+			continue
+		}
+		logger.Info("Deleting "+m.gvk.Kind, "name", existing.GetName())
+		if err := r.Delete(ctx, existing); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
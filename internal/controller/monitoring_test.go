@@ -0,0 +1,100 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+)
+
+var _ = Describe("Monitoring", func() {
+	var keydb *keydbv1.Keydb
+
+	BeforeEach(func() {
+		keydb = &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "one"},
+			Spec: keydbv1.KeydbSpec{
+				Metrics: keydbv1.MetricsSpec{
+					Enabled: true,
+					ServiceMonitor: &keydbv1.ServiceMonitorSpec{
+						Interval: "30s",
+						Labels:   map[string]string{"release": "prometheus", "apps": "other"},
+					},
+					PrometheusRule: &keydbv1.PrometheusRuleSpec{
+						AlertLabels:         map[string]string{"severity": "critical"},
+						MemoryUsagePercent:  80,
+						RejectedConnections: 10,
+					},
+				},
+			},
+		}
+	})
+
+	It("should not be generated unless metrics are enabled", func() {
+		keydb.Spec.Metrics.Enabled = false
+		Expect(k8sresources.GenerateServiceMonitor(keydb, runtime.NewScheme())).To(BeNil())
+		Expect(k8sresources.GeneratePrometheusRule(keydb, runtime.NewScheme())).To(BeNil())
+	})
+
+	It("should scrape every pod once through the headless Service", func() {
+		sm := k8sresources.GenerateServiceMonitor(keydb, runtime.NewScheme())
+		Expect(sm.GroupVersionKind()).To(Equal(k8sresources.ServiceMonitorGVK))
+		Expect(sm.GetLabels()).To(Equal(map[string]string{"release": "prometheus", "apps": "keydb"}))
+
+		selector, _, _ := unstructured.NestedStringMap(sm.Object, "spec", "selector", "matchLabels")
+		Expect(selector).To(HaveKeyWithValue(keydbv1.LabelMetrics, "true"))
+		endpoints, _, _ := unstructured.NestedSlice(sm.Object, "spec", "endpoints")
+		Expect(endpoints).To(ConsistOf(map[string]interface{}{"port": "metrics", "path": "/metrics", "interval": "30s"}))
+
+		services, err := k8sresources.GenerateService(keydb, scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+		var scraped []string
+		for _, svc := range services {
+			if svc.Labels[keydbv1.LabelMetrics] == "true" {
+				scraped = append(scraped, svc.Name)
+			}
+		}
+		Expect(scraped).To(Equal([]string{"keydb-headless"}))
+	})
+
+	It("should alert with the configured thresholds", func() {
+		pr := k8sresources.GeneratePrometheusRule(keydb, runtime.NewScheme())
+		groups, _, _ := unstructured.NestedSlice(pr.Object, "spec", "groups")
+		Expect(groups).To(HaveLen(1))
+		rules := groups[0].(map[string]interface{})["rules"].([]interface{})
+
+		alerts := map[string]map[string]interface{}{}
+		for _, r := range rules {
+			rule := r.(map[string]interface{})
+			alerts[rule["alert"].(string)] = rule
+			Expect(rule["for"]).To(Equal("5m"))
+			Expect(rule["labels"]).To(Equal(map[string]interface{}{"severity": "critical"}))
+		}
+		Expect(alerts).To(HaveKey("KeydbInstanceDown"))
+		Expect(alerts).To(HaveKey("KeydbReplicationBroken"))
+		Expect(alerts["KeydbMemoryNearLimit"]["expr"]).To(ContainSubstring(`* 100 > 80`))
+		Expect(alerts["KeydbRejectedConnections"]["expr"]).To(Equal(
+			`increase(redis_rejected_connections_total{namespace="one",service="keydb-headless"}[5m]) > 10`))
+	})
+})
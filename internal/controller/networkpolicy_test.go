@@ -0,0 +1,108 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+)
+
+var _ = Describe("NetworkPolicy", func() {
+	var keydb *keydbv1.Keydb
+
+	BeforeEach(func() {
+		keydb = &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "one"},
+			Spec: keydbv1.KeydbSpec{
+				Replication: keydbv1.ReplicationSpec{
+					Mode: keydbv1.ReplicationModeMasterMaster,
+					Port: 6380,
+					Domain: []string{
+						"keydb-two-headless.two.svc.cluster.local",
+						"keydb-three",
+						"10.1.2.3",
+						"keydb.example.com",
+					},
+				},
+				NetworkPolicy: &keydbv1.NetworkPolicySpec{},
+			},
+		}
+	})
+
+	namespace := func(name string) networkingv1.NetworkPolicyPeer {
+		return networkingv1.NetworkPolicyPeer{NamespaceSelector: &metav1.LabelSelector{
+			MatchLabels: map[string]string{corev1.LabelMetadataName: name},
+		}}
+	}
+
+	It("should not be generated unless requested", func() {
+		keydb.Spec.NetworkPolicy = nil
+		Expect(k8sresources.GenerateNetworkPolicy(keydb, runtime.NewScheme())).To(BeNil())
+	})
+
+	It("should allow replication with the in-cluster and IP domains", func() {
+		np := k8sresources.GenerateNetworkPolicy(keydb, runtime.NewScheme())
+		peers := []networkingv1.NetworkPolicyPeer{
+			namespace("two"),
+			namespace("one"),
+			{IPBlock: &networkingv1.IPBlock{CIDR: "10.1.2.3/32"}},
+		}
+
+		Expect(np.Spec.Ingress[0].From).To(ContainElements(peers))
+		replication := np.Spec.Egress[len(np.Spec.Egress)-1]
+		Expect(replication.To).To(Equal(peers))
+		Expect(replication.Ports[0].Port.IntValue()).To(Equal(6380))
+	})
+
+	It("should only open metrics to the selected namespaces", func() {
+		keydb.Spec.Metrics.Enabled = true
+		Expect(k8sresources.GenerateNetworkPolicy(keydb, runtime.NewScheme()).Spec.Ingress).To(HaveLen(2))
+
+		selector := &metav1.LabelSelector{MatchLabels: map[string]string{"name": "monitoring"}}
+		keydb.Spec.NetworkPolicy.MetricsNamespaceSelector = selector
+		ingress := k8sresources.GenerateNetworkPolicy(keydb, runtime.NewScheme()).Spec.Ingress
+		Expect(ingress).To(HaveLen(3))
+		Expect(ingress[2].From).To(Equal([]networkingv1.NetworkPolicyPeer{{NamespaceSelector: selector}}))
+		Expect(ingress[2].Ports[0].Port.IntValue()).To(Equal(9121))
+	})
+
+	It("should allow the S3 restore download until the restore completes", func() {
+		keydb.Spec.Replication = keydbv1.ReplicationSpec{}
+		Expect(k8sresources.GenerateNetworkPolicy(keydb, runtime.NewScheme()).Spec.Egress).To(HaveLen(2))
+
+		keydb.Spec.Restore = &keydbv1.RestoreSpec{S3: &keydbv1.S3RestoreSource{Bucket: "b", Key: "k"}}
+		egress := k8sresources.GenerateNetworkPolicy(keydb, runtime.NewScheme()).Spec.Egress
+		Expect(egress).To(HaveLen(3))
+		Expect(egress[2].To).To(BeEmpty())
+		Expect(egress[2].Ports[0].Port.IntValue()).To(Equal(443))
+
+		keydb.Spec.Restore.S3.Endpoint = "http://minio.storage:9000"
+		egress = k8sresources.GenerateNetworkPolicy(keydb, runtime.NewScheme()).Spec.Egress
+		Expect(egress[2].To).To(Equal([]networkingv1.NetworkPolicyPeer{namespace("storage")}))
+		Expect(egress[2].Ports[0].Port.IntValue()).To(Equal(9000))
+
+		keydb.Status.Restore = &keydbv1.RestoreStatus{Phase: keydbv1.RestorePhaseCompleted}
+		Expect(k8sresources.GenerateNetworkPolicy(keydb, runtime.NewScheme()).Spec.Egress).To(HaveLen(2))
+	})
+})
@@ -0,0 +1,283 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// passwordRotationPoll is how often a password rotation in progress is checked.
+const passwordRotationPoll = 10 * time.Second
+
+// passwordSyncDelay covers the kubelet sync period and its Secret cache, after
+// which every pod, and through the config reloader the metrics exporter, reads
+// the new password from its mounted file.
+const passwordSyncDelay = 2 * time.Minute
+
+// reconcilePasswordRotation rotates the generated password Secret when the
+// keydb.keydb/rotate-password annotation changes. The new password is added
+// next to the old one on every pod, masterauth is switched, the Secret is
+// updated so clients, probes and the operator move over, and only then is the
+// old password removed. It returns a non-zero duration while a rotation is in
+// progress.
+func (r *KeydbReconciler) reconcilePasswordRotation(ctx context.Context, keydb *keydbv1.Keydb) (time.Duration, error) {
+	if keydb.Spec.PasswordSecret != nil || r.KeydbClients == nil {
+		return 0, nil
+	}
+	// A restore replaces pods on purpose; pick up where we left off afterwards
+	if _, restoring := keydb.Annotations[keydbv1.AnnotationRestore]; restoring {
+		return 0, nil
+	}
+	trigger := keydb.Annotations[keydbv1.AnnotationRotatePassword]
+	status := keydb.Status.PasswordRotation
+	idle := status == nil || status.Step == keydbv1.RotationStepCompleted
+	if idle && (trigger == "" || (status != nil && status.Trigger == trigger)) {
+		return 0, nil
+	}
+
+	secretName, _ := k8sresources.PasswordSecretRef(keydb)
+	secret := &corev1.Secret{}
+	if err := r.Get(ctx, types.NamespacedName{Name: secretName, Namespace: keydb.Namespace}, secret); err != nil {
+		return 0, err
+	}
+	if idle {
+		return passwordRotationPoll, r.startPasswordRotation(ctx, keydb, secret, trigger)
+	}
+
+	pods, err := r.rotationPods(ctx, keydb)
+	if err != nil {
+		return 0, err
+	}
+	if pods == nil {
+		return passwordRotationPoll, r.setRotationMessage(ctx, keydb, "Waiting for every pod to be running")
+	}
+
+	current := string(secret.Data[k8sresources.SecretKeyPassword])
+	next := string(secret.Data[k8sresources.SecretKeyNextPassword])
+	previous := string(secret.Data[k8sresources.SecretKeyPreviousPassword])
+
+	switch status.Step {
+	case keydbv1.RotationStepAddPassword, keydbv1.RotationStepUpdateMasterauth:
+		if next == "" {
+			if previous != "" && status.Step == keydbv1.RotationStepUpdateMasterauth {
+				// The Secret was switched but the step was not recorded
+				return passwordRotationPoll, r.setRotationStep(ctx, keydb, keydbv1.RotationStepRollClients,
+					"Waiting for clients to pick up the new password")
+			}
+			// next-password was removed from the Secret; start over with a fresh one
+			return passwordRotationPoll, r.startPasswordRotation(ctx, keydb, secret, trigger)
+		}
+		masterauth := status.Step == keydbv1.RotationStepUpdateMasterauth
+		if err := r.applyPassword(ctx, keydb, pods, []string{current}, next, masterauth, false); err != nil {
+			return 0, r.rotationError(ctx, keydb, err)
+		}
+		if !masterauth {
+			return passwordRotationPoll, r.setRotationStep(ctx, keydb, keydbv1.RotationStepUpdateMasterauth,
+				"Switching masterauth to the new password")
+		}
+
+		// Every pod accepts and replicates with the new password; hand it to clients
+		secret.Data[k8sresources.SecretKeyPassword] = []byte(next)
+		secret.Data[k8sresources.SecretKeyPreviousPassword] = []byte(current)
+		delete(secret.Data, k8sresources.SecretKeyNextPassword)
+		if err := r.Update(ctx, secret); err != nil {
+			return 0, err
+		}
+		return passwordRotationPoll, r.setRotationStep(ctx, keydb, keydbv1.RotationStepRollClients,
+			"Waiting for clients to pick up the new password")
+
+	case keydbv1.RotationStepRollClients:
+		// Pods restarted in the meantime may come up with either password
+		if err := r.applyPassword(ctx, keydb, pods, []string{current, previous}, current, true, false); err != nil {
+			return 0, r.rotationError(ctx, keydb, err)
+		}
+		if wait := passwordSyncDelay - time.Since(status.LastTransitionTime.Time); wait > 0 {
+			return wait, r.setRotationMessage(ctx, keydb, "Waiting for clients to pick up the new password")
+		}
+		return passwordRotationPoll, r.setRotationStep(ctx, keydb, keydbv1.RotationStepRemoveOldPassword,
+			"Removing the old password")
+
+	case keydbv1.RotationStepRemoveOldPassword:
+		// resetpass drops every other password, including any left behind by
+		// an interrupted rotation
+		if err := r.applyPassword(ctx, keydb, pods, []string{current}, current, true, true); err != nil {
+			return 0, r.rotationError(ctx, keydb, err)
+		}
+		if _, ok := secret.Data[k8sresources.SecretKeyPreviousPassword]; ok {
+			delete(secret.Data, k8sresources.SecretKeyPreviousPassword)
+			if err := r.Update(ctx, secret); err != nil {
+				return 0, err
+			}
+		}
+		return 0, r.setRotationStep(ctx, keydb, keydbv1.RotationStepCompleted, "Password rotated")
+	}
+	return 0, nil
+}
+
+// startPasswordRotation stores a freshly generated password in the Secret
+// next to the current one and records the rotation in status.
+func (r *KeydbReconciler) startPasswordRotation(ctx context.Context, keydb *keydbv1.Keydb, secret *corev1.Secret, trigger string) error {
+	password, err := k8sresources.Generatepassword(16)
+	if err != nil {
+		return err
+	}
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[k8sresources.SecretKeyNextPassword] = []byte(password)
+	if err := r.Update(ctx, secret); err != nil {
+		return err
+	}
+
+	now := metav1.Now()
+	keydb.Status.PasswordRotation = &keydbv1.PasswordRotationStatus{
+		Step:               keydbv1.RotationStepAddPassword,
+		Trigger:            trigger,
+		Message:            "Adding the new password on every pod",
+		StartTime:          &now,
+		LastTransitionTime: &now,
+	}
+	r.event(keydb, corev1.EventTypeNormal, "PasswordRotationStarted", "Rotating the password in Secret "+secret.Name)
+	return r.Status().Update(ctx, keydb)
+}
+
+// rotationPods returns the running pods of keydb, or nil while any of the
+// desired pods is missing or not running, since a password step must reach
+// every pod before the next one starts.
+func (r *KeydbReconciler) rotationPods(ctx context.Context, keydb *keydbv1.Keydb) ([]corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList,
+		client.InNamespace(keydb.Namespace),
+		client.MatchingLabels{"apps": keydb.Name},
+	); err != nil {
+		return nil, err
+	}
+	desired := int32(1)
+	if keydb.Spec.Replicas != nil {
+		desired = *keydb.Spec.Replicas
+	}
+
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning || pod.Status.PodIP == "" {
+			return nil, nil
+		}
+		pods = append(pods, pod)
+	}
+	if int32(len(pods)) < desired {
+		return nil, nil
+	}
+	return pods, nil
+}
+
+// applyPassword adds password to the default user on every pod, logging in
+// with the first of auth that is accepted. With masterauth set, replicas also
+// authenticate to their primary with it; with reset set, every other password
+// is removed.
+func (r *KeydbReconciler) applyPassword(ctx context.Context, keydb *keydbv1.Keydb, pods []corev1.Pod, auth []string, password string, masterauth, reset bool) error {
+	opts, err := keydbOptions(ctx, r.Client, keydb)
+	if err != nil {
+		return err
+	}
+	rules := []string{">" + password}
+	if reset {
+		rules = []string{"resetpass", ">" + password}
+	}
+
+	for i := range pods {
+		pod := &pods[i]
+		err := errors.New("no password to log in with")
+		for _, pw := range auth {
+			if pw == "" {
+				continue
+			}
+			opts.Password = pw
+			err = withPod(ctx, r.KeydbClients, keydb, opts, pod, func(c *keydbclient.Client) error {
+				if err := c.ACLSetUser(ctx, "default", rules...); err != nil {
+					return err
+				}
+				if masterauth {
+					return c.ConfigSet(ctx, "masterauth", password)
+				}
+				return nil
+			})
+			if !isAuthError(err) {
+				break
+			}
+		}
+		if err != nil {
+			return fmt.Errorf("pod %s: %w", pod.Name, err)
+		}
+	}
+	return nil
+}
+
+// isAuthError reports whether err is KeyDB rejecting a password.
+func isAuthError(err error) bool {
+	var replyErr keydbclient.Error
+	if !errors.As(err, &replyErr) {
+		return false
+	}
+	return strings.HasPrefix(string(replyErr), "WRONGPASS") || strings.HasPrefix(string(replyErr), "ERR invalid password")
+}
+
+// setRotationStep moves the rotation to step and records an event.
+func (r *KeydbReconciler) setRotationStep(ctx context.Context, keydb *keydbv1.Keydb, step, message string) error {
+	status := keydb.Status.PasswordRotation
+	now := metav1.Now()
+	status.Step = step
+	status.Message = message
+	status.LastTransitionTime = &now
+	if step == keydbv1.RotationStepCompleted {
+		status.CompletionTime = &now
+		r.event(keydb, corev1.EventTypeNormal, "PasswordRotated", "The old password was removed from every pod")
+	} else {
+		r.event(keydb, corev1.EventTypeNormal, "PasswordRotation", message)
+	}
+	return r.Status().Update(ctx, keydb)
+}
+
+// setRotationMessage updates the message of the current step.
+func (r *KeydbReconciler) setRotationMessage(ctx context.Context, keydb *keydbv1.Keydb, message string) error {
+	status := keydb.Status.PasswordRotation
+	if status.Message == message {
+		return nil
+	}
+	status.Message = message
+	return r.Status().Update(ctx, keydb)
+}
+
+// rotationError records err in the rotation status and returns it, so the
+// step is retried with backoff.
+func (r *KeydbReconciler) rotationError(ctx context.Context, keydb *keydbv1.Keydb, err error) error {
+	if statusErr := r.setRotationMessage(ctx, keydb, err.Error()); statusErr != nil {
+		return statusErr
+	}
+	return err
+}
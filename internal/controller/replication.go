@@ -0,0 +1,196 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// reconcilePodLabels labels every pod with whether it takes client traffic
+// and, in master-replica mode, with its role, so the client-facing Services
+// select the right endpoints. Traffic is disabled while a KeydbRestore holds
+// the Keydb.
+func (r *KeydbReconciler) reconcilePodLabels(ctx context.Context, keydb *keydbv1.Keydb) error {
+	logger := log.FromContext(ctx)
+
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList,
+		client.InNamespace(keydb.Namespace),
+		client.MatchingLabels{"apps": keydb.Name},
+	); err != nil {
+		return err
+	}
+
+	traffic := keydbv1.TrafficEnabled
+	if _, restoring := keydb.Annotations[keydbv1.AnnotationRestore]; restoring {
+		traffic = keydbv1.TrafficDisabled
+	}
+	primary := k8sresources.PrimaryPodName(keydb)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		want := map[string]string{keydbv1.LabelTraffic: traffic}
+		if keydb.Spec.Replication.Mode == keydbv1.ReplicationModeMasterReplica {
+			want[keydbv1.LabelRole] = keydbv1.RoleReplica
+			if pod.Name == primary {
+				want[keydbv1.LabelRole] = keydbv1.RolePrimary
+			}
+		}
+		changed := false
+		for key, value := range want {
+			if pod.Labels[key] != value {
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Labels == nil {
+			pod.Labels = map[string]string{}
+		}
+		for key, value := range want {
+			pod.Labels[key] = value
+		}
+		if err := r.Patch(ctx, pod, patch); err != nil {
+			return err
+		}
+		logger.Info("updated pod labels", "pod", pod.Name, "role", want[keydbv1.LabelRole], "traffic", traffic)
+	}
+
+	return nil
+}
+
+// podToKeydb maps a pod owned by a Keydb StatefulSet back to its Keydb, so
+// restarted pods get their labels back without waiting for a resync.
+func podToKeydb(_ context.Context, obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()["apps"]
+	if !ok {
+		return nil
+	}
+	for _, ref := range obj.GetOwnerReferences() {
+		if ref.Kind == "StatefulSet" && ref.Name == name {
+			return []reconcile.Request{{
+				NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()},
+			}}
+		}
+	}
+	return nil
+}
+
+// collectNodeStatus queries INFO on every running pod and reports what KeyDB
+// itself sees, so a broken replication link shows up even when the pod is Ready.
+func (r *KeydbReconciler) collectNodeStatus(ctx context.Context, keydb *keydbv1.Keydb, pods []corev1.Pod) []keydbv1.NodeStatus {
+	if r.KeydbClients == nil {
+		return nil
+	}
+	opts, err := keydbOptions(ctx, r.Client, keydb)
+	if err != nil {
+		log.FromContext(ctx).V(1).Info("unable to collect node status", "error", err.Error())
+		return nil
+	}
+
+	nodes := make([]keydbv1.NodeStatus, 0, len(pods))
+	infos := make([]map[string]string, 0, len(pods))
+	offsetByHost := map[string]int64{}
+	for i := range pods {
+		pod := &pods[i]
+		if pod.DeletionTimestamp != nil || pod.Status.Phase != corev1.PodRunning {
+			continue
+		}
+
+		node := keydbv1.NodeStatus{Pod: pod.Name}
+		info, err := podInfo(ctx, r.KeydbClients, keydb, opts, pod)
+		if err != nil {
+			node.Error = err.Error()
+			nodes = append(nodes, node)
+			infos = append(infos, nil)
+			continue
+		}
+
+		node.Role = info["role"]
+		node.MasterHost = info["master_host"]
+		node.LinkStatus = info["master_link_status"]
+		node.Version = info["keydb_version"]
+		if node.Version == "" {
+			node.Version = info["redis_version"]
+		}
+		node.UsedMemory = info["used_memory_human"]
+		node.PersistenceError = persistenceError(info)
+		if n, err := strconv.ParseInt(info["connected_slaves"], 10, 32); err == nil {
+			node.ConnectedReplicas = int32(n)
+		}
+		if n, err := strconv.ParseInt(info["master_repl_offset"], 10, 64); err == nil {
+			node.ReplicationOffset = n
+			offsetByHost[k8sresources.PodFQDN(keydb, pod.Name)] = n
+		}
+		if n, err := strconv.ParseInt(info["master_last_io_seconds_ago"], 10, 64); err == nil && n >= 0 {
+			node.LagSeconds = &n
+		}
+
+		nodes = append(nodes, node)
+		infos = append(infos, info)
+	}
+
+	// Byte lag can only be computed against a primary that is part of this cluster
+	for i := range nodes {
+		if infos[i] == nil || nodes[i].MasterHost == "" {
+			continue
+		}
+		primaryOffset, ok := offsetByHost[nodes[i].MasterHost]
+		if !ok {
+			continue
+		}
+		replicaOffset, err := strconv.ParseInt(infos[i]["slave_repl_offset"], 10, 64)
+		if err != nil {
+			continue
+		}
+		lag := primaryOffset - replicaOffset
+		if lag < 0 {
+			lag = 0
+		}
+		nodes[i].LagBytes = &lag
+	}
+
+	return nodes
+}
+
+// persistenceError describes the failed RDB save or AOF write INFO reports,
+// or returns "" when persistence is healthy or off.
+func persistenceError(info map[string]string) string {
+	var failed []string
+	if info["rdb_last_bgsave_status"] == "err" {
+		failed = append(failed, "last RDB save failed")
+	}
+	if info["aof_last_write_status"] == "err" {
+		failed = append(failed, "last AOF write failed")
+	}
+	if info["aof_last_bgrewrite_status"] == "err" {
+		failed = append(failed, "last AOF rewrite failed")
+	}
+	return strings.Join(failed, ", ")
+}
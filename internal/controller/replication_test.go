@@ -0,0 +1,85 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+)
+
+var _ = Describe("Node status", func() {
+	var (
+		ctx context.Context
+		fc  *fakeCluster
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		fc = newFakeCluster(3)
+	})
+
+	It("should report what INFO says about every pod", func() {
+		primary := k8sresources.PodFQDN(fc.keydb, "keydb-0")
+		fc.servers["keydb-0"].SetReplication("", "", 500)
+		fc.servers["keydb-1"].SetReplication(primary, "6379", 500)
+		fc.servers["keydb-2"].SetReplication(primary, "6379", 300)
+
+		nodes := fc.r.collectNodeStatus(ctx, fc.keydb, fc.pods)
+		Expect(nodes).To(Equal([]keydbv1.NodeStatus{
+			{Pod: "keydb-0", Role: "master", Version: "6.3.4", UsedMemory: "1.00M", ReplicationOffset: 500},
+			{
+				Pod: "keydb-1", Role: "slave", MasterHost: primary, LinkStatus: "up", Version: "6.3.4",
+				UsedMemory: "1.00M", ReplicationOffset: 500, LagBytes: ptr.To(int64(0)), LagSeconds: ptr.To(int64(0)),
+			},
+			{
+				Pod: "keydb-2", Role: "slave", MasterHost: primary, LinkStatus: "up", Version: "6.3.4",
+				UsedMemory: "1.00M", ReplicationOffset: 300, LagBytes: ptr.To(int64(200)), LagSeconds: ptr.To(int64(0)),
+			},
+		}))
+	})
+
+	It("should only compute the byte lag against a primary of the cluster", func() {
+		fc.servers["keydb-1"].SetReplication("keydb.example.com", "6379", 300)
+
+		nodes := fc.r.collectNodeStatus(ctx, fc.keydb, fc.pods)
+		Expect(nodes[1].MasterHost).To(Equal("keydb.example.com"))
+		Expect(nodes[1].LagBytes).To(BeNil())
+		Expect(nodes[2].LagBytes).NotTo(BeNil())
+	})
+
+	It("should report pods that cannot be queried and skip those not running", func() {
+		fc.servers["keydb-1"].Handle("INFO", func([]string) interface{} {
+			return keydbclient.Error("LOADING KeyDB is loading the dataset in memory")
+		})
+		fc.pods[2].Status.Phase = corev1.PodPending
+
+		nodes := fc.r.collectNodeStatus(ctx, fc.keydb, fc.pods)
+		Expect(nodes).To(HaveLen(2))
+		Expect(nodes[0].Role).To(Equal("master"))
+		Expect(nodes[1].Pod).To(Equal("keydb-1"))
+		Expect(nodes[1].Role).To(BeEmpty())
+		Expect(nodes[1].Error).To(ContainSubstring("LOADING"))
+	})
+})
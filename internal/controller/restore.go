@@ -0,0 +1,220 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// restoreRequeue is how often a Keydb waiting for its backup is checked again.
+const restoreRequeue = 30 * time.Second
+
+// restoreResult is the termination message of the restore init container.
+type restoreResult struct {
+	Result  string `json:"result"`
+	Message string `json:"message"`
+}
+
+// restoreSource describes spec.restore for status and events.
+func restoreSource(restore *keydbv1.RestoreSpec) string {
+	switch {
+	case restore.BackupName != "":
+		return "backup/" + restore.BackupName
+	case restore.S3 != nil:
+		return k8sresources.RestoreS3URL(restore.S3)
+	case restore.PersistentVolumeClaim != nil:
+		return "pvc/" + restore.PersistentVolumeClaim.ClaimName
+	}
+	return ""
+}
+
+// resolveRestore records spec.restore in status and, for a backup source,
+// resolves the backup to its S3 object. It returns false while the StatefulSet
+// must not be created yet because the backup has not completed.
+func (r *KeydbReconciler) resolveRestore(ctx context.Context, keydb *keydbv1.Keydb) (bool, error) {
+	restore := keydb.Spec.Restore
+	if restore == nil {
+		return true, nil
+	}
+	status := keydb.Status.Restore
+	if status != nil && status.Source != restoreSource(restore) {
+		// The source was corrected after a failed or pending restore
+		status = nil
+	}
+	if status != nil && (restore.BackupName == "" || status.S3 != nil) {
+		return true, nil
+	}
+	if status == nil {
+		status = &keydbv1.RestoreStatus{
+			Phase:  keydbv1.RestorePhasePending,
+			Source: restoreSource(restore),
+		}
+	}
+	proceed := true
+
+	if restore.BackupName != "" {
+		backup := &keydbv1.KeydbBackup{}
+		err := r.Get(ctx, types.NamespacedName{Name: restore.BackupName, Namespace: keydb.Namespace}, backup)
+		switch {
+		case apierrors.IsNotFound(err):
+			status.Message = fmt.Sprintf("Waiting for KeydbBackup %s", restore.BackupName)
+			proceed = false
+		case err != nil:
+			return false, err
+		case backup.Status.Phase == keydbv1.BackupPhaseFailed:
+			status.Phase = keydbv1.RestorePhaseFailed
+			status.Message = fmt.Sprintf("KeydbBackup %s failed: %s", backup.Name, backup.Status.Message)
+			proceed = false
+		case backup.Status.Phase != keydbv1.BackupPhaseCompleted:
+			status.Message = fmt.Sprintf("Waiting for KeydbBackup %s to complete", backup.Name)
+			proceed = false
+		default:
+			status.S3 = k8sresources.BackupS3Source(backup)
+			status.Message = fmt.Sprintf("Restoring %s", backup.Status.Location)
+		}
+	}
+
+	changed := keydb.Status.Restore != status || keydb.Status.Restore.Phase != status.Phase || keydb.Status.Restore.Message != status.Message
+	keydb.Status.Restore = status
+	if changed {
+		if status.Phase == keydbv1.RestorePhaseFailed {
+			r.event(keydb, corev1.EventTypeWarning, "RestoreFailed", status.Message)
+		}
+		if err := r.Status().Update(ctx, keydb); err != nil {
+			return false, err
+		}
+	}
+	return proceed, nil
+}
+
+// updateRestoreStatus follows the restore init container on the restore pod
+// and, once data is in place, turns AOF back on where the pod was started
+// without it.
+func (r *KeydbReconciler) updateRestoreStatus(ctx context.Context, keydb *keydbv1.Keydb) {
+	status := keydb.Status.Restore
+	if keydb.Spec.Restore == nil || status == nil {
+		return
+	}
+
+	switch status.Phase {
+	case keydbv1.RestorePhasePending, keydbv1.RestorePhaseRestoring:
+		r.observeRestorePod(ctx, keydb, status)
+	case keydbv1.RestorePhaseCompleted:
+		if !status.AppendOnlyRestored {
+			status.AppendOnlyRestored = r.enableAppendOnly(ctx, keydb, status.Pod)
+		}
+	}
+}
+
+func (r *KeydbReconciler) observeRestorePod(ctx context.Context, keydb *keydbv1.Keydb, status *keydbv1.RestoreStatus) {
+	podName := keydb.Name + "-0"
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: podName, Namespace: keydb.Namespace}, pod); err != nil {
+		return
+	}
+	for _, cs := range pod.Status.InitContainerStatuses {
+		if cs.Name != k8sresources.RestoreContainer {
+			continue
+		}
+		status.Pod = podName
+		switch {
+		case cs.State.Running != nil:
+			status.Phase = keydbv1.RestorePhaseRestoring
+			status.Message = "Restoring data into " + podName
+		case cs.State.Terminated != nil:
+			var result restoreResult
+			_ = json.Unmarshal([]byte(cs.State.Terminated.Message), &result)
+			if result.Message == "" {
+				result.Message = cs.State.Terminated.Reason
+			}
+			if cs.State.Terminated.ExitCode != 0 || result.Result == "failed" {
+				status.Phase = keydbv1.RestorePhaseFailed
+				status.Message = result.Message
+				r.event(keydb, corev1.EventTypeWarning, "RestoreFailed", fmt.Sprintf("Restore into %s failed: %s", podName, result.Message))
+				return
+			}
+			now := metav1.Now()
+			status.CompletionTime = &now
+			status.Message = result.Message
+			if result.Result == "skipped" {
+				status.Phase = keydbv1.RestorePhaseSkipped
+				r.event(keydb, corev1.EventTypeNormal, "RestoreSkipped", fmt.Sprintf("Skipped restore into %s: %s", podName, result.Message))
+				return
+			}
+			status.Phase = keydbv1.RestorePhaseCompleted
+			r.event(keydb, corev1.EventTypeNormal, "RestoreCompleted", fmt.Sprintf("Restored %s into %s", status.Source, podName))
+		case cs.State.Waiting != nil && cs.LastTerminationState.Terminated != nil:
+			// Crash-looping; report the last failure but keep waiting for a retry
+			status.Message = cs.LastTerminationState.Terminated.Message
+		}
+	}
+}
+
+// enableAppendOnly turns AOF back on in the restored pod, which started from
+// the restored dump.rdb with appendonly disabled. KeyDB rewrites the AOF from
+// memory, so later restarts load it as usual. It reports whether there is
+// nothing left to do, so that it runs once per restore and never overrides
+// AOF settings afterwards.
+func (r *KeydbReconciler) enableAppendOnly(ctx context.Context, keydb *keydbv1.Keydb, podName string) bool {
+	if !k8sresources.AppendOnlyConfigured(keydb) {
+		return true
+	}
+	if r.KeydbClients == nil || podName == "" {
+		return false
+	}
+	logger := log.FromContext(ctx)
+
+	pod := &corev1.Pod{}
+	if err := r.Get(ctx, types.NamespacedName{Name: podName, Namespace: keydb.Namespace}, pod); err != nil {
+		return false
+	}
+	if pod.Status.Phase != corev1.PodRunning || pod.DeletionTimestamp != nil {
+		return false
+	}
+	opts, err := keydbOptions(ctx, r.Client, keydb)
+	if err != nil {
+		return false
+	}
+	info, err := podInfo(ctx, r.KeydbClients, keydb, opts, pod, "persistence")
+	if err != nil || info["loading"] == "1" {
+		return false
+	}
+	if info["aof_enabled"] != "0" {
+		return true
+	}
+	err = withPod(ctx, r.KeydbClients, keydb, opts, pod, func(c *keydbclient.Client) error {
+		return c.ConfigSet(ctx, "appendonly", "yes")
+	})
+	if err != nil {
+		logger.Error(err, "failed to re-enable appendonly after restore", "pod", pod.Name)
+		return false
+	}
+	logger.Info("re-enabled appendonly after restore", "pod", pod.Name)
+	return true
+}
@@ -0,0 +1,117 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"sort"
+	"time"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+// defaultFailedBackupsHistoryLimit applies when the schedule leaves it unset
+const defaultFailedBackupsHistoryLimit = 3
+
+// backupFinishTime is when a backup finished, falling back to its creation.
+func backupFinishTime(b *keydbv1.KeydbBackup) time.Time {
+	if b.Status.CompletionTime != nil {
+		return b.Status.CompletionTime.Time
+	}
+	return b.CreationTimestamp.Time
+}
+
+// backupsToPrune returns the finished backups of a schedule that no retention
+// rule keeps. Backups that are still running are never pruned.
+func backupsToPrune(backups []keydbv1.KeydbBackup, spec keydbv1.KeydbBackupScheduleSpec, now time.Time) []*keydbv1.KeydbBackup {
+	var completed, failed []*keydbv1.KeydbBackup
+	for i := range backups {
+		b := &backups[i]
+		if !b.DeletionTimestamp.IsZero() {
+			continue
+		}
+		switch b.Status.Phase {
+		case keydbv1.BackupPhaseCompleted:
+			completed = append(completed, b)
+		case keydbv1.BackupPhaseFailed:
+			failed = append(failed, b)
+		}
+	}
+	newestFirst := func(list []*keydbv1.KeydbBackup) {
+		sort.SliceStable(list, func(i, j int) bool {
+			return backupFinishTime(list[i]).After(backupFinishTime(list[j]))
+		})
+	}
+	newestFirst(completed)
+	newestFirst(failed)
+
+	var prune []*keydbv1.KeydbBackup
+
+	failedLimit := defaultFailedBackupsHistoryLimit
+	if spec.FailedBackupsHistoryLimit != nil {
+		failedLimit = int(*spec.FailedBackupsHistoryLimit)
+	}
+	if len(failed) > failedLimit {
+		prune = append(prune, failed[failedLimit:]...)
+	}
+
+	r := spec.Retention
+	if r.KeepLast == nil && r.KeepDaily == nil && r.KeepWeekly == nil {
+		return prune
+	}
+
+	keep := map[string]bool{}
+	if r.KeepLast != nil {
+		for i := 0; i < len(completed) && i < int(*r.KeepLast); i++ {
+			keep[completed[i].Name] = true
+		}
+	}
+
+	today := now.UTC().Truncate(24 * time.Hour)
+	if r.KeepDaily != nil && *r.KeepDaily > 0 {
+		cutoff := today.AddDate(0, 0, -int(*r.KeepDaily-1))
+		seen := map[string]bool{}
+		for _, b := range completed {
+			t := backupFinishTime(b).UTC()
+			day := t.Format(time.DateOnly)
+			if !t.Before(cutoff) && !seen[day] {
+				seen[day] = true
+				keep[b.Name] = true
+			}
+		}
+	}
+	if r.KeepWeekly != nil && *r.KeepWeekly > 0 {
+		// ISO weeks start on Monday
+		weekStart := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		cutoff := weekStart.AddDate(0, 0, -7*int(*r.KeepWeekly-1))
+		seen := map[[2]int]bool{}
+		for _, b := range completed {
+			t := backupFinishTime(b).UTC()
+			year, week := t.ISOWeek()
+			if !t.Before(cutoff) && !seen[[2]int{year, week}] {
+				seen[[2]int{year, week}] = true
+				keep[b.Name] = true
+			}
+		}
+	}
+
+	for _, b := range completed {
+		if !keep[b.Name] {
+			prune = append(prune, b)
+		}
+	}
+	return prune
+}
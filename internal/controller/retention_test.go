@@ -0,0 +1,97 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+var _ = Describe("Backup retention", func() {
+	// Wednesday
+	now := time.Date(2025, 6, 11, 12, 0, 0, 0, time.UTC)
+
+	backup := func(name, phase string, finished time.Time) keydbv1.KeydbBackup {
+		t := metav1.NewTime(finished)
+		return keydbv1.KeydbBackup{
+			ObjectMeta: metav1.ObjectMeta{Name: name, CreationTimestamp: t},
+			Status:     keydbv1.KeydbBackupStatus{Phase: phase, CompletionTime: &t},
+		}
+	}
+	names := func(list []*keydbv1.KeydbBackup) []string {
+		out := []string{}
+		for _, b := range list {
+			out = append(out, b.Name)
+		}
+		return out
+	}
+
+	It("should keep every completed backup without retention rules", func() {
+		backups := []keydbv1.KeydbBackup{
+			backup("a", keydbv1.BackupPhaseCompleted, now.Add(-48*time.Hour)),
+			backup("b", keydbv1.BackupPhaseCompleted, now.Add(-24*time.Hour)),
+		}
+		Expect(backupsToPrune(backups, keydbv1.KeydbBackupScheduleSpec{}, now)).To(BeEmpty())
+	})
+
+	It("should keep the last N and never prune running backups", func() {
+		backups := []keydbv1.KeydbBackup{
+			backup("old", keydbv1.BackupPhaseCompleted, now.Add(-3*time.Hour)),
+			backup("mid", keydbv1.BackupPhaseCompleted, now.Add(-2*time.Hour)),
+			backup("new", keydbv1.BackupPhaseCompleted, now.Add(-1*time.Hour)),
+			backup("running", keydbv1.BackupPhaseRunning, now.Add(-4*time.Hour)),
+		}
+		spec := keydbv1.KeydbBackupScheduleSpec{
+			Retention: keydbv1.BackupRetentionSpec{KeepLast: ptr.To(int32(2))},
+		}
+		Expect(names(backupsToPrune(backups, spec, now))).To(ConsistOf("old"))
+	})
+
+	It("should keep the newest backup of each recent day and week", func() {
+		backups := []keydbv1.KeydbBackup{
+			backup("today-2", keydbv1.BackupPhaseCompleted, now.Add(-1*time.Hour)),
+			backup("today-1", keydbv1.BackupPhaseCompleted, now.Add(-2*time.Hour)),
+			backup("yesterday", keydbv1.BackupPhaseCompleted, now.Add(-24*time.Hour)),
+			// Monday two weeks back: outside KeepDaily, inside KeepWeekly
+			backup("two-weeks", keydbv1.BackupPhaseCompleted, time.Date(2025, 5, 26, 3, 0, 0, 0, time.UTC)),
+			backup("old", keydbv1.BackupPhaseCompleted, time.Date(2025, 4, 1, 3, 0, 0, 0, time.UTC)),
+		}
+		spec := keydbv1.KeydbBackupScheduleSpec{
+			Retention: keydbv1.BackupRetentionSpec{
+				KeepDaily:  ptr.To(int32(2)),
+				KeepWeekly: ptr.To(int32(3)),
+			},
+		}
+		Expect(names(backupsToPrune(backups, spec, now))).To(ConsistOf("today-1", "old"))
+	})
+
+	It("should cap the failed backup history", func() {
+		backups := []keydbv1.KeydbBackup{
+			backup("f1", keydbv1.BackupPhaseFailed, now.Add(-3*time.Hour)),
+			backup("f2", keydbv1.BackupPhaseFailed, now.Add(-2*time.Hour)),
+			backup("f3", keydbv1.BackupPhaseFailed, now.Add(-1*time.Hour)),
+		}
+		spec := keydbv1.KeydbBackupScheduleSpec{FailedBackupsHistoryLimit: ptr.To(int32(1))}
+		Expect(names(backupsToPrune(backups, spec, now))).To(ConsistOf("f1", "f2"))
+	})
+})
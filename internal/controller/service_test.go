@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+)
+
+var _ = Describe("Services", func() {
+	var keydb *keydbv1.Keydb
+
+	BeforeEach(func() {
+		keydb = &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "default"},
+		}
+	})
+
+	It("should select new pods before the operator labels them", func() {
+		sts, err := k8sresources.GenerateStatefulSet(keydb, runtime.NewScheme(), "controller:latest")
+		Expect(err).NotTo(HaveOccurred())
+		podLabels := labels.Set(sts.Spec.Template.Labels)
+
+		services, err := k8sresources.GenerateService(keydb, scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+		for _, svc := range services {
+			Expect(labels.SelectorFromSet(svc.Spec.Selector).Matches(podLabels)).To(BeTrue(), svc.Name)
+		}
+
+		By("not selecting pods a restore drained")
+		podLabels[keydbv1.LabelTraffic] = keydbv1.TrafficDisabled
+		Expect(labels.SelectorFromSet(services[0].Spec.Selector).Matches(podLabels)).To(BeFalse())
+	})
+})
+
+var _ = Describe("Role Services", func() {
+	It("should send writes to the primary and reads to the replicas", func() {
+		ctx := context.Background()
+		fc := newFakeCluster(3)
+		fc.keydb.Status.Primary = "keydb-1"
+		Expect(fc.r.reconcilePodLabels(ctx, fc.keydb)).To(Succeed())
+
+		pods := &corev1.PodList{}
+		Expect(fc.r.List(ctx, pods)).To(Succeed())
+		services, err := k8sresources.GenerateService(fc.keydb, scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+		selected := map[string][]string{}
+		for _, svc := range services {
+			for _, pod := range pods.Items {
+				if labels.SelectorFromSet(svc.Spec.Selector).Matches(labels.Set(pod.Labels)) {
+					selected[svc.Name] = append(selected[svc.Name], pod.Name)
+				}
+			}
+		}
+		Expect(selected).To(HaveKeyWithValue("keydb-primary", []string{"keydb-1"}))
+		Expect(selected).To(HaveKeyWithValue("keydb-replicas", []string{"keydb-0", "keydb-2"}))
+	})
+})
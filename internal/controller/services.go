@@ -0,0 +1,109 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http:// www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+)
+
+// reconcileServices applies the Services of a Keydb and removes the per-pod
+// Services of pods that are gone or no longer wanted.
+func (r *KeydbReconciler) reconcileServices(ctx context.Context, keydb *keydbv1.Keydb) error {
+	logger := log.FromContext(ctx)
+
+	services, err := k8sresources.GenerateService(keydb, r.Scheme)
+	if err != nil {
+		return err
+	}
+	wanted := map[string]bool{}
+	for _, svc := range services {
+		wanted[svc.Name] = true
+		if err := r.recreateOnHeadlessChange(ctx, svc); err != nil {
+			return err
+		}
+		if err := r.apply(ctx, keydb, svc); err != nil {
+			return err
+		}
+	}
+
+	podServices := &corev1.ServiceList{}
+	if err := r.List(ctx, podServices,
+		client.InNamespace(keydb.Namespace),
+		client.MatchingLabels{"apps": keydb.Name, keydbv1.LabelPodService: "true"},
+	); err != nil {
+		return err
+	}
+	for i := range podServices.Items {
+		svc := &podServices.Items[i]
+		if wanted[svc.Name] || !metav1.IsControlledBy(svc, keydb) {
+			continue
+		}
+		logger.Info("Deleting per-pod Service", "service", svc.Name)
+		if err := r.Delete(ctx, svc); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// recreateOnHeadlessChange deletes a Service that has to switch between
+// headless and a cluster IP, which Kubernetes does not allow in place. This
+// happens when spec.service.type changes to or from ClusterIP.
+func (r *KeydbReconciler) recreateOnHeadlessChange(ctx context.Context, desired *corev1.Service) error {
+	var existing corev1.Service
+	err := r.Get(ctx, types.NamespacedName{Name: desired.Name, Namespace: desired.Namespace}, &existing)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if (existing.Spec.ClusterIP == corev1.ClusterIPNone) == (desired.Spec.ClusterIP == corev1.ClusterIPNone) {
+		return nil
+	}
+	log.FromContext(ctx).Info("Recreating Service to change its cluster IP", "service", existing.Name)
+	return client.IgnoreNotFound(r.Delete(ctx, &existing))
+}
+
+// reconcileNetworkPolicy applies the NetworkPolicy of spec.networkPolicy, or
+// deletes it once the field is removed.
+func (r *KeydbReconciler) reconcileNetworkPolicy(ctx context.Context, keydb *keydbv1.Keydb) error {
+	if np := k8sresources.GenerateNetworkPolicy(keydb, r.Scheme); np != nil {
+		return r.apply(ctx, keydb, np)
+	}
+
+	var np networkingv1.NetworkPolicy
+	err := r.Get(ctx, types.NamespacedName{Name: keydb.Name, Namespace: keydb.Namespace}, &np)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil || !metav1.IsControlledBy(&np, keydb) {
+		return err
+	}
+	log.FromContext(ctx).Info("Deleting NetworkPolicy", "networkPolicy", np.Name)
+	return client.IgnoreNotFound(r.Delete(ctx, &np))
+}
@@ -0,0 +1,298 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"strings"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/scheme"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	k8sresources "github.com/rsingh0101/keydb-operator/internal/controller/k8s_resources"
+)
+
+var _ = Describe("Pod scheduling", func() {
+	var keydb *keydbv1.Keydb
+
+	BeforeEach(func() {
+		keydb = &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "default"},
+			Spec:       keydbv1.KeydbSpec{Image: keydbv1.DefaultImage},
+		}
+	})
+
+	podSpec := func() corev1.PodSpec {
+		sts, err := k8sresources.GenerateStatefulSet(keydb, runtime.NewScheme(), "controller:latest")
+		Expect(err).NotTo(HaveOccurred())
+		return sts.Spec.Template.Spec
+	}
+
+	It("should prefer spreading pods over nodes by default", func() {
+		affinity := podSpec().Affinity
+		Expect(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+		Expect(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+	})
+
+	It("should add the anti-affinity to custom affinity terms", func() {
+		nodeAffinity := &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{{
+					MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "pool", Operator: corev1.NodeSelectorOpIn, Values: []string{"memory"}},
+					},
+				}},
+			},
+		}
+		keydb.Spec.PodAntiAffinity = keydbv1.PodAntiAffinityRequired
+		keydb.Spec.Affinity = &corev1.Affinity{NodeAffinity: nodeAffinity}
+
+		affinity := podSpec().Affinity
+		Expect(affinity.NodeAffinity).To(Equal(nodeAffinity))
+		Expect(affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution).To(HaveLen(1))
+		Expect(affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution).To(BeEmpty())
+		Expect(keydb.Spec.Affinity.PodAntiAffinity).To(BeNil())
+
+		keydb.Spec.PodAntiAffinity = keydbv1.PodAntiAffinityNone
+		Expect(podSpec().Affinity.PodAntiAffinity).To(BeNil())
+	})
+
+	It("should select the Keydb pods in spread constraints without a selector", func() {
+		keydb.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{{
+			MaxSkew:           1,
+			TopologyKey:       "topology.kubernetes.io/zone",
+			WhenUnsatisfiable: corev1.DoNotSchedule,
+		}}
+		constraints := podSpec().TopologySpreadConstraints
+		Expect(constraints).To(HaveLen(1))
+		Expect(constraints[0].LabelSelector.MatchLabels).To(Equal(map[string]string{"apps": "keydb"}))
+		Expect(keydb.Spec.TopologySpreadConstraints[0].LabelSelector).To(BeNil())
+	})
+})
+
+var _ = Describe("Pod template overrides", func() {
+	It("should merge containers, env and volumes by name", func() {
+		keydb := &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "default"},
+			Spec: keydbv1.KeydbSpec{
+				Image: keydbv1.DefaultImage,
+				PodTemplate: &keydbv1.PodTemplateOverride{
+					Labels:      map[string]string{"apps": "other", "cost-center": "cache"},
+					Annotations: map[string]string{"sidecar.istio.io/inject": "false"},
+					Containers: []corev1.Container{
+						{Name: "log-shipper", Image: "fluent/fluent-bit"},
+						{Name: "keydb", ImagePullPolicy: corev1.PullAlways},
+					},
+					Volumes:            []corev1.Volume{{Name: "logs", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}},
+					VolumeMounts:       []corev1.VolumeMount{{Name: "logs", MountPath: "/logs"}},
+					Env:                []corev1.EnvVar{{Name: "BITNAMI_DEBUG", Value: "true"}},
+					ServiceAccountName: "platform",
+				},
+			},
+		}
+		sts, err := k8sresources.GenerateStatefulSet(keydb, runtime.NewScheme(), "controller:latest")
+		Expect(err).NotTo(HaveOccurred())
+		template := sts.Spec.Template
+
+		Expect(template.Labels).To(Equal(map[string]string{
+			"apps": "keydb", keydbv1.LabelTraffic: keydbv1.TrafficEnabled, "cost-center": "cache",
+		}))
+		Expect(template.Annotations).To(HaveKeyWithValue("sidecar.istio.io/inject", "false"))
+		Expect(template.Spec.ServiceAccountName).To(Equal("platform"))
+		Expect(template.Spec.Volumes).To(ContainElement(HaveField("Name", "logs")))
+
+		names := []string{}
+		for _, c := range template.Spec.Containers {
+			names = append(names, c.Name)
+		}
+		Expect(names).To(Equal([]string{"keydb", "config-reloader", "log-shipper"}))
+
+		keydbContainer := template.Spec.Containers[0]
+		Expect(keydbContainer.Image).To(Equal(keydbv1.DefaultImage))
+		Expect(keydbContainer.ImagePullPolicy).To(Equal(corev1.PullAlways))
+		Expect(keydbContainer.VolumeMounts).To(ContainElement(corev1.VolumeMount{Name: "logs", MountPath: "/logs"}))
+		Expect(keydbContainer.Env).To(ContainElement(corev1.EnvVar{Name: "BITNAMI_DEBUG", Value: "true"}))
+		Expect(keydbContainer.Env).To(ContainElement(HaveField("Name", "KEYDB_PASSWORD_FILE")))
+	})
+})
+
+var _ = Describe("Pod security context", func() {
+	var keydb *keydbv1.Keydb
+
+	BeforeEach(func() {
+		keydb = &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "default"},
+			Spec: keydbv1.KeydbSpec{
+				Image:   keydbv1.DefaultImage,
+				Metrics: keydbv1.MetricsSpec{Enabled: true},
+				Restore: &keydbv1.RestoreSpec{
+					PersistentVolumeClaim: &keydbv1.PVCRestoreSource{ClaimName: "old-data"},
+				},
+			},
+		}
+	})
+
+	podSpec := func() corev1.PodSpec {
+		sts, err := k8sresources.GenerateStatefulSet(keydb, runtime.NewScheme(), "controller:latest")
+		Expect(err).NotTo(HaveOccurred())
+		return sts.Spec.Template.Spec
+	}
+
+	It("should meet the restricted Pod Security Standard by default", func() {
+		spec := podSpec()
+		Expect(*spec.SecurityContext.RunAsNonRoot).To(BeTrue())
+		Expect(*spec.SecurityContext.FSGroup).To(Equal(keydbv1.DefaultUID))
+		Expect(spec.SecurityContext.SeccompProfile.Type).To(Equal(corev1.SeccompProfileTypeRuntimeDefault))
+
+		containers := append(spec.InitContainers, spec.Containers...)
+		Expect(containers).To(HaveLen(4))
+		for _, c := range containers {
+			Expect(*c.SecurityContext.RunAsUser).To(Equal(keydbv1.DefaultUID), c.Name)
+			Expect(*c.SecurityContext.AllowPrivilegeEscalation).To(BeFalse(), c.Name)
+			Expect(*c.SecurityContext.ReadOnlyRootFilesystem).To(BeTrue(), c.Name)
+			Expect(c.SecurityContext.Capabilities.Drop).To(ConsistOf(corev1.Capability("ALL")), c.Name)
+		}
+	})
+
+	It("should use the identity and seccomp profile from the spec", func() {
+		keydb.Spec.SecurityContext = &keydbv1.SecurityContextSpec{
+			RunAsUser:              &[]int64{999}[0],
+			RunAsGroup:             &[]int64{999}[0],
+			FSGroup:                &[]int64{999}[0],
+			SeccompProfile:         &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeLocalhost, LocalhostProfile: &[]string{"keydb.json"}[0]},
+			ReadOnlyRootFilesystem: &[]bool{false}[0],
+		}
+
+		spec := podSpec()
+		Expect(*spec.SecurityContext.FSGroup).To(Equal(int64(999)))
+		Expect(spec.SecurityContext.SeccompProfile).To(Equal(keydb.Spec.SecurityContext.SeccompProfile))
+		for _, c := range spec.Containers {
+			Expect(*c.SecurityContext.RunAsUser).To(Equal(int64(999)), c.Name)
+			Expect(*c.SecurityContext.RunAsGroup).To(Equal(int64(999)), c.Name)
+			Expect(*c.SecurityContext.ReadOnlyRootFilesystem).To(BeFalse(), c.Name)
+		}
+	})
+})
+
+var _ = Describe("Image flavors", func() {
+	var keydb *keydbv1.Keydb
+
+	BeforeEach(func() {
+		keydb = &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "default"},
+			Spec: keydbv1.KeydbSpec{
+				ImageFlavor: keydbv1.ImageFlavorUpstream,
+				Persistence: keydbv1.PersistenceSpec{Enabled: true, Size: "1Gi"},
+			},
+		}
+	})
+
+	keydbContainer := func() corev1.Container {
+		sts, err := k8sresources.GenerateStatefulSet(keydb, runtime.NewScheme(), "controller:latest")
+		Expect(err).NotTo(HaveOccurred())
+		return sts.Spec.Template.Spec.Containers[0]
+	}
+
+	mountPaths := func(c corev1.Container) map[string]string {
+		paths := map[string]string{}
+		for _, m := range c.VolumeMounts {
+			paths[m.Name] = m.MountPath
+		}
+		return paths
+	}
+
+	It("should use the upstream layout without the Bitnami scripts", func() {
+		c := keydbContainer()
+		Expect(c.Image).To(Equal(keydbv1.DefaultUpstreamImage))
+		Expect(mountPaths(c)).To(HaveKeyWithValue("keydb-config", "/etc/keydb"))
+		Expect(mountPaths(c)).To(HaveKeyWithValue("data-keydb-pvc", "/data"))
+		Expect(c.Args[1]).To(ContainSubstring(`KEYDB_PASSWORD="$(cat "$KEYDB_PASSWORD_FILE")"`))
+		Expect(c.Args[1]).To(ContainSubstring(`"${KEYDB_MODIFIED_CONFIG:-/etc/keydb/keydb.conf}"`))
+		Expect(c.Args[1]).To(ContainSubstring("[ -f /data/.keydb-load-rdb ]"))
+		Expect(c.Args[1]).NotTo(ContainSubstring("bitnami/scripts/keydb-env.sh"))
+
+		cms, err := k8sresources.GenerateKeydbConfigMap(keydb, scheme.Scheme)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(cms[0].Data["keydb.conf"]).To(ContainSubstring("dir /data\n"))
+		for name, script := range cms[1].Data {
+			Expect(script).NotTo(ContainSubstring("/opt/bitnami/scripts/"), name)
+		}
+	})
+
+	It("should only start without AOF on data a restore just seeded", func() {
+		keydb.Spec.ImageFlavor = keydbv1.ImageFlavorBitnami
+		Expect(keydbContainer().Args[1]).NotTo(ContainSubstring("dump.rdb"))
+
+		keydb.Spec.Restore = &keydbv1.RestoreSpec{S3: &keydbv1.S3RestoreSource{Bucket: "backups", Key: "dump.rdb"}}
+		sts, err := k8sresources.GenerateStatefulSet(keydb, runtime.NewScheme(), "controller:latest")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(sts.Spec.Template.Spec.Containers[0].Args[1]).
+			To(ContainSubstring(`if [ -f /bitnami/keydb/data/.keydb-load-rdb ]; then`))
+		var restoreScript string
+		for _, c := range sts.Spec.Template.Spec.InitContainers {
+			if c.Name == k8sresources.RestoreContainer {
+				restoreScript = strings.Join(append(c.Command, c.Args...), " ")
+			}
+		}
+		Expect(restoreScript).To(ContainSubstring(`touch "$DATA/.keydb-load-rdb" "$MARKER"`))
+	})
+
+	It("should use the layout of a custom image", func() {
+		keydb.Spec.ImageFlavor = keydbv1.ImageFlavorCustom
+		keydb.Spec.Image = "registry.example.com/keydb:hardened"
+		keydb.Spec.ImageProfile = &keydbv1.ImageProfileSpec{
+			Server:    "/usr/bin/keydb-server",
+			DataDir:   "/var/lib/keydb",
+			EnvScript: "/etc/profile.d/keydb.sh",
+		}
+
+		c := keydbContainer()
+		Expect(c.Image).To(Equal("registry.example.com/keydb:hardened"))
+		Expect(mountPaths(c)).To(HaveKeyWithValue("keydb-config", "/etc/keydb"))
+		Expect(mountPaths(c)).To(HaveKeyWithValue("data-keydb-pvc", "/var/lib/keydb"))
+		Expect(c.Args[1]).To(HavePrefix(". /etc/profile.d/keydb.sh\n"))
+		Expect(c.Args[1]).To(HaveSuffix(`exec /usr/bin/keydb-server "${args[@]}"`))
+	})
+})
+
+var _ = Describe("Metrics exporter", func() {
+	It("should read the password the config reloader keeps in sync", func() {
+		keydb := &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "default"},
+			Spec:       keydbv1.KeydbSpec{Metrics: keydbv1.MetricsSpec{Enabled: true}},
+		}
+		sts, err := k8sresources.GenerateStatefulSet(keydb, runtime.NewScheme(), "controller:latest")
+		Expect(err).NotTo(HaveOccurred())
+
+		containers := map[string]corev1.Container{}
+		for _, c := range sts.Spec.Template.Spec.Containers {
+			containers[c.Name] = c
+		}
+		reloader, exporter := containers["config-reloader"], containers["metrics"]
+		Expect(exporter.Env).NotTo(ContainElement(HaveField("Name", "REDIS_PASSWORD")))
+		Expect(exporter.Env).To(ContainElement(corev1.EnvVar{
+			Name: "REDIS_PASSWORD_FILE", Value: "/opt/keydb-exporter/password.json",
+		}))
+		Expect(reloader.Args).To(ContainElement("--exporter-password-file=/opt/keydb-exporter/password.json"))
+		Expect(reloader.VolumeMounts).To(ContainElement(exporter.VolumeMounts[0]))
+		Expect(sts.Spec.Template.Annotations).NotTo(HaveKey("checksum/secret"))
+	})
+})
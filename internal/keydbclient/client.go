@@ -0,0 +1,354 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package keydbclient is a small RESP client the operator uses to talk to
+// KeyDB pods directly, instead of shelling out to keydb-cli.
+package keydbclient
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	defaultDialTimeout = 5 * time.Second
+	defaultIOTimeout   = 10 * time.Second
+)
+
+// Options configures a connection to a single KeyDB server.
+type Options struct {
+	// Addr is the host:port of the server.
+	Addr string
+	// Username is sent with AUTH when set; otherwise the default user is used.
+	Username string
+	// Password is sent with AUTH when set.
+	Password string
+	// TLSConfig enables TLS when set.
+	TLSConfig *tls.Config
+	// DialTimeout bounds connection setup. Defaults to 5s.
+	DialTimeout time.Duration
+	// IOTimeout bounds each command when the context has no deadline. Defaults to 10s.
+	IOTimeout time.Duration
+}
+
+// VerifyChain returns a tls.Config VerifyConnection function that checks the
+// server certificate chains to roots, without checking the host name. The
+// operator dials pods by IP and the config reloader by localhost, which a
+// certificate only has to cover the Service and pod DNS names of. Use it
+// with InsecureSkipVerify, which turns off the default verification.
+func VerifyChain(roots *x509.CertPool) func(tls.ConnectionState) error {
+	return func(state tls.ConnectionState) error {
+		if len(state.PeerCertificates) == 0 {
+			return errors.New("server presented no certificate")
+		}
+		intermediates := x509.NewCertPool()
+		for _, c := range state.PeerCertificates[1:] {
+			intermediates.AddCert(c)
+		}
+		_, err := state.PeerCertificates[0].Verify(x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: intermediates,
+		})
+		return err
+	}
+}
+
+// Client is a single connection to a KeyDB server. It is not safe for
+// concurrent use; use a Pool to share connections.
+type Client struct {
+	opts   Options
+	conn   net.Conn
+	reader *bufio.Reader
+	writer *bufio.Writer
+	// broken is set after an I/O error so the pool does not reuse the connection
+	broken bool
+	// sent counts the commands written, and replied records whether any reply
+	// was read, since the pool last handed the connection out
+	sent    int
+	replied bool
+}
+
+// Dial connects to opts.Addr and authenticates.
+func Dial(ctx context.Context, opts Options) (*Client, error) {
+	if opts.DialTimeout == 0 {
+		opts.DialTimeout = defaultDialTimeout
+	}
+	if opts.IOTimeout == 0 {
+		opts.IOTimeout = defaultIOTimeout
+	}
+
+	dialer := &net.Dialer{Timeout: opts.DialTimeout}
+	var conn net.Conn
+	var err error
+	if opts.TLSConfig != nil {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: opts.TLSConfig}).DialContext(ctx, "tcp", opts.Addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", opts.Addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		opts:   opts,
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		writer: bufio.NewWriter(conn),
+	}
+
+	if opts.Password != "" {
+		args := []string{"AUTH", opts.Password}
+		if opts.Username != "" {
+			args = []string{"AUTH", opts.Username, opts.Password}
+		}
+		if _, err := c.Do(ctx, args...); err != nil {
+			_ = c.Close()
+			return nil, fmt.Errorf("auth to %s: %w", opts.Addr, err)
+		}
+	}
+
+	return c, nil
+}
+
+// Addr returns the address the client is connected to.
+func (c *Client) Addr() string {
+	return c.opts.Addr
+}
+
+// Close closes the underlying connection.
+func (c *Client) Close() error {
+	c.broken = true
+	return c.conn.Close()
+}
+
+// Do sends a command and returns the decoded reply.
+func (c *Client) Do(ctx context.Context, args ...string) (interface{}, error) {
+	if len(args) == 0 {
+		return nil, errors.New("keydbclient: empty command")
+	}
+	if c.broken {
+		return nil, errors.New("keydbclient: connection is closed")
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(c.opts.IOTimeout)
+	}
+	if err := c.conn.SetDeadline(deadline); err != nil {
+		c.broken = true
+		return nil, err
+	}
+
+	c.sent++
+	if err := WriteCommand(c.writer, args...); err != nil {
+		c.broken = true
+		return nil, err
+	}
+	reply, err := ReadReply(c.reader)
+	if err != nil {
+		var replyErr Error
+		if !errors.As(err, &replyErr) {
+			c.broken = true
+			return nil, err
+		}
+		c.replied = true
+		return nil, err
+	}
+	c.replied = true
+	return reply, nil
+}
+
+// stale reports whether err shows the server had closed the connection before
+// the first command since checkout reached it, so that command did not run.
+// Timeouts never count: the command may have run.
+func (c *Client) stale(err error) bool {
+	if c.sent != 1 || c.replied {
+		return false
+	}
+	return errors.Is(err, io.EOF) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE)
+}
+
+// String runs a command whose reply is a simple or bulk string.
+func (c *Client) String(ctx context.Context, args ...string) (string, error) {
+	reply, err := c.Do(ctx, args...)
+	if err != nil {
+		return "", err
+	}
+	s, ok := reply.(string)
+	if !ok {
+		return "", fmt.Errorf("keydbclient: unexpected reply %T to %s", reply, args[0])
+	}
+	return s, nil
+}
+
+// ok runs a command that is expected to reply +OK.
+func (c *Client) ok(ctx context.Context, args ...string) error {
+	s, err := c.String(ctx, args...)
+	if err != nil {
+		return err
+	}
+	if s != "OK" {
+		return fmt.Errorf("keydbclient: unexpected reply %q to %s", s, args[0])
+	}
+	return nil
+}
+
+// Ping checks that the server answers.
+func (c *Client) Ping(ctx context.Context) error {
+	_, err := c.String(ctx, "PING")
+	return err
+}
+
+// Info runs INFO for the given sections and returns the parsed key/value pairs.
+func (c *Client) Info(ctx context.Context, sections ...string) (map[string]string, error) {
+	s, err := c.String(ctx, append([]string{"INFO"}, sections...)...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseInfo(s), nil
+}
+
+// ConfigGet returns the current value of a configuration directive.
+func (c *Client) ConfigGet(ctx context.Context, key string) (string, error) {
+	reply, err := c.Do(ctx, "CONFIG", "GET", key)
+	if err != nil {
+		return "", err
+	}
+	items, ok := reply.([]interface{})
+	if !ok || len(items) < 2 {
+		return "", fmt.Errorf("keydbclient: unknown config directive %q", key)
+	}
+	value, _ := items[1].(string)
+	return value, nil
+}
+
+// ConfigSet changes a configuration directive at runtime.
+func (c *Client) ConfigSet(ctx context.Context, key, value string) error {
+	return c.ok(ctx, "CONFIG", "SET", key, value)
+}
+
+// ReplicaOf makes the server replicate from host:port. A server that already
+// does answers "OK Already connected to specified master", which succeeds too.
+func (c *Client) ReplicaOf(ctx context.Context, host string, port int32) error {
+	s, err := c.String(ctx, "REPLICAOF", host, strconv.Itoa(int(port)))
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(s, "OK") {
+		return fmt.Errorf("keydbclient: unexpected reply %q to REPLICAOF", s)
+	}
+	return nil
+}
+
+// ReplicaOfNoOne promotes the server to a primary.
+func (c *Client) ReplicaOfNoOne(ctx context.Context) error {
+	return c.ok(ctx, "REPLICAOF", "NO", "ONE")
+}
+
+// ClientPause suspends clients for timeout. With writeOnly set, only commands
+// that may modify data are held back.
+func (c *Client) ClientPause(ctx context.Context, timeout time.Duration, writeOnly bool) error {
+	args := []string{"CLIENT", "PAUSE", strconv.FormatInt(timeout.Milliseconds(), 10)}
+	if writeOnly {
+		args = append(args, "WRITE")
+	}
+	return c.ok(ctx, args...)
+}
+
+// ClientUnpause resumes clients suspended by ClientPause.
+func (c *Client) ClientUnpause(ctx context.Context) error {
+	return c.ok(ctx, "CLIENT", "UNPAUSE")
+}
+
+// BgSave starts a background RDB snapshot.
+func (c *Client) BgSave(ctx context.Context) error {
+	s, err := c.String(ctx, "BGSAVE")
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(s, "Background saving started") && s != "OK" {
+		return fmt.Errorf("keydbclient: unexpected reply %q to BGSAVE", s)
+	}
+	return nil
+}
+
+// LastSave returns the unix time of the last successful RDB snapshot.
+func (c *Client) LastSave(ctx context.Context) (int64, error) {
+	reply, err := c.Do(ctx, "LASTSAVE")
+	if err != nil {
+		return 0, err
+	}
+	n, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("keydbclient: unexpected reply %T to LASTSAVE", reply)
+	}
+	return n, nil
+}
+
+// ACLSetUser creates or modifies a user with the given ACL rules.
+func (c *Client) ACLSetUser(ctx context.Context, username string, rules ...string) error {
+	return c.ok(ctx, append([]string{"ACL", "SETUSER", username}, rules...)...)
+}
+
+// ACLDelUser deletes users. Deleting a user that does not exist is not an error.
+func (c *Client) ACLDelUser(ctx context.Context, usernames ...string) error {
+	_, err := c.Do(ctx, append([]string{"ACL", "DELUSER"}, usernames...)...)
+	return err
+}
+
+// ACLList returns the users and rules in ACL file format.
+func (c *Client) ACLList(ctx context.Context) ([]string, error) {
+	reply, err := c.Do(ctx, "ACL", "LIST")
+	if err != nil {
+		return nil, err
+	}
+	items, ok := reply.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("keydbclient: unexpected reply %T to ACL LIST", reply)
+	}
+	users := make([]string, 0, len(items))
+	for _, item := range items {
+		if s, ok := item.(string); ok {
+			users = append(users, s)
+		}
+	}
+	return users, nil
+}
+
+// ParseInfo turns the "key:value" lines of an INFO reply into a map.
+func ParseInfo(reply string) map[string]string {
+	info := map[string]string{}
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, ":"); ok {
+			info[key] = value
+		}
+	}
+	return info
+}
@@ -0,0 +1,145 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keydbclient_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient/fake"
+)
+
+var _ = Describe("Client", func() {
+	var (
+		ctx    context.Context
+		server *fake.Server
+		client *keydbclient.Client
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		server, err = fake.NewServer()
+		Expect(err).NotTo(HaveOccurred())
+		server.SetPassword("s3cr3t")
+		DeferCleanup(server.Close)
+
+		client, err = keydbclient.Dial(ctx, keydbclient.Options{Addr: server.Addr(), Password: "s3cr3t"})
+		Expect(err).NotTo(HaveOccurred())
+		DeferCleanup(client.Close)
+	})
+
+	It("should reject a wrong password", func() {
+		_, err := keydbclient.Dial(ctx, keydbclient.Options{Addr: server.Addr(), Password: "wrong"})
+		Expect(err).To(MatchError(ContainSubstring("WRONGPASS")))
+	})
+
+	It("should fail commands before AUTH", func() {
+		c, err := keydbclient.Dial(ctx, keydbclient.Options{Addr: server.Addr()})
+		Expect(err).NotTo(HaveOccurred())
+		defer c.Close() //nolint:errcheck
+
+		err = c.Ping(ctx)
+		var replyErr keydbclient.Error
+		Expect(errors.As(err, &replyErr)).To(BeTrue())
+		Expect(string(replyErr)).To(HavePrefix("NOAUTH"))
+	})
+
+	It("should parse INFO replication", func() {
+		server.SetReplication("keydb-0.keydb-headless.default.svc.cluster.local", "6379", 42)
+
+		info, err := client.Info(ctx, "replication")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(HaveKeyWithValue("role", "slave"))
+		Expect(info).To(HaveKeyWithValue("master_host", "keydb-0.keydb-headless.default.svc.cluster.local"))
+		Expect(info).To(HaveKeyWithValue("slave_repl_offset", "42"))
+		Expect(server.Commands()).To(ContainElement([]string{"INFO", "replication"}))
+	})
+
+	It("should change replication with REPLICAOF", func() {
+		Expect(client.ReplicaOf(ctx, "keydb-1.keydb-headless", 6379)).To(Succeed())
+		info, err := client.Info(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(HaveKeyWithValue("master_host", "keydb-1.keydb-headless"))
+
+		Expect(client.ReplicaOfNoOne(ctx)).To(Succeed())
+		info, err = client.Info(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(info).To(HaveKeyWithValue("role", "master"))
+	})
+
+	It("should set and get configuration", func() {
+		Expect(client.ConfigSet(ctx, "appendonly", "yes")).To(Succeed())
+		Expect(server.Config("appendonly")).To(Equal("yes"))
+
+		value, err := client.ConfigGet(ctx, "appendonly")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(value).To(Equal("yes"))
+
+		_, err = client.ConfigGet(ctx, "no-such-directive")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should start a background save", func() {
+		before, err := client.LastSave(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(client.BgSave(ctx)).To(Succeed())
+		after, err := client.LastSave(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(after).To(BeNumerically(">", before))
+	})
+
+	It("should pause and resume writes", func() {
+		Expect(client.ClientPause(ctx, time.Minute, true)).To(Succeed())
+		Expect(server.Paused()).To(BeTrue())
+		Expect(server.Commands()).To(ContainElement([]string{"CLIENT", "PAUSE", "60000", "WRITE"}))
+
+		Expect(client.ClientUnpause(ctx)).To(Succeed())
+		Expect(server.Paused()).To(BeFalse())
+	})
+
+	It("should manage ACL users", func() {
+		Expect(client.ACLSetUser(ctx, "app", "on", ">pw", "~*", "+@read")).To(Succeed())
+		Expect(server.User("app")).To(Equal([]string{"on", ">pw", "~*", "+@read"}))
+
+		users, err := client.ACLList(ctx)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(users).To(ContainElement("user app on >pw ~* +@read"))
+
+		Expect(client.ACLDelUser(ctx, "app")).To(Succeed())
+		Expect(server.User("app")).To(BeNil())
+	})
+
+	It("should keep the connection usable after an error reply", func() {
+		_, err := client.Do(ctx, "NOSUCHCOMMAND")
+		var replyErr keydbclient.Error
+		Expect(errors.As(err, &replyErr)).To(BeTrue())
+		Expect(client.Ping(ctx)).To(Succeed())
+	})
+})
+
+var _ = Describe("ParseInfo", func() {
+	It("should skip section headers and blank lines", func() {
+		info := keydbclient.ParseInfo("# Replication\r\nrole:master\r\n\r\nconnected_slaves:2\r\n")
+		Expect(info).To(Equal(map[string]string{"role": "master", "connected_slaves": "2"}))
+	})
+})
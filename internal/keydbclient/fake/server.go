@@ -0,0 +1,419 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package fake provides an in-process RESP server that emulates the subset of
+// KeyDB the operator uses, for unit tests.
+package fake
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+)
+
+// HandlerFunc answers a command. The returned value is encoded as a reply:
+// string as a bulk string, int64 as an integer, []string as an array, nil as
+// a null bulk string, and keydbclient.Error as an error reply.
+type HandlerFunc func(args []string) interface{}
+
+// Server is a fake KeyDB server listening on a random localhost port.
+type Server struct {
+	mu         sync.Mutex
+	password   string
+	listener   net.Listener
+	handlers   map[string]HandlerFunc
+	commands   [][]string
+	conns      int
+	open       map[net.Conn]struct{}
+	config     map[string]string
+	users      map[string][]string
+	masterHost string
+	masterPort string
+	replOffset int64
+	// linkDown is how long the link to the primary has been down, or -1
+	linkDown int
+	lastSave int64
+	paused   bool
+	closed   chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewServer starts a fake server. Close must be called to stop it.
+func NewServer() (*Server, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{
+		listener: l,
+		handlers: map[string]HandlerFunc{},
+		config:   map[string]string{},
+		users:    map[string][]string{"default": {"on", "nopass", "~*", "&*", "+@all"}},
+		open:     map[net.Conn]struct{}{},
+		lastSave: 1,
+		linkDown: -1,
+		closed:   make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// Addr returns the host:port the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server and closes client connections.
+func (s *Server) Close() {
+	close(s.closed)
+	_ = s.listener.Close()
+	s.wg.Wait()
+}
+
+// Handle overrides the reply to a command (matched case-insensitively on the first argument).
+func (s *Server) Handle(command string, fn HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[strings.ToUpper(command)] = fn
+}
+
+// SetPassword requires AUTH with password on new connections. An empty
+// password disables authentication.
+func (s *Server) SetPassword(password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.password = password
+}
+
+// SetReplication sets the replication state reported by INFO.
+func (s *Server) SetReplication(masterHost, masterPort string, offset int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.masterHost, s.masterPort, s.replOffset = masterHost, masterPort, offset
+	s.linkDown = -1
+}
+
+// SetLinkDown reports the link to the primary as down for seconds in INFO,
+// until the next SetReplication or REPLICAOF.
+func (s *Server) SetLinkDown(seconds int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.linkDown = seconds
+}
+
+// Commands returns every command received, excluding AUTH.
+func (s *Server) Commands() [][]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([][]string(nil), s.commands...)
+}
+
+// Connections returns how many connections were accepted.
+func (s *Server) Connections() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.conns
+}
+
+// DropConnections closes every open client connection, like a restarted
+// server would.
+func (s *Server) DropConnections() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.open {
+		_ = conn.Close()
+	}
+}
+
+// Config returns the value set for a directive through CONFIG SET.
+func (s *Server) Config(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config[key]
+}
+
+// User returns the ACL rules applied to a user, or nil if it does not exist.
+func (s *Server) User(name string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.users[name]
+}
+
+// Paused reports whether clients are paused by CLIENT PAUSE.
+func (s *Server) Paused() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.paused
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns++
+		s.open[conn] = struct{}{}
+		s.mu.Unlock()
+		s.wg.Add(1)
+		go s.handleConn(conn)
+	}
+}
+
+func (s *Server) handleConn(conn net.Conn) {
+	defer s.wg.Done()
+	go func() {
+		<-s.closed
+		_ = conn.Close()
+	}()
+	defer func() {
+		s.mu.Lock()
+		delete(s.open, conn)
+		s.mu.Unlock()
+		_ = conn.Close()
+	}()
+
+	reader := bufio.NewReader(conn)
+	writer := bufio.NewWriter(conn)
+	s.mu.Lock()
+	password := s.password
+	s.mu.Unlock()
+	authenticated := password == ""
+	for {
+		req, err := keydbclient.ReadReply(reader)
+		if err != nil {
+			return
+		}
+		items, ok := req.([]interface{})
+		if !ok || len(items) == 0 {
+			return
+		}
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+
+		var reply interface{}
+		switch {
+		case strings.EqualFold(args[0], "AUTH"):
+			if args[len(args)-1] == password {
+				authenticated = true
+				reply = "+OK"
+			} else {
+				reply = keydbclient.Error("WRONGPASS invalid username-password pair or user is disabled.")
+			}
+		case !authenticated:
+			reply = keydbclient.Error("NOAUTH Authentication required.")
+		default:
+			reply = s.dispatch(args)
+		}
+
+		if err := writeReply(writer, reply); err != nil {
+			return
+		}
+		if err := writer.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+func (s *Server) dispatch(args []string) interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.commands = append(s.commands, args)
+
+	command := strings.ToUpper(args[0])
+	if fn, ok := s.handlers[command]; ok {
+		return fn(args)
+	}
+
+	switch command {
+	case "PING":
+		return "+PONG"
+	case "INFO":
+		return s.info()
+	case "CONFIG":
+		return s.configCommand(args)
+	case "REPLICAOF", "SLAVEOF":
+		if len(args) != 3 {
+			return keydbclient.Error("ERR wrong number of arguments for 'replicaof' command")
+		}
+		if strings.EqualFold(args[1], "NO") && strings.EqualFold(args[2], "ONE") {
+			s.masterHost, s.masterPort = "", ""
+		} else if s.masterHost == args[1] && s.masterPort == args[2] {
+			return "+OK Already connected to specified master"
+		} else {
+			s.masterHost, s.masterPort = args[1], args[2]
+		}
+		s.linkDown = -1
+		return "+OK"
+	case "BGSAVE":
+		s.lastSave++
+		return "+Background saving started"
+	case "LASTSAVE":
+		return s.lastSave
+	case "ACL":
+		return s.aclCommand(args)
+	case "CLIENT":
+		return s.clientCommand(args)
+	default:
+		return keydbclient.Error(fmt.Sprintf("ERR unknown command '%s'", args[0]))
+	}
+}
+
+func (s *Server) info() string {
+	var b strings.Builder
+	b.WriteString("# Server\r\nredis_version:6.3.4\r\n")
+	b.WriteString("# Memory\r\nused_memory_human:1.00M\r\n")
+	b.WriteString("# Replication\r\n")
+	if s.masterHost == "" {
+		b.WriteString("role:master\r\nconnected_slaves:0\r\n")
+	} else {
+		fmt.Fprintf(&b, "role:slave\r\nmaster_host:%s\r\nmaster_port:%s\r\n", s.masterHost, s.masterPort)
+		if s.linkDown < 0 {
+			b.WriteString("master_link_status:up\r\n")
+		} else {
+			fmt.Fprintf(&b, "master_link_status:down\r\nmaster_link_down_since_seconds:%d\r\n", s.linkDown)
+		}
+		fmt.Fprintf(&b, "master_last_io_seconds_ago:0\r\nslave_repl_offset:%d\r\n", s.replOffset)
+	}
+	fmt.Fprintf(&b, "master_repl_offset:%d\r\n", s.replOffset)
+	fmt.Fprintf(&b, "# Persistence\r\nloading:0\r\nrdb_bgsave_in_progress:0\r\nrdb_last_save_time:%d\r\nrdb_last_bgsave_status:ok\r\n", s.lastSave)
+	aofEnabled := 1
+	if s.config["appendonly"] == "no" {
+		aofEnabled = 0
+	}
+	fmt.Fprintf(&b, "aof_enabled:%d\r\n", aofEnabled)
+	return b.String()
+}
+
+func (s *Server) configCommand(args []string) interface{} {
+	if len(args) < 3 {
+		return keydbclient.Error("ERR wrong number of arguments for 'config' command")
+	}
+	switch strings.ToUpper(args[1]) {
+	case "GET":
+		value, ok := s.config[args[2]]
+		if !ok {
+			return []string{}
+		}
+		return []string{args[2], value}
+	case "SET":
+		if len(args) != 4 {
+			return keydbclient.Error("ERR wrong number of arguments for 'config set' command")
+		}
+		s.config[args[2]] = args[3]
+		return "+OK"
+	default:
+		return keydbclient.Error("ERR unknown subcommand '" + args[1] + "'")
+	}
+}
+
+func (s *Server) clientCommand(args []string) interface{} {
+	if len(args) < 2 {
+		return keydbclient.Error("ERR wrong number of arguments for 'client' command")
+	}
+	switch strings.ToUpper(args[1]) {
+	case "PAUSE":
+		if len(args) < 3 {
+			return keydbclient.Error("ERR wrong number of arguments for 'client pause' command")
+		}
+		s.paused = true
+		return "+OK"
+	case "UNPAUSE":
+		s.paused = false
+		return "+OK"
+	default:
+		return keydbclient.Error("ERR unknown subcommand '" + args[1] + "'")
+	}
+}
+
+func (s *Server) aclCommand(args []string) interface{} {
+	if len(args) < 2 {
+		return keydbclient.Error("ERR wrong number of arguments for 'acl' command")
+	}
+	switch strings.ToUpper(args[1]) {
+	case "SETUSER":
+		if len(args) < 3 {
+			return keydbclient.Error("ERR wrong number of arguments for 'acl setuser' command")
+		}
+		s.users[args[2]] = append(s.users[args[2]], args[3:]...)
+		return "+OK"
+	case "DELUSER":
+		var deleted int64
+		for _, name := range args[2:] {
+			if _, ok := s.users[name]; ok && name != "default" {
+				delete(s.users, name)
+				deleted++
+			}
+		}
+		return deleted
+	case "LIST":
+		names := make([]string, 0, len(s.users))
+		for name := range s.users {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		list := make([]string, 0, len(names))
+		for _, name := range names {
+			list = append(list, "user "+name+" "+strings.Join(s.users[name], " "))
+		}
+		return list
+	default:
+		return keydbclient.Error("ERR unknown subcommand '" + args[1] + "'")
+	}
+}
+
+// writeReply encodes a handler result. Strings starting with "+" are sent as
+// simple strings, other strings as bulk strings.
+func writeReply(w *bufio.Writer, reply interface{}) error {
+	var err error
+	switch v := reply.(type) {
+	case nil:
+		_, err = w.WriteString("$-1\r\n")
+	case keydbclient.Error:
+		_, err = fmt.Fprintf(w, "-%s\r\n", string(v))
+	case string:
+		if strings.HasPrefix(v, "+") {
+			_, err = fmt.Fprintf(w, "%s\r\n", v)
+		} else {
+			_, err = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(v), v)
+		}
+	case int64:
+		_, err = fmt.Fprintf(w, ":%d\r\n", v)
+	case []string:
+		if _, err = fmt.Fprintf(w, "*%d\r\n", len(v)); err != nil {
+			return err
+		}
+		for _, item := range v {
+			if _, err = fmt.Fprintf(w, "$%d\r\n%s\r\n", len(item), item); err != nil {
+				return err
+			}
+		}
+	default:
+		err = errors.New("fake: unsupported reply type " + fmt.Sprintf("%T", reply))
+	}
+	return err
+}
@@ -0,0 +1,137 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keydbclient
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultMaxIdlePerAddr = 2
+
+// Pool keeps idle connections per cluster and address so repeated reconciles
+// do not dial and AUTH every time. Connections of a cluster are dropped when
+// its credentials or TLS settings change.
+type Pool struct {
+	// MaxIdlePerAddr caps the idle connections kept for each address.
+	MaxIdlePerAddr int
+
+	mu       sync.Mutex
+	clusters map[string]*clusterPool
+}
+
+type clusterPool struct {
+	opts Options
+	idle map[string][]*Client
+}
+
+// NewPool returns an empty pool.
+func NewPool() *Pool {
+	return &Pool{
+		MaxIdlePerAddr: defaultMaxIdlePerAddr,
+		clusters:       map[string]*clusterPool{},
+	}
+}
+
+// Get returns an idle connection to opts.Addr for cluster, or dials a new one.
+// Callers must hand the client back with Put.
+func (p *Pool) Get(ctx context.Context, cluster string, opts Options) (*Client, error) {
+	if c := p.idle(cluster, opts); c != nil {
+		return c, nil
+	}
+	return Dial(ctx, opts)
+}
+
+func (p *Pool) idle(cluster string, opts Options) *Client {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cp := p.clusters[cluster]
+	if cp != nil && !sameCredentials(cp.opts, opts) {
+		closeAll(cp)
+		cp = nil
+	}
+	if cp == nil {
+		cp = &clusterPool{opts: opts, idle: map[string][]*Client{}}
+		p.clusters[cluster] = cp
+	}
+	idle := cp.idle[opts.Addr]
+	if len(idle) == 0 {
+		return nil
+	}
+	cp.idle[opts.Addr] = idle[:len(idle)-1]
+	c := idle[len(idle)-1]
+	c.sent, c.replied = 0, false
+	return c
+}
+
+// Put returns a client to the pool. Broken or surplus connections are closed.
+func (p *Pool) Put(cluster string, c *Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cp := p.clusters[cluster]
+	if c.broken || cp == nil || !sameCredentials(cp.opts, c.opts) || len(cp.idle[c.opts.Addr]) >= p.MaxIdlePerAddr {
+		_ = c.Close()
+		return
+	}
+	cp.idle[c.opts.Addr] = append(cp.idle[c.opts.Addr], c)
+}
+
+// Do runs fn on a pooled connection and returns the connection afterwards.
+// When the server had closed an idle connection, the first command fails
+// without running and fn is retried once on a fresh connection. Any other
+// failure is returned as is, since retrying would repeat commands.
+func (p *Pool) Do(ctx context.Context, cluster string, opts Options, fn func(*Client) error) error {
+	if c := p.idle(cluster, opts); c != nil {
+		err := fn(c)
+		p.Put(cluster, c)
+		if err == nil || !c.stale(err) {
+			return err
+		}
+	}
+
+	c, err := Dial(ctx, opts)
+	if err != nil {
+		return err
+	}
+	defer p.Put(cluster, c)
+	return fn(c)
+}
+
+// Forget closes every idle connection of a cluster, e.g. when it is deleted.
+func (p *Pool) Forget(cluster string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if cp := p.clusters[cluster]; cp != nil {
+		closeAll(cp)
+		delete(p.clusters, cluster)
+	}
+}
+
+func closeAll(cp *clusterPool) {
+	for _, idle := range cp.idle {
+		for _, c := range idle {
+			_ = c.Close()
+		}
+	}
+}
+
+func sameCredentials(a, b Options) bool {
+	return a.Username == b.Username && a.Password == b.Password && a.TLSConfig == b.TLSConfig
+}
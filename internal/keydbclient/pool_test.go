@@ -0,0 +1,116 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keydbclient_test
+
+import (
+	"context"
+	"os"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient/fake"
+)
+
+var _ = Describe("Pool", func() {
+	var (
+		ctx    context.Context
+		server *fake.Server
+		pool   *keydbclient.Pool
+		opts   keydbclient.Options
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		server, err = fake.NewServer()
+		Expect(err).NotTo(HaveOccurred())
+		server.SetPassword("s3cr3t")
+		DeferCleanup(server.Close)
+
+		pool = keydbclient.NewPool()
+		DeferCleanup(pool.Forget, "default/keydb")
+		opts = keydbclient.Options{Addr: server.Addr(), Password: "s3cr3t"}
+	})
+
+	ping := func(o keydbclient.Options) error {
+		return pool.Do(ctx, "default/keydb", o, func(c *keydbclient.Client) error {
+			return c.Ping(ctx)
+		})
+	}
+
+	It("should reuse idle connections", func() {
+		Expect(ping(opts)).To(Succeed())
+		Expect(ping(opts)).To(Succeed())
+		Expect(server.Connections()).To(Equal(1))
+	})
+
+	It("should redial when the password changes", func() {
+		Expect(ping(opts)).To(Succeed())
+		server.SetPassword("rotated")
+		opts.Password = "rotated"
+		Expect(ping(opts)).To(Succeed())
+		Expect(server.Connections()).To(Equal(2))
+	})
+
+	It("should drop connections that failed", func() {
+		Expect(pool.Do(ctx, "default/keydb", opts, func(c *keydbclient.Client) error {
+			return c.Close()
+		})).To(Succeed())
+		Expect(ping(opts)).To(Succeed())
+		Expect(server.Connections()).To(Equal(2))
+	})
+
+	It("should retry once on a fresh connection when an idle one went stale", func() {
+		Expect(ping(opts)).To(Succeed())
+		server.DropConnections()
+		Expect(ping(opts)).To(Succeed())
+		Expect(server.Connections()).To(Equal(2))
+	})
+
+	It("should not retry a command that timed out", func() {
+		opts.IOTimeout = 50 * time.Millisecond
+		Expect(ping(opts)).To(Succeed())
+		server.Handle("PING", func([]string) interface{} {
+			time.Sleep(200 * time.Millisecond)
+			return "PONG"
+		})
+
+		calls := 0
+		err := pool.Do(ctx, "default/keydb", opts, func(c *keydbclient.Client) error {
+			calls++
+			return c.Ping(ctx)
+		})
+		Expect(err).To(MatchError(os.ErrDeadlineExceeded))
+		Expect(calls).To(Equal(1))
+		Expect(server.Connections()).To(Equal(1))
+	})
+
+	It("should not retry error replies", func() {
+		Expect(ping(opts)).To(Succeed())
+		calls := 0
+		err := pool.Do(ctx, "default/keydb", opts, func(c *keydbclient.Client) error {
+			calls++
+			_, err := c.Do(ctx, "NOSUCHCOMMAND")
+			return err
+		})
+		Expect(err).To(BeAssignableToTypeOf(keydbclient.Error("")))
+		Expect(calls).To(Equal(1))
+	})
+})
@@ -0,0 +1,119 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keydbclient
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Error is an error reply sent by the server, e.g. "ERR unknown command".
+// The connection stays usable after an Error.
+type Error string
+
+func (e Error) Error() string { return string(e) }
+
+// errProtocol is returned when the peer sends something that is not RESP.
+var errProtocol = errors.New("keydbclient: protocol error")
+
+// WriteCommand encodes args as a RESP array of bulk strings.
+func WriteCommand(w *bufio.Writer, args ...string) error {
+	if _, err := fmt.Fprintf(w, "*%d\r\n", len(args)); err != nil {
+		return err
+	}
+	for _, arg := range args {
+		if _, err := fmt.Fprintf(w, "$%d\r\n%s\r\n", len(arg), arg); err != nil {
+			return err
+		}
+	}
+	return w.Flush()
+}
+
+// ReadReply decodes a single RESP2 reply. Simple strings and bulk strings are
+// returned as string, integers as int64, arrays as []interface{} and null
+// replies as nil. Error replies are returned as an Error.
+func ReadReply(r *bufio.Reader) (interface{}, error) {
+	line, err := readLine(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(line) == 0 {
+		return nil, errProtocol
+	}
+
+	switch line[0] {
+	case '+':
+		return line[1:], nil
+	case '-':
+		return nil, Error(line[1:])
+	case ':':
+		return strconv.ParseInt(line[1:], 10, 64)
+	case '$':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(line[1:])
+		if err != nil {
+			return nil, errProtocol
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		items := make([]interface{}, n)
+		for i := range items {
+			item, err := ReadReply(r)
+			// Nested error replies (e.g. inside EXEC) are kept as values
+			var replyErr Error
+			if errors.As(err, &replyErr) {
+				items[i] = replyErr
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	default:
+		return nil, errProtocol
+	}
+}
+
+// readLine reads up to and strips the trailing CRLF.
+func readLine(r *bufio.Reader) (string, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if len(line) < 2 || line[len(line)-2] != '\r' {
+		return "", errProtocol
+	}
+	return line[:len(line)-2], nil
+}
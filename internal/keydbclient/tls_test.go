@@ -0,0 +1,83 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keydbclient_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rsingh0101/keydb-operator/internal/keydbclient"
+)
+
+var _ = Describe("VerifyChain", func() {
+	newCA := func() (*x509.Certificate, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "keydb-ca"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			KeyUsage:              x509.KeyUsageCertSign,
+			BasicConstraintsValid: true,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).NotTo(HaveOccurred())
+		ca, err := x509.ParseCertificate(der)
+		Expect(err).NotTo(HaveOccurred())
+		return ca, key
+	}
+
+	It("should accept a certificate without the dialed host name", func() {
+		ca, caKey := newCA()
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).NotTo(HaveOccurred())
+		der, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+			SerialNumber: big.NewInt(2),
+			Subject:      pkix.Name{CommonName: "keydb"},
+			DNSNames:     []string{"keydb-0.keydb-headless.default.svc"},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		}, ca, &key.PublicKey, caKey)
+		Expect(err).NotTo(HaveOccurred())
+		leaf, err := x509.ParseCertificate(der)
+		Expect(err).NotTo(HaveOccurred())
+
+		roots := x509.NewCertPool()
+		roots.AddCert(ca)
+		verify := keydbclient.VerifyChain(roots)
+		Expect(verify(tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}})).To(Succeed())
+
+		other, _ := newCA()
+		otherRoots := x509.NewCertPool()
+		otherRoots.AddCert(other)
+		Expect(keydbclient.VerifyChain(otherRoots)(tls.ConnectionState{PeerCertificates: []*x509.Certificate{leaf}})).
+			NotTo(Succeed())
+		Expect(verify(tls.ConnectionState{})).To(MatchError("server presented no certificate"))
+	})
+})
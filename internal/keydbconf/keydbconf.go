@@ -0,0 +1,208 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package keydbconf renders user-supplied KeyDB directives into keydb.conf and
+// classifies them by how a change reaches a running server.
+package keydbconf
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+)
+
+// Class says how a change to a directive is applied.
+type Class int
+
+const (
+	// HotReload directives are applied to running pods with CONFIG SET.
+	HotReload Class = iota
+	// RestartRequired directives are only read at startup, so changing them
+	// rolls the pods one at a time.
+	RestartRequired
+	// Owned directives are set by the operator and cannot be overridden.
+	Owned
+)
+
+// owned are the directives the operator derives from the Keydb spec or
+// relies on for its scripts, probes and replication. Backups and restores
+// copy dump.rdb, appendonly.aof and appendonlydir by name, and restores turn
+// AOF off and back on around loading a dump.
+var owned = map[string]bool{
+	"bind":              true,
+	"port":              true,
+	"dir":               true,
+	"dbfilename":        true,
+	"appendonly":        true,
+	"appendfilename":    true,
+	"appenddirname":     true,
+	"replicaof":         true,
+	"slaveof":           true,
+	"replica-read-only": true,
+	"slave-read-only":   true,
+	"active-replica":    true,
+	"multi-master":      true,
+	"requirepass":       true,
+	"masterauth":        true,
+	"masteruser":        true,
+	"aclfile":           true,
+	"include":           true,
+	"rename-command":    true,
+	"daemonize":         true,
+	"supervised":        true,
+	"pidfile":           true,
+	"logfile":           true,
+	"unixsocket":        true,
+	"unixsocketperm":    true,
+	"cluster-enabled":   true,
+	"tls-port":          true,
+	"tls-cert-file":     true,
+	"tls-key-file":      true,
+	"tls-ca-cert-file":  true,
+	"tls-auth-clients":  true,
+	"tls-replication":   true,
+	"tls-cluster":       true,
+}
+
+// restartRequired are the directives KeyDB refuses to change with CONFIG SET.
+var restartRequired = map[string]bool{
+	"databases":                true,
+	"io-threads":               true,
+	"io-threads-do-reads":      true,
+	"server-threads":           true,
+	"server-thread-affinity":   true,
+	"storage-provider":         true,
+	"storage-provider-options": true,
+	"tcp-backlog":              true,
+	"rdbchecksum":              true,
+	"always-show-logo":         true,
+	"syslog-enabled":           true,
+	"syslog-ident":             true,
+	"syslog-facility":          true,
+	"set-proc-title":           true,
+	"proc-title-template":      true,
+	"disable-thp":              true,
+	"oom-score-adj":            true,
+	"oom-score-adj-values":     true,
+}
+
+// Classify returns the class of a directive. Directive names are case-insensitive.
+func Classify(directive string) Class {
+	directive = strings.ToLower(directive)
+	switch {
+	case owned[directive]:
+		return Owned
+	case restartRequired[directive]:
+		return RestartRequired
+	}
+	return HotReload
+}
+
+// RestartRequiredDirectives returns the restart-required directives, sorted.
+func RestartRequiredDirectives() []string {
+	directives := make([]string, 0, len(restartRequired))
+	for directive := range restartRequired {
+		directives = append(directives, directive)
+	}
+	sort.Strings(directives)
+	return directives
+}
+
+// Lines renders a directive as keydb.conf lines. An empty value is written
+// as "", and each "seconds changes" pair of save gets its own line, which is
+// the only form the config file accepts.
+func Lines(directive, value string) []string {
+	directive = strings.ToLower(directive)
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return []string{directive + ` ""`}
+	}
+	if directive == "save" {
+		fields := strings.Fields(value)
+		if len(fields)%2 == 0 {
+			lines := make([]string, 0, len(fields)/2)
+			for i := 0; i < len(fields); i += 2 {
+				lines = append(lines, "save "+fields[i]+" "+fields[i+1])
+			}
+			return lines
+		}
+	}
+	return []string{directive + " " + value}
+}
+
+// Merge applies overrides to base, a list of "directive value" lines. An
+// overridden directive keeps its place in base; new ones are appended in name
+// order so the rendered file is stable. Owned directives are ignored.
+func Merge(base []string, overrides map[string]string) []string {
+	normalized := make(map[string]string, len(overrides))
+	for directive, value := range overrides {
+		if Classify(directive) != Owned {
+			normalized[strings.ToLower(directive)] = value
+		}
+	}
+
+	merged := make([]string, 0, len(base)+len(normalized))
+	done := map[string]bool{}
+	for _, line := range base {
+		directive, _, _ := strings.Cut(line, " ")
+		directive = strings.ToLower(directive)
+		value, ok := normalized[directive]
+		if !ok {
+			merged = append(merged, line)
+			continue
+		}
+		if !done[directive] {
+			merged = append(merged, Lines(directive, value)...)
+			done[directive] = true
+		}
+	}
+
+	directives := make([]string, 0, len(normalized))
+	for directive := range normalized {
+		if !done[directive] {
+			directives = append(directives, directive)
+		}
+	}
+	sort.Strings(directives)
+	for _, directive := range directives {
+		merged = append(merged, Lines(directive, normalized[directive])...)
+	}
+	return merged
+}
+
+// RestartHash hashes the restart-required directives of overrides and the
+// names of the hot-reload ones, or returns "" when there are none. It changes
+// when the pods must be restarted to pick up the config: a restart-required
+// directive changes, or a directive is added or removed, since KeyDB cannot go
+// back to the default of a removed one.
+func RestartHash(overrides map[string]string) string {
+	var directives []string
+	for directive, value := range overrides {
+		switch Classify(directive) {
+		case RestartRequired:
+			directives = append(directives, strings.ToLower(directive)+"="+strings.TrimSpace(value))
+		case HotReload:
+			directives = append(directives, strings.ToLower(directive))
+		}
+	}
+	if len(directives) == 0 {
+		return ""
+	}
+	sort.Strings(directives)
+	sum := sha256.Sum256([]byte(strings.Join(directives, "\n")))
+	return hex.EncodeToString(sum[:])
+}
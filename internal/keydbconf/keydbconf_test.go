@@ -0,0 +1,82 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package keydbconf_test
+
+import (
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	"github.com/rsingh0101/keydb-operator/internal/keydbconf"
+)
+
+var _ = Describe("Classify", func() {
+	It("should classify directives case-insensitively", func() {
+		Expect(keydbconf.Classify("maxmemory")).To(Equal(keydbconf.HotReload))
+		Expect(keydbconf.Classify("Server-Threads")).To(Equal(keydbconf.RestartRequired))
+		Expect(keydbconf.Classify("REPLICAOF")).To(Equal(keydbconf.Owned))
+	})
+})
+
+var _ = Describe("Merge", func() {
+	base := []string{"dir /bitnami/keydb/data", "port 6379", "loglevel notice", "appendonly yes"}
+
+	It("should override defaults in place and append the rest in order", func() {
+		Expect(keydbconf.Merge(base, map[string]string{
+			"maxmemory-policy": "allkeys-lru",
+			"LogLevel":         "warning",
+			"maxmemory":        "1gb",
+		})).To(Equal([]string{
+			"dir /bitnami/keydb/data",
+			"port 6379",
+			"loglevel warning",
+			"appendonly yes",
+			"maxmemory 1gb",
+			"maxmemory-policy allkeys-lru",
+		}))
+	})
+
+	It("should ignore owned directives", func() {
+		Expect(keydbconf.Merge(base, map[string]string{"port": "6380", "dir": "/tmp"})).To(Equal(base))
+	})
+
+	It("should split save rules into one line per pair", func() {
+		Expect(keydbconf.Merge(nil, map[string]string{"save": "900 1 300 10"})).To(Equal([]string{
+			"save 900 1",
+			"save 300 10",
+		}))
+		Expect(keydbconf.Merge(nil, map[string]string{"save": ""})).To(Equal([]string{`save ""`}))
+	})
+})
+
+var _ = Describe("RestartHash", func() {
+	It("should only change with restart-required directives", func() {
+		Expect(keydbconf.RestartHash(nil)).To(BeEmpty())
+		Expect(keydbconf.RestartHash(map[string]string{"port": "6380"})).To(BeEmpty())
+
+		hash := keydbconf.RestartHash(map[string]string{"server-threads": "2", "maxmemory": "1gb"})
+		Expect(hash).NotTo(BeEmpty())
+		Expect(keydbconf.RestartHash(map[string]string{"server-threads": "2", "maxmemory": "2gb"})).To(Equal(hash))
+		Expect(keydbconf.RestartHash(map[string]string{"server-threads": "4"})).NotTo(Equal(hash))
+	})
+
+	It("should change when a hot-reload directive is removed", func() {
+		hash := keydbconf.RestartHash(map[string]string{"maxmemory": "1gb", "maxmemory-policy": "allkeys-lru"})
+		Expect(hash).NotTo(BeEmpty())
+		Expect(keydbconf.RestartHash(map[string]string{"maxmemory": "2gb", "maxmemory-policy": "noeviction"})).To(Equal(hash))
+		Expect(keydbconf.RestartHash(map[string]string{"maxmemory": "1gb"})).NotTo(Equal(hash))
+	})
+})
@@ -0,0 +1,197 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package metrics exports the state of the managed Keydb clusters on the
+// manager's metrics endpoint, next to the controller-runtime metrics.
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	crmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+const metricsNamespace = "keydb_operator"
+
+// collectTimeout bounds how long a scrape waits for the informer cache.
+const collectTimeout = 5 * time.Second
+
+var log = logf.Log.WithName("metrics")
+
+var (
+	// Failovers counts the replicas promoted to primary by the operator.
+	Failovers = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "keydb_failovers_total",
+		Help:      "Number of times the operator promoted a replica after losing the primary.",
+	}, []string{"namespace", "keydb"})
+
+	// ReconcileStepDuration is how long generating and applying one kind of
+	// resource took during a Keydb reconcile.
+	ReconcileStepDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "reconcile_step_duration_seconds",
+		Help:      "Time taken by each step of a Keydb reconcile, by generated resource.",
+		Buckets:   []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10},
+	}, []string{"resource"})
+)
+
+var (
+	desiredReplicasDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "keydb", "desired_replicas"),
+		"Number of KeyDB pods requested in spec.replicas.",
+		[]string{"namespace", "keydb"}, nil)
+	readyReplicasDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "keydb", "ready_replicas"),
+		"Number of KeyDB pods that are ready.",
+		[]string{"namespace", "keydb"}, nil)
+	phaseDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "keydb", "phase"),
+		"The current phase of the Keydb; always 1, the phase is in the label.",
+		[]string{"namespace", "keydb", "phase"}, nil)
+	lagBytesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "keydb", "replication_lag_bytes"),
+		"How far a replica is behind its in-cluster primary.",
+		[]string{"namespace", "keydb", "pod"}, nil)
+	lagSecondsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "keydb", "replication_lag_seconds"),
+		"Time since a replica last heard from its primary.",
+		[]string{"namespace", "keydb", "pod"}, nil)
+	configReloadFailuresDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "keydb", "config_reload_failures"),
+		"Number of keydb.conf directives a pod's config reloader failed to apply.",
+		[]string{"namespace", "keydb", "pod"}, nil)
+	lastBackupDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(metricsNamespace, "keydb", "last_successful_backup_timestamp_seconds"),
+		"Completion time of the latest completed KeydbBackup of the Keydb.",
+		[]string{"namespace", "keydb"}, nil)
+)
+
+// Register adds the operator's metrics to the controller-runtime registry.
+// The per-Keydb gauges are read from reader, normally the manager's cache,
+// on every scrape, so they survive operator restarts and disappear with
+// their Keydb.
+func Register(reader client.Reader) error {
+	for _, c := range []prometheus.Collector{Failovers, ReconcileStepDuration, NewClusterCollector(reader)} {
+		if err := crmetrics.Registry.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ObserveStep records the duration of a reconcile step started at start.
+func ObserveStep(resource string, start time.Time) {
+	ReconcileStepDuration.WithLabelValues(resource).Observe(time.Since(start).Seconds())
+}
+
+// Forget drops the series the operator keeps in memory for a deleted Keydb.
+func Forget(namespace, name string) {
+	Failovers.DeletePartialMatch(prometheus.Labels{"namespace": namespace, "keydb": name})
+}
+
+// ClusterCollector reports the status of every Keydb and its backups.
+type ClusterCollector struct {
+	reader client.Reader
+}
+
+// NewClusterCollector returns a collector listing Keydbs and KeydbBackups
+// from reader.
+func NewClusterCollector(reader client.Reader) *ClusterCollector {
+	return &ClusterCollector{reader: reader}
+}
+
+// Describe implements prometheus.Collector.
+func (c *ClusterCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		desiredReplicasDesc, readyReplicasDesc, phaseDesc, lagBytesDesc,
+		lagSecondsDesc, configReloadFailuresDesc, lastBackupDesc,
+	} {
+		ch <- d
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (c *ClusterCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	var keydbs keydbv1.KeydbList
+	if err := c.reader.List(ctx, &keydbs); err != nil {
+		log.Error(err, "unable to list Keydbs")
+		return
+	}
+	for i := range keydbs.Items {
+		collectKeydb(ch, &keydbs.Items[i])
+	}
+
+	var backups keydbv1.KeydbBackupList
+	if err := c.reader.List(ctx, &backups); err != nil {
+		log.Error(err, "unable to list KeydbBackups")
+		return
+	}
+	type keydbKey struct{ namespace, name string }
+	latest := map[keydbKey]time.Time{}
+	for _, b := range backups.Items {
+		if b.Status.Phase != keydbv1.BackupPhaseCompleted || b.Status.CompletionTime == nil {
+			continue
+		}
+		key := keydbKey{b.Namespace, b.Spec.KeydbName}
+		if t := b.Status.CompletionTime.Time; t.After(latest[key]) {
+			latest[key] = t
+		}
+	}
+	for key, t := range latest {
+		ch <- prometheus.MustNewConstMetric(lastBackupDesc, prometheus.GaugeValue,
+			float64(t.Unix()), key.namespace, key.name)
+	}
+}
+
+func collectKeydb(ch chan<- prometheus.Metric, k *keydbv1.Keydb) {
+	desired := keydbv1.DefaultReplicas
+	if k.Spec.Replicas != nil {
+		desired = *k.Spec.Replicas
+	}
+	ch <- prometheus.MustNewConstMetric(desiredReplicasDesc, prometheus.GaugeValue,
+		float64(desired), k.Namespace, k.Name)
+	ch <- prometheus.MustNewConstMetric(readyReplicasDesc, prometheus.GaugeValue,
+		float64(k.Status.ReadyReplicas), k.Namespace, k.Name)
+	if k.Status.Phase != "" {
+		ch <- prometheus.MustNewConstMetric(phaseDesc, prometheus.GaugeValue,
+			1, k.Namespace, k.Name, k.Status.Phase)
+	}
+
+	for _, node := range k.Status.Nodes {
+		if node.LagBytes != nil {
+			ch <- prometheus.MustNewConstMetric(lagBytesDesc, prometheus.GaugeValue,
+				float64(*node.LagBytes), k.Namespace, k.Name, node.Pod)
+		}
+		if node.LagSeconds != nil {
+			ch <- prometheus.MustNewConstMetric(lagSecondsDesc, prometheus.GaugeValue,
+				float64(*node.LagSeconds), k.Namespace, k.Name, node.Pod)
+		}
+	}
+	for _, reload := range k.Status.ConfigReload {
+		ch <- prometheus.MustNewConstMetric(configReloadFailuresDesc, prometheus.GaugeValue,
+			float64(len(reload.Failed)), k.Namespace, k.Name, reload.Pod)
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics_test
+
+import (
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	"github.com/rsingh0101/keydb-operator/internal/metrics"
+)
+
+var _ = Describe("ClusterCollector", func() {
+	It("should report the status of every Keydb and its latest backup", func() {
+		scheme := runtime.NewScheme()
+		Expect(keydbv1.AddToScheme(scheme)).To(Succeed())
+
+		replicas := int32(3)
+		lag := int64(512)
+		keydb := &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "cache", Namespace: "prod"},
+			Spec:       keydbv1.KeydbSpec{Replicas: &replicas},
+			Status: keydbv1.KeydbStatus{
+				Phase:         "Scaling",
+				ReadyReplicas: 2,
+				Nodes: []keydbv1.NodeStatus{
+					{Pod: "cache-0"},
+					{Pod: "cache-1", LagBytes: &lag},
+				},
+				ConfigReload: []keydbv1.ConfigReloadStatus{
+					{Pod: "cache-0", Failed: []keydbv1.DirectiveError{{Directive: "maxmemory-policy", Error: "ERR"}}},
+				},
+			},
+		}
+		backup := func(name, phase string, completed time.Time) *keydbv1.KeydbBackup {
+			return &keydbv1.KeydbBackup{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "prod"},
+				Spec:       keydbv1.KeydbBackupSpec{KeydbName: "cache"},
+				Status: keydbv1.KeydbBackupStatus{
+					Phase:          phase,
+					CompletionTime: &metav1.Time{Time: completed},
+				},
+			}
+		}
+		reader := fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			keydb,
+			backup("nightly-1", keydbv1.BackupPhaseCompleted, time.Unix(1700000000, 0)),
+			backup("nightly-2", keydbv1.BackupPhaseCompleted, time.Unix(1700086400, 0)),
+			backup("nightly-3", keydbv1.BackupPhaseFailed, time.Unix(1700172800, 0)),
+		).Build()
+
+		expected := `
+# HELP keydb_operator_keydb_config_reload_failures Number of keydb.conf directives a pod's config reloader failed to apply.
+# TYPE keydb_operator_keydb_config_reload_failures gauge
+keydb_operator_keydb_config_reload_failures{keydb="cache",namespace="prod",pod="cache-0"} 1
+# HELP keydb_operator_keydb_desired_replicas Number of KeyDB pods requested in spec.replicas.
+# TYPE keydb_operator_keydb_desired_replicas gauge
+keydb_operator_keydb_desired_replicas{keydb="cache",namespace="prod"} 3
+# HELP keydb_operator_keydb_last_successful_backup_timestamp_seconds Completion time of the latest completed KeydbBackup of the Keydb.
+# TYPE keydb_operator_keydb_last_successful_backup_timestamp_seconds gauge
+keydb_operator_keydb_last_successful_backup_timestamp_seconds{keydb="cache",namespace="prod"} 1.7000864e+09
+# HELP keydb_operator_keydb_phase The current phase of the Keydb; always 1, the phase is in the label.
+# TYPE keydb_operator_keydb_phase gauge
+keydb_operator_keydb_phase{keydb="cache",namespace="prod",phase="Scaling"} 1
+# HELP keydb_operator_keydb_ready_replicas Number of KeyDB pods that are ready.
+# TYPE keydb_operator_keydb_ready_replicas gauge
+keydb_operator_keydb_ready_replicas{keydb="cache",namespace="prod"} 2
+# HELP keydb_operator_keydb_replication_lag_bytes How far a replica is behind its in-cluster primary.
+# TYPE keydb_operator_keydb_replication_lag_bytes gauge
+keydb_operator_keydb_replication_lag_bytes{keydb="cache",namespace="prod",pod="cache-1"} 512
+`
+		Expect(testutil.CollectAndCompare(metrics.NewClusterCollector(reader), strings.NewReader(expected))).To(Succeed())
+	})
+})
+
+var _ = Describe("Failovers", func() {
+	It("should forget the failovers of a deleted Keydb", func() {
+		metrics.Failovers.WithLabelValues("prod", "cache").Inc()
+		metrics.Failovers.WithLabelValues("prod", "sessions").Inc()
+		Expect(testutil.ToFloat64(metrics.Failovers.WithLabelValues("prod", "cache"))).To(Equal(1.0))
+
+		metrics.Forget("prod", "cache")
+		Expect(testutil.CollectAndCount(metrics.Failovers)).To(Equal(1))
+	})
+})
@@ -0,0 +1,519 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+	"github.com/rsingh0101/keydb-operator/internal/keydbconf"
+)
+
+// configDirective matches keydb.conf directive names.
+var configDirective = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9-]*$`)
+
+// log is for logging in this package.
+var keydblog = logf.Log.WithName("keydb-resource")
+
+// SetupKeydbWebhookWithManager registers the webhook for Keydb in the manager.
+func SetupKeydbWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&keydbv1.Keydb{}).
+		WithValidator(&KeydbCustomValidator{Client: mgr.GetClient()}).
+		WithDefaulter(&KeydbCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-keydb-keydb-v1-keydb,mutating=true,failurePolicy=fail,sideEffects=None,groups=keydb.keydb,resources=keydbs,verbs=create;update,versions=v1,name=mkeydb-v1.kb.io,admissionReviewVersions=v1
+
+// KeydbCustomDefaulter sets default values on the Keydb resource when it is
+// created or updated, so the stored object reflects what the operator deploys.
+type KeydbCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &KeydbCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter so a webhook will be registered for the Kind Keydb.
+func (d *KeydbCustomDefaulter) Default(_ context.Context, obj runtime.Object) error {
+	keydb, ok := obj.(*keydbv1.Keydb)
+	if !ok {
+		return fmt.Errorf("expected a Keydb object but got %T", obj)
+	}
+	keydblog.Info("Defaulting for Keydb", "name", keydb.GetName())
+
+	if keydb.Spec.Image == "" {
+		switch keydb.Spec.ImageFlavor {
+		case keydbv1.ImageFlavorCustom:
+			// There is no default custom image; validation asks for one
+		case keydbv1.ImageFlavorUpstream:
+			keydb.Spec.Image = keydbv1.DefaultUpstreamImage
+		default:
+			keydb.Spec.Image = keydbv1.DefaultImage
+		}
+	}
+	if keydb.Spec.Replicas == nil {
+		replicas := keydbv1.DefaultReplicas
+		keydb.Spec.Replicas = &replicas
+	}
+	if keydb.Spec.Replication.Port == 0 {
+		keydb.Spec.Replication.Port = keydbv1.DefaultPort
+	}
+	if keydb.Spec.Metrics.Enabled && keydb.Spec.Metrics.Image == "" {
+		keydb.Spec.Metrics.Image = keydbv1.DefaultMetricsImage
+	}
+
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-keydb-keydb-v1-keydb,mutating=false,failurePolicy=fail,sideEffects=None,groups=keydb.keydb,resources=keydbs,verbs=create;update,versions=v1,name=vkeydb-v1.kb.io,admissionReviewVersions=v1
+
+// KeydbCustomValidator rejects Keydb specs that would otherwise only fail
+// deep inside Reconcile. The client is used to look up the PasswordSecret.
+type KeydbCustomValidator struct {
+	Client client.Reader
+}
+
+var _ webhook.CustomValidator = &KeydbCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator so a webhook will be registered for the type Keydb.
+func (v *KeydbCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	keydb, ok := obj.(*keydbv1.Keydb)
+	if !ok {
+		return nil, fmt.Errorf("expected a Keydb object but got %T", obj)
+	}
+	keydblog.Info("Validation for Keydb upon creation", "name", keydb.GetName())
+
+	warnings, allErrs := v.validateSpec(ctx, keydb)
+	return warnings, toAggregate(keydb, allErrs)
+}
+
+// ValidateUpdate implements webhook.CustomValidator so a webhook will be registered for the type Keydb.
+func (v *KeydbCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	keydb, ok := newObj.(*keydbv1.Keydb)
+	if !ok {
+		return nil, fmt.Errorf("expected a Keydb object for the newObj but got %T", newObj)
+	}
+	oldKeydb, ok := oldObj.(*keydbv1.Keydb)
+	if !ok {
+		return nil, fmt.Errorf("expected a Keydb object for the oldObj but got %T", oldObj)
+	}
+	keydblog.Info("Validation for Keydb upon update", "name", keydb.GetName())
+
+	// Allow finalizer removal and status-only churn on objects being deleted
+	if !keydb.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+
+	warnings, allErrs := v.validateSpec(ctx, keydb)
+	allErrs = append(allErrs, validateImmutableFields(oldKeydb, keydb)...)
+	if keydbconf.RestartHash(oldKeydb.Spec.Config) != keydbconf.RestartHash(keydb.Spec.Config) {
+		warnings = append(warnings, "spec.config adds or removes directives, or changes ones KeyDB only reads at startup; pods are restarted one at a time")
+	}
+	if isExternal(oldKeydb.Spec.Service.Type) != isExternal(keydb.Spec.Service.Type) {
+		warnings = append(warnings, fmt.Sprintf("changing spec.service.type between ClusterIP and %s recreates Service %s-svc; "+
+			"clients using it are disconnected briefly", keydb.Spec.Service.Type, keydb.Name))
+	}
+	if (oldKeydb.Spec.TLS == nil) != (keydb.Spec.TLS == nil) {
+		warnings = append(warnings, "toggling spec.tls restarts every pod and switches port 6379 between plaintext and TLS; "+
+			"replication is interrupted until all pods have restarted and clients must switch as well")
+	}
+	return warnings, toAggregate(keydb, allErrs)
+}
+
+// ValidateDelete implements webhook.CustomValidator so a webhook will be registered for the type Keydb.
+func (v *KeydbCustomValidator) ValidateDelete(_ context.Context, _ runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validateSpec checks the fields that GenerateKeydbConfigMap and
+// GenerateStatefulSet rely on being well formed.
+func (v *KeydbCustomValidator) validateSpec(ctx context.Context, keydb *keydbv1.Keydb) (admission.Warnings, field.ErrorList) {
+	var warnings admission.Warnings
+	var allErrs field.ErrorList
+	specPath := field.NewPath("spec")
+
+	modePath := specPath.Child("replication", "mode")
+	switch keydb.Spec.Replication.Mode {
+	case "", keydbv1.ReplicationModeMasterReplica, keydbv1.ReplicationModeMasterMaster:
+	default:
+		allErrs = append(allErrs, field.NotSupported(modePath, keydb.Spec.Replication.Mode,
+			[]string{keydbv1.ReplicationModeMasterReplica, keydbv1.ReplicationModeMasterMaster}))
+	}
+	if keydb.Spec.Replication.Mode == keydbv1.ReplicationModeMasterReplica && len(keydb.Spec.Replication.Domain) > 0 {
+		warnings = append(warnings, "spec.replication.domain is ignored in master-replica mode")
+	}
+	if port := keydb.Spec.Replication.Port; port < 0 || port > 65535 {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("replication", "port"), port,
+			"must be a valid port number"))
+	}
+
+	persistencePath := specPath.Child("persistence")
+	if size := keydb.Spec.Persistence.Size; size != "" {
+		if _, err := resource.ParseQuantity(size); err != nil {
+			allErrs = append(allErrs, field.Invalid(persistencePath.Child("size"), size, err.Error()))
+		}
+	} else if keydb.Spec.Persistence.Enabled {
+		allErrs = append(allErrs, field.Required(persistencePath.Child("size"),
+			"size is required when persistence is enabled"))
+	}
+
+	allErrs = append(allErrs, validateImageProfile(keydb, specPath)...)
+	allErrs = append(allErrs, validateConfig(keydb.Spec.Config, specPath.Child("config"))...)
+	allErrs = append(allErrs, validateService(keydb.Spec.Service, specPath.Child("service"))...)
+	allErrs = append(allErrs, validateMetrics(keydb.Spec.Metrics, specPath.Child("metrics"))...)
+	if keydb.Spec.PodTemplate != nil {
+		allErrs = append(allErrs, validatePodTemplate(keydb.Spec.PodTemplate, specPath.Child("podTemplate"))...)
+	}
+	allErrs = append(allErrs, validateTopologySpread(keydb.Spec.TopologySpreadConstraints, specPath.Child("topologySpreadConstraints"))...)
+	if keydb.Spec.SecurityContext != nil {
+		allErrs = append(allErrs, validateSecurityContext(keydb.Spec.SecurityContext, specPath.Child("securityContext"))...)
+	}
+
+	if tls := keydb.Spec.TLS; tls != nil && (tls.SecretName == "") == (tls.CertManager == nil) {
+		allErrs = append(allErrs, field.Invalid(specPath.Child("tls"), "",
+			"exactly one of secretName or certManager must be set"))
+	}
+
+	if keydb.Spec.Restore != nil {
+		allErrs = append(allErrs, validateRestore(keydb, specPath.Child("restore"))...)
+	}
+
+	if _, ok := keydb.Annotations[keydbv1.AnnotationRotatePassword]; ok && keydb.Spec.PasswordSecret != nil {
+		allErrs = append(allErrs, field.Invalid(field.NewPath("metadata", "annotations").Key(keydbv1.AnnotationRotatePassword), "",
+			"only the generated password Secret can be rotated; remove spec.passwordSecret or the annotation"))
+	}
+
+	if keydb.Spec.PasswordSecret != nil {
+		secretWarnings, secretErrs := v.validatePasswordSecret(ctx, keydb, specPath.Child("passwordSecret"))
+		warnings = append(warnings, secretWarnings...)
+		allErrs = append(allErrs, secretErrs...)
+	}
+
+	return warnings, allErrs
+}
+
+// validatePasswordSecret makes sure the referenced Secret carries the key the
+// pods mount as their password. A Secret that does not exist yet is only a
+// warning, since it may be applied together with the Keydb.
+func (v *KeydbCustomValidator) validatePasswordSecret(
+	ctx context.Context,
+	keydb *keydbv1.Keydb,
+	path *field.Path,
+) (admission.Warnings, field.ErrorList) {
+	ref := keydb.Spec.PasswordSecret
+	var allErrs field.ErrorList
+	if ref.Name == "" {
+		allErrs = append(allErrs, field.Required(path.Child("name"), "secret name is required"))
+	}
+	if ref.Key == "" {
+		allErrs = append(allErrs, field.Required(path.Child("key"), "secret key is required"))
+	}
+	if len(allErrs) > 0 || v.Client == nil {
+		return nil, allErrs
+	}
+
+	var secret corev1.Secret
+	err := v.Client.Get(ctx, types.NamespacedName{Name: ref.Name, Namespace: keydb.Namespace}, &secret)
+	switch {
+	case apierrors.IsNotFound(err):
+		return admission.Warnings{fmt.Sprintf("password secret %q does not exist yet; pods will not start until it is created", ref.Name)}, nil
+	case err != nil:
+		return nil, field.ErrorList{field.InternalError(path, err)}
+	}
+
+	if _, ok := secret.Data[ref.Key]; !ok {
+		if _, ok := secret.StringData[ref.Key]; !ok {
+			allErrs = append(allErrs, field.Invalid(path.Child("key"), ref.Key,
+				fmt.Sprintf("key not found in secret %q", ref.Name)))
+		}
+	}
+	return nil, allErrs
+}
+
+// validateImageProfile checks that a custom image is described completely
+// enough to mount keydb.conf and the data into it.
+func validateImageProfile(keydb *keydbv1.Keydb, specPath *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	profile := keydb.Spec.ImageProfile
+	if keydb.Spec.ImageFlavor != keydbv1.ImageFlavorCustom {
+		if profile != nil {
+			allErrs = append(allErrs, field.Forbidden(specPath.Child("imageProfile"),
+				"only applies to the custom image flavor"))
+		}
+		return allErrs
+	}
+	if keydb.Spec.Image == "" {
+		allErrs = append(allErrs, field.Required(specPath.Child("image"), "required for the custom image flavor"))
+	}
+	if profile == nil {
+		return allErrs
+	}
+	profilePath := specPath.Child("imageProfile")
+	for _, dir := range []struct {
+		name, value string
+	}{
+		{"configDir", profile.ConfigDir},
+		{"dataDir", profile.DataDir},
+		{"envScript", profile.EnvScript},
+	} {
+		if dir.value != "" && !path.IsAbs(dir.value) {
+			allErrs = append(allErrs, field.Invalid(profilePath.Child(dir.name), dir.value, "must be an absolute path"))
+		}
+	}
+	if profile.ConfigDir != "" && profile.ConfigDir == profile.DataDir {
+		allErrs = append(allErrs, field.Invalid(profilePath.Child("dataDir"), profile.DataDir,
+			"must differ from configDir, which is mounted read-only"))
+	}
+	return allErrs
+}
+
+// validateMetrics rejects monitoring objects that would have nothing to
+// scrape and scrape timeouts Prometheus refuses.
+func validateMetrics(metrics keydbv1.MetricsSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if !metrics.Enabled {
+		if metrics.ServiceMonitor != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("serviceMonitor"), "", "requires spec.metrics.enabled"))
+		}
+		if metrics.PrometheusRule != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("prometheusRule"), "", "requires spec.metrics.enabled"))
+		}
+	}
+	if sm := metrics.ServiceMonitor; sm != nil && sm.Interval != "" && sm.ScrapeTimeout != "" {
+		interval, intervalErr := time.ParseDuration(sm.Interval)
+		timeout, timeoutErr := time.ParseDuration(sm.ScrapeTimeout)
+		if intervalErr == nil && timeoutErr == nil && timeout > interval {
+			allErrs = append(allErrs, field.Invalid(path.Child("serviceMonitor", "scrapeTimeout"), sm.ScrapeTimeout,
+				"must not exceed the interval"))
+		}
+	}
+	return allErrs
+}
+
+// validateService rejects settings Kubernetes ignores or refuses for the
+// chosen Service type.
+func validateService(service keydbv1.ServiceSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if service.ExternalTrafficPolicy != "" && !isExternal(service.Type) {
+		allErrs = append(allErrs, field.Invalid(path.Child("externalTrafficPolicy"), service.ExternalTrafficPolicy,
+			"only applies to NodePort and LoadBalancer Services"))
+	}
+	rangesPath := path.Child("loadBalancerSourceRanges")
+	if len(service.LoadBalancerSourceRanges) > 0 && service.Type != corev1.ServiceTypeLoadBalancer {
+		allErrs = append(allErrs, field.Invalid(rangesPath, service.LoadBalancerSourceRanges,
+			"only applies to LoadBalancer Services"))
+	}
+	for i, cidr := range service.LoadBalancerSourceRanges {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			allErrs = append(allErrs, field.Invalid(rangesPath.Index(i), cidr, "must be a CIDR such as 10.0.0.0/8"))
+		}
+	}
+	return allErrs
+}
+
+// validatePodTemplate rejects overrides of the labels the operator selects
+// pods by, which would be silently dropped, and containers without a name,
+// which cannot be merged.
+func validatePodTemplate(override *keydbv1.PodTemplateOverride, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for _, key := range []string{"apps", keydbv1.LabelRole, keydbv1.LabelTraffic} {
+		if _, ok := override.Labels[key]; ok {
+			allErrs = append(allErrs, field.Forbidden(path.Child("labels").Key(key), "set by the operator"))
+		}
+	}
+	for _, list := range []struct {
+		name       string
+		containers []corev1.Container
+	}{
+		{"containers", override.Containers},
+		{"initContainers", override.InitContainers},
+	} {
+		for i, c := range list.containers {
+			if c.Name == "" {
+				allErrs = append(allErrs, field.Required(path.Child(list.name).Index(i).Child("name"), "containers are merged by name"))
+			}
+		}
+	}
+	for i, v := range override.Volumes {
+		if v.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("volumes").Index(i).Child("name"), "volumes are merged by name"))
+		}
+	}
+	return allErrs
+}
+
+// validateTopologySpread catches constraints the StatefulSet would be
+// rejected for, which would otherwise only show up at reconcile time.
+func validateTopologySpread(constraints []corev1.TopologySpreadConstraint, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	for i, c := range constraints {
+		if c.TopologyKey == "" {
+			allErrs = append(allErrs, field.Required(path.Index(i).Child("topologyKey"), "e.g. topology.kubernetes.io/zone"))
+		}
+		if c.MaxSkew < 1 {
+			allErrs = append(allErrs, field.Invalid(path.Index(i).Child("maxSkew"), c.MaxSkew, "must be at least 1"))
+		}
+		switch c.WhenUnsatisfiable {
+		case corev1.DoNotSchedule, corev1.ScheduleAnyway:
+		default:
+			allErrs = append(allErrs, field.NotSupported(path.Index(i).Child("whenUnsatisfiable"), c.WhenUnsatisfiable,
+				[]string{string(corev1.DoNotSchedule), string(corev1.ScheduleAnyway)}))
+		}
+	}
+	return allErrs
+}
+
+// validateSecurityContext rejects seccomp profiles the restricted Pod
+// Security Standard does not allow.
+func validateSecurityContext(sc *keydbv1.SecurityContextSpec, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	if p := sc.SeccompProfile; p != nil {
+		switch p.Type {
+		case corev1.SeccompProfileTypeRuntimeDefault:
+		case corev1.SeccompProfileTypeLocalhost:
+			if p.LocalhostProfile == nil || *p.LocalhostProfile == "" {
+				allErrs = append(allErrs, field.Required(path.Child("seccompProfile", "localhostProfile"),
+					"required when type is Localhost"))
+			}
+		default:
+			allErrs = append(allErrs, field.NotSupported(path.Child("seccompProfile", "type"), p.Type,
+				[]string{string(corev1.SeccompProfileTypeRuntimeDefault), string(corev1.SeccompProfileTypeLocalhost)}))
+		}
+	}
+	return allErrs
+}
+
+// isExternal reports whether a Service type is reachable from outside the cluster.
+func isExternal(t corev1.ServiceType) bool {
+	return t == corev1.ServiceTypeNodePort || t == corev1.ServiceTypeLoadBalancer
+}
+
+// validateConfig rejects directives the operator manages and values that
+// would not render as a single keydb.conf directive.
+func validateConfig(config map[string]string, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	directives := make([]string, 0, len(config))
+	for directive := range config {
+		directives = append(directives, directive)
+	}
+	sort.Strings(directives)
+
+	for _, directive := range directives {
+		value := config[directive]
+		directivePath := path.Key(directive)
+		switch {
+		case !configDirective.MatchString(directive):
+			allErrs = append(allErrs, field.Invalid(directivePath, directive, "must be a keydb.conf directive name"))
+		case keydbconf.Classify(directive) == keydbconf.Owned:
+			allErrs = append(allErrs, field.Forbidden(directivePath, "managed by the operator"))
+		case strings.ContainsAny(value, "\r\n"):
+			allErrs = append(allErrs, field.Invalid(directivePath, value, "must be a single line"))
+		case strings.EqualFold(directive, "save") && len(strings.Fields(value))%2 != 0:
+			allErrs = append(allErrs, field.Invalid(directivePath, value, `must be "seconds changes" pairs, or empty to disable snapshots`))
+		}
+	}
+	return allErrs
+}
+
+// validateRestore requires exactly one restore source and a data volume to
+// restore into.
+func validateRestore(keydb *keydbv1.Keydb, path *field.Path) field.ErrorList {
+	var allErrs field.ErrorList
+	restore := keydb.Spec.Restore
+
+	sources := 0
+	if restore.BackupName != "" {
+		sources++
+	}
+	if restore.S3 != nil {
+		sources++
+	}
+	if restore.PersistentVolumeClaim != nil {
+		sources++
+	}
+	if sources != 1 {
+		allErrs = append(allErrs, field.Invalid(path, sources,
+			"exactly one of backupName, s3 or persistentVolumeClaim must be set"))
+	}
+	if !keydb.Spec.Persistence.Enabled {
+		allErrs = append(allErrs, field.Forbidden(path,
+			"restore requires persistence.enabled, otherwise the restored data is lost on the first restart"))
+	}
+	return allErrs
+}
+
+// validateImmutableFields rejects changes the StatefulSet cannot roll out,
+// since volumeClaimTemplates are immutable once created.
+func validateImmutableFields(oldKeydb, keydb *keydbv1.Keydb) field.ErrorList {
+	var allErrs field.ErrorList
+	persistencePath := field.NewPath("spec", "persistence")
+
+	if oldKeydb.Spec.Persistence.Enabled != keydb.Spec.Persistence.Enabled {
+		allErrs = append(allErrs, field.Forbidden(persistencePath.Child("enabled"),
+			"persistence cannot be toggled after creation"))
+	}
+	if oldKeydb.Spec.Persistence.StorageClassName != keydb.Spec.Persistence.StorageClassName {
+		allErrs = append(allErrs, field.Forbidden(persistencePath.Child("storageClassName"),
+			"storageClassName is immutable"))
+	}
+
+	// A KeydbRestore relies on the topology it started with
+	if holder, restoring := oldKeydb.Annotations[keydbv1.AnnotationRestore]; restoring &&
+		!equality.Semantic.DeepEqual(oldKeydb.Spec, keydb.Spec) {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec"),
+			fmt.Sprintf("spec cannot be changed while KeydbRestore %s is in progress", holder)))
+	}
+
+	// The restore source can only be corrected while nothing was restored yet
+	if !equality.Semantic.DeepEqual(oldKeydb.Spec.Restore, keydb.Spec.Restore) && oldKeydb.Status.Restore != nil {
+		switch oldKeydb.Status.Restore.Phase {
+		case keydbv1.RestorePhasePending, keydbv1.RestorePhaseFailed:
+		default:
+			allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "restore"),
+				fmt.Sprintf("restore cannot be changed once it is %s", oldKeydb.Status.Restore.Phase)))
+		}
+	}
+
+	return allErrs
+}
+
+// toAggregate wraps field errors into the Invalid status error the API server
+// returns to kubectl.
+func toAggregate(keydb *keydbv1.Keydb, allErrs field.ErrorList) error {
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(keydbv1.GroupVersion.WithKind("Keydb").GroupKind(), keydb.Name, allErrs)
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2025.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	keydbv1 "github.com/rsingh0101/keydb-operator/api/v1"
+)
+
+var _ = Describe("Keydb Webhook", func() {
+	var (
+		ctx       context.Context
+		obj       *keydbv1.Keydb
+		oldObj    *keydbv1.Keydb
+		validator KeydbCustomValidator
+		defaulter KeydbCustomDefaulter
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		obj = &keydbv1.Keydb{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb", Namespace: "default"},
+		}
+		oldObj = obj.DeepCopy()
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "keydb-secret", Namespace: "default"},
+			Data:       map[string][]byte{"password": []byte("s3cr3t")},
+		}
+		validator = KeydbCustomValidator{
+			Client: fake.NewClientBuilder().WithScheme(testScheme).WithObjects(secret).Build(),
+		}
+		defaulter = KeydbCustomDefaulter{}
+	})
+
+	Context("When creating Keydb under Defaulting Webhook", func() {
+		It("Should apply defaults when fields are unset", func() {
+			obj.Spec.Metrics.Enabled = true
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Image).To(Equal(keydbv1.DefaultImage))
+			Expect(obj.Spec.Replicas).NotTo(BeNil())
+			Expect(*obj.Spec.Replicas).To(Equal(keydbv1.DefaultReplicas))
+			Expect(obj.Spec.Replication.Port).To(Equal(keydbv1.DefaultPort))
+			Expect(obj.Spec.Metrics.Image).To(Equal(keydbv1.DefaultMetricsImage))
+		})
+
+		It("Should keep values that are already set", func() {
+			replicas := int32(3)
+			obj.Spec.Image = "eqalpha/keydb:latest"
+			obj.Spec.Replicas = &replicas
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Image).To(Equal("eqalpha/keydb:latest"))
+			Expect(*obj.Spec.Replicas).To(Equal(int32(3)))
+			Expect(obj.Spec.Metrics.Image).To(BeEmpty())
+		})
+
+		It("Should default the image of the flavor", func() {
+			obj.Spec.ImageFlavor = keydbv1.ImageFlavorUpstream
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Image).To(Equal(keydbv1.DefaultUpstreamImage))
+
+			custom := &keydbv1.Keydb{Spec: keydbv1.KeydbSpec{ImageFlavor: keydbv1.ImageFlavorCustom}}
+			Expect(defaulter.Default(ctx, custom)).To(Succeed())
+			Expect(custom.Spec.Image).To(BeEmpty())
+		})
+	})
+
+	Context("When creating or updating Keydb under Validating Webhook", func() {
+		It("Should deny creation with an unknown replication mode", func() {
+			obj.Spec.Replication.Mode = "ring"
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.replication.mode")))
+		})
+
+		It("Should deny creation with an unparsable persistence size", func() {
+			obj.Spec.Persistence = keydbv1.PersistenceSpec{Enabled: true, Size: "lots"}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.persistence.size")))
+		})
+
+		It("Should deny creation when the password secret lacks the key", func() {
+			obj.Spec.PasswordSecret = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "keydb-secret"},
+				Key:                  "pass",
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("key not found in secret")))
+		})
+
+		It("Should warn when the password secret does not exist yet", func() {
+			obj.Spec.PasswordSecret = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "missing"},
+				Key:                  "password",
+			}
+			warnings, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(HaveLen(1))
+		})
+
+		It("Should deny rotating a user-provided password secret", func() {
+			obj.Annotations = map[string]string{keydbv1.AnnotationRotatePassword: "2025-01-01T00:00:00Z"}
+			obj.Spec.PasswordSecret = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "keydb-secret"},
+				Key:                  "password",
+			}
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).To(MatchError(ContainSubstring("keydb.keydb/rotate-password")))
+
+			obj.Spec.PasswordSecret = nil
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny config directives the operator manages", func() {
+			obj.Spec.Config = map[string]string{
+				"maxmemory": "1gb",
+				"replicaof": "elsewhere 6379",
+				"Dir":       "/tmp",
+				"save":      "900",
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.config[replicaof]")))
+			Expect(err).To(MatchError(ContainSubstring("spec.config[Dir]")))
+			Expect(err).To(MatchError(ContainSubstring("spec.config[save]")))
+			Expect(err).NotTo(MatchError(ContainSubstring("spec.config[maxmemory]")))
+		})
+
+		It("Should deny config directives that rename the persistence files", func() {
+			obj.Spec.Config = map[string]string{
+				"dbfilename":     "data.rdb",
+				"appendonly":     "no",
+				"appendfilename": "data.aof",
+				"appenddirname":  "aof",
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			for _, directive := range []string{"dbfilename", "appendonly", "appendfilename", "appenddirname"} {
+				Expect(err).To(MatchError(ContainSubstring("spec.config[" + directive + "]: Forbidden")))
+			}
+		})
+
+		It("Should warn when a config change restarts the pods", func() {
+			oldObj.Spec.Config = map[string]string{"maxmemory": "512mb", "maxmemory-policy": "allkeys-lru"}
+			obj.Spec.Config = map[string]string{"maxmemory": "1gb", "maxmemory-policy": "allkeys-lru"}
+			warnings, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(BeEmpty())
+
+			By("removing a directive KeyDB cannot reset at runtime")
+			delete(obj.Spec.Config, "maxmemory-policy")
+			warnings, err = validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("restarted one at a time")))
+
+			By("changing a directive KeyDB only reads at startup")
+			obj.Spec.Config = map[string]string{"maxmemory": "1gb", "maxmemory-policy": "allkeys-lru", "server-threads": "4"}
+			oldObj.Spec.Config["server-threads"] = "2"
+			warnings, err = validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("restarted one at a time")))
+		})
+
+		It("Should admit creation with a valid spec", func() {
+			obj.Spec.Persistence = keydbv1.PersistenceSpec{Enabled: true, Size: "1Gi"}
+			obj.Spec.Replication.Mode = keydbv1.ReplicationModeMasterMaster
+			obj.Spec.PasswordSecret = &corev1.SecretKeySelector{
+				LocalObjectReference: corev1.LocalObjectReference{Name: "keydb-secret"},
+				Key:                  "password",
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny changing the storage class or toggling persistence", func() {
+			oldObj.Spec.Persistence = keydbv1.PersistenceSpec{Enabled: true, Size: "1Gi", StorageClassName: "standard"}
+			obj.Spec.Persistence = keydbv1.PersistenceSpec{Enabled: false, Size: "1Gi", StorageClassName: "fast"}
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.persistence.enabled")))
+			Expect(err).To(MatchError(ContainSubstring("spec.persistence.storageClassName")))
+		})
+
+		It("Should deny load balancer settings on other Service types", func() {
+			obj.Spec.Service = keydbv1.ServiceSpec{
+				Type:                     corev1.ServiceTypeNodePort,
+				ExternalTrafficPolicy:    corev1.ServiceExternalTrafficPolicyLocal,
+				LoadBalancerSourceRanges: []string{"10.0.0.0/8", "office"},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("only applies to LoadBalancer Services")))
+			Expect(err).To(MatchError(ContainSubstring("spec.service.loadBalancerSourceRanges[1]")))
+			Expect(err).NotTo(MatchError(ContainSubstring("spec.service.externalTrafficPolicy")))
+
+			obj.Spec.Service.Type = corev1.ServiceTypeLoadBalancer
+			obj.Spec.Service.LoadBalancerSourceRanges = []string{"10.0.0.0/8"}
+			warnings, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("recreates Service keydb-svc")))
+		})
+
+		It("Should deny incomplete topology spread constraints", func() {
+			obj.Spec.TopologySpreadConstraints = []corev1.TopologySpreadConstraint{
+				{MaxSkew: 1, TopologyKey: "topology.kubernetes.io/zone", WhenUnsatisfiable: corev1.DoNotSchedule},
+				{},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.topologySpreadConstraints[1].topologyKey")))
+			Expect(err).To(MatchError(ContainSubstring("spec.topologySpreadConstraints[1].maxSkew")))
+			Expect(err).NotTo(MatchError(ContainSubstring("spec.topologySpreadConstraints[0]")))
+		})
+
+		It("Should validate the image profile", func() {
+			obj.Spec.ImageProfile = &keydbv1.ImageProfileSpec{DataDir: "/data"}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.imageProfile: Forbidden")))
+
+			obj.Spec.ImageFlavor = keydbv1.ImageFlavorCustom
+			obj.Spec.Image = ""
+			obj.Spec.ImageProfile = &keydbv1.ImageProfileSpec{ConfigDir: "etc/keydb", DataDir: "/var/lib/keydb"}
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.image: Required")))
+			Expect(err).To(MatchError(ContainSubstring("spec.imageProfile.configDir")))
+			Expect(err).NotTo(MatchError(ContainSubstring("spec.imageProfile.dataDir")))
+
+			obj.Spec.Image = "registry.example.com/keydb:hardened"
+			obj.Spec.ImageProfile.ConfigDir = "/etc/keydb"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny monitoring without metrics", func() {
+			obj.Spec.Metrics.ServiceMonitor = &keydbv1.ServiceMonitorSpec{Interval: "15s", ScrapeTimeout: "30s"}
+			obj.Spec.Metrics.PrometheusRule = &keydbv1.PrometheusRuleSpec{}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.metrics.serviceMonitor: Invalid value")))
+			Expect(err).To(MatchError(ContainSubstring("spec.metrics.prometheusRule: Invalid value")))
+			Expect(err).To(MatchError(ContainSubstring("spec.metrics.serviceMonitor.scrapeTimeout")))
+
+			obj.Spec.Metrics.Enabled = true
+			obj.Spec.Metrics.ServiceMonitor.ScrapeTimeout = "10s"
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny seccomp profiles the restricted standard forbids", func() {
+			obj.Spec.SecurityContext = &keydbv1.SecurityContextSpec{
+				SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeUnconfined},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.securityContext.seccompProfile.type")))
+
+			obj.Spec.SecurityContext.SeccompProfile.Type = corev1.SeccompProfileTypeLocalhost
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.securityContext.seccompProfile.localhostProfile")))
+
+			obj.Spec.SecurityContext.SeccompProfile.LocalhostProfile = &[]string{"profiles/keydb.json"}[0]
+			_, err = validator.ValidateCreate(ctx, obj)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("Should deny pod template labels the operator sets", func() {
+			obj.Spec.PodTemplate = &keydbv1.PodTemplateOverride{
+				Labels:     map[string]string{"apps": "other", "cost-center": "cache"},
+				Containers: []corev1.Container{{Image: "fluent/fluent-bit"}},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.podTemplate.labels[apps]")))
+			Expect(err).To(MatchError(ContainSubstring("spec.podTemplate.containers[0].name")))
+			Expect(err).NotTo(MatchError(ContainSubstring("cost-center")))
+		})
+
+		It("Should require exactly one TLS certificate source", func() {
+			obj.Spec.TLS = &keydbv1.TLSSpec{}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.tls")))
+
+			obj.Spec.TLS.CertManager = &keydbv1.CertManagerSpec{IssuerRef: keydbv1.IssuerReference{Name: "ca-issuer"}}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should warn when TLS is toggled", func() {
+			obj.Spec.TLS = &keydbv1.TLSSpec{SecretName: "keydb-tls"}
+			warnings, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(warnings).To(ContainElement(ContainSubstring("spec.tls")))
+		})
+
+		It("Should deny a restore without exactly one source or without persistence", func() {
+			obj.Spec.Restore = &keydbv1.RestoreSpec{
+				BackupName:            "nightly",
+				PersistentVolumeClaim: &keydbv1.PVCRestoreSource{ClaimName: "old-data"},
+			}
+			_, err := validator.ValidateCreate(ctx, obj)
+			Expect(err).To(MatchError(ContainSubstring("exactly one of backupName")))
+			Expect(err).To(MatchError(ContainSubstring("restore requires persistence.enabled")))
+		})
+
+		It("Should deny spec changes while a KeydbRestore holds the Keydb", func() {
+			oldObj.Annotations = map[string]string{keydbv1.AnnotationRestore: "rollback"}
+			obj = oldObj.DeepCopy()
+			obj.Labels = map[string]string{"team": "cache"}
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+
+			obj.Spec.Image = "eqalpha/keydb:latest"
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).To(MatchError(ContainSubstring("KeydbRestore rollback is in progress")))
+		})
+
+		It("Should only allow changing the restore source before it ran", func() {
+			oldObj.Spec.Persistence = keydbv1.PersistenceSpec{Enabled: true, Size: "1Gi"}
+			oldObj.Spec.Restore = &keydbv1.RestoreSpec{BackupName: "nightly"}
+			oldObj.Status.Restore = &keydbv1.RestoreStatus{Phase: keydbv1.RestorePhaseFailed}
+			obj = oldObj.DeepCopy()
+			obj.Spec.Restore.BackupName = "weekly"
+			Expect(validator.ValidateUpdate(ctx, oldObj, obj)).Error().NotTo(HaveOccurred())
+
+			oldObj.Status.Restore.Phase = keydbv1.RestorePhaseCompleted
+			_, err := validator.ValidateUpdate(ctx, oldObj, obj)
+			Expect(err).To(MatchError(ContainSubstring("spec.restore")))
+		})
+	})
+})